@@ -2,28 +2,54 @@ package chat
 
 import (
 	"github.com/rsocket/rsocket-go/rx/flux"
+	logger "github.com/sirupsen/logrus"
 	"main/gql"
+	"main/hub"
+	"main/store"
+	"sync"
 )
 
-type Chat struct {
+// number of messages buffered for single subscriber of chat
+const MESSAGE_SUBSCRIBER_BUFFER = 100
 
-	// Chat Name set by user
-	ChatName string
+type Chat struct {
 
 	// UUID for chat
 	ChatID string
 
-	// list of all participating in chat Clients
-	clientsIPsList []string
+	// name and avatar set by admins of chat (see Metadata.go)
+	metadataMutex    sync.RWMutex
+	chatName         string
+	chatAvatar       string
+	metadataVersions map[string]store.MemberVersion
+
+	// list of all participating in chat Clients and their roles (see Roles.go)
+	participantsMutex sync.RWMutex
+	clientsIPsList    []string
+	owner             string
+	admins            map[string]bool
+	versions          map[string]store.MemberVersion
+	creation          *store.MemberVersion  // signed by owner
+	history           []store.MemberVersion // applied changes of roles
 
-	// all messages within the chat goes here
-	MessagesChan chan *gql.TextMessage
+	// all messages within the chat go to subscribers of hub, slow ones miss messages they can read from history
+	messages hub.Hub
 
 	// messages sent by Client goes here
 	SendMessageChan chan gql.TextMessage
 
-	// list of all messages (in database in the future)
-	TextMessageList []*gql.TextMessage
+	// all messages of chat are kept in store
+	store store.Store
+
+	// keys of chat (see Encryption.go), old ones are kept to read history
+	keysMutex      sync.Mutex
+	keyEpoch       uint32
+	keys           map[uint32][]byte   // epoch : used key
+	concurrentKeys map[uint32][][]byte // epoch : keys generated at the same time as the used one
+
+	// Lamport clock of chat, the highest clock of known messages
+	clockMutex sync.Mutex
+	clock      int
 
 	listiner interface{}
 	f        flux.Flux
@@ -36,15 +62,178 @@ type Chat struct {
 
 // Create new chat
 // args - ChatID: ID of chat (numeric string); clientsIPsList: list of other participants addresses (list of strings)
+// st: store keeping messages of chat
 //
-func NewChat(chatID string, clientsIPsList []string) *Chat {
-	return &Chat{ChatID: chatID, TextMessageList: []*gql.TextMessage{},
-		clientsIPsList: clientsIPsList, MessagesChan: make(chan *gql.TextMessage, 100),
+func NewChat(chatID string, clientsIPsList []string, st store.Store) *Chat {
+	return &Chat{ChatID: chatID, store: st,
+		clientsIPsList: clientsIPsList,
 		SendMessageChan: make(chan gql.TextMessage)}
 }
 
-func (c Chat) ClientsIPsList() []string {
-	return c.clientsIPsList
+// FromRecord restores chat saved in store
+func FromRecord(record store.ChatRecord, st store.Store) *Chat {
+	tmpChat := NewChat(record.ChatID, record.Participants, st)
+	tmpChat.SetMetadata(record.ChatName, record.ChatAvatar, record.MetadataVersions)
+	tmpChat.SetRoles(record.Owner, record.Admins, record.Versions)
+	history := record.History
+	// chats saved before history was kept start it with the last changes
+	if len(history) == 0 {
+		history = versionsHistory(record.Versions)
+	}
+	tmpChat.SetHistory(record.Creation, history)
+	for epoch, key := range record.Keys {
+		tmpChat.AddKey(epoch, key)
+	}
+	for epoch, keys := range record.ConcurrentKeys {
+		for _, key := range keys {
+			tmpChat.AddKey(epoch, key)
+		}
+	}
+	// clock continues after the last saved message
+	if st != nil {
+		if last, err := tmpChat.LastMessage(); err == nil && last != nil {
+			tmpChat.Witness(last.Clock)
+		}
+	}
+	return tmpChat
+}
+
+// Record returns state of chat to be saved in store
+func (c *Chat) Record() store.ChatRecord {
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+
+	record := store.ChatRecord{
+		ChatID:           c.ChatID,
+		ChatName:         c.ChatName(),
+		ChatAvatar:       c.ChatAvatar(),
+		Participants:     c.ClientsIPsList(),
+		KeyEpoch:         c.keyEpoch,
+		Owner:            c.Owner(),
+		Admins:           c.Admins(),
+		Versions:         c.Versions(),
+		MetadataVersions: c.MetadataVersions(),
+		History:          c.History(),
+	}
+	if creation, ok := c.Creation(); ok {
+		record.Creation = &creation
+	}
+	if len(c.keys) > 0 {
+		record.Keys = make(map[uint32][]byte, len(c.keys))
+		for epoch, key := range c.keys {
+			record.Keys[epoch] = key
+		}
+	}
+	if len(c.concurrentKeys) > 0 {
+		record.ConcurrentKeys = make(map[uint32][][]byte, len(c.concurrentKeys))
+		for epoch, keys := range c.concurrentKeys {
+			record.ConcurrentKeys[epoch] = append([][]byte(nil), keys...)
+		}
+	}
+	return record
+}
+
+// ClientsIPsList returns user IDs of participants of chat
+func (c *Chat) ClientsIPsList() []string {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	return append([]string(nil), c.clientsIPsList...)
+}
+
+// HasParticipant reports if user with given ID participates in chat
+func (c *Chat) HasParticipant(userID string) bool {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	for _, participant := range c.clientsIPsList {
+		if participant == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetParticipants replaces participants of chat
+func (c *Chat) SetParticipants(participants []string) {
+	c.participantsMutex.Lock()
+	defer c.participantsMutex.Unlock()
+
+	c.clientsIPsList = append([]string(nil), participants...)
+}
+
+// Tick returns clock of new message written in chat, higher than clock of every message known so far
+func (c *Chat) Tick() int {
+	c.clockMutex.Lock()
+	defer c.clockMutex.Unlock()
+
+	c.clock++
+	return c.clock
+}
+
+// Witness moves clock of chat forward to clock of received message
+func (c *Chat) Witness(clock int) {
+	c.clockMutex.Lock()
+	defer c.clockMutex.Unlock()
+
+	if clock > c.clock {
+		c.clock = clock
+	}
+}
+
+// AddMessage saves message in store, its clock is witnessed
+// returns false if message was already there
+func (c *Chat) AddMessage(message *gql.TextMessage) (bool, error) {
+	c.Witness(message.Clock)
+	return c.store.AddMessage(message)
+}
+
+// Messages returns all messages of chat in order of their clocks (see store.MessageBefore)
+func (c *Chat) Messages() ([]*gql.TextMessage, error) {
+	return c.store.Messages(c.ChatID)
+}
+
+// MessagesSince returns at most limit messages newer than sinceMessageID, the oldest first
+func (c *Chat) MessagesSince(sinceMessageID string, limit int) ([]*gql.TextMessage, error) {
+	return c.store.MessagesSince(c.ChatID, sinceMessageID, limit)
+}
+
+// MessagesPage returns at most limit messages of chat between given messages, the newest or the oldest of them
+// (see store.Store.MessagesPage)
+func (c *Chat) MessagesPage(afterMessageID, beforeMessageID string, limit int, newest bool) (store.MessagePage, error) {
+	return c.store.MessagesPage(c.ChatID, afterMessageID, beforeMessageID, limit, newest)
+}
+
+// SubscribeMessages returns channel with new messages of chat and function ending subscription
+func (c *Chat) SubscribeMessages() (<-chan *gql.TextMessage, func()) {
+	ch := make(chan *gql.TextMessage, MESSAGE_SUBSCRIBER_BUFFER)
+	return ch, c.messages.Subscribe(ch, nil)
+}
+
+// PublishMessage passes copy of new message to subscribers of chat without blocking
+func (c *Chat) PublishMessage(message *gql.TextMessage) {
+	tmpMessage := *message
+	if missed := c.messages.Publish(&tmpMessage); missed > 0 {
+		logger.WithFields(logger.Fields{
+			"chatID":    message.ChatID,
+			"messageID": message.MessageID,
+			"missed":    missed,
+		}).Warn("PublishMessage: subscriber too slow, message dropped")
+	}
+}
+
+// MessageSubscribers returns number of subscribers of messages of chat
+func (c *Chat) MessageSubscribers() int {
+	return c.messages.Len()
+}
+
+// LastMessage returns the last message of chat in order of Messages or nil if there are no messages
+func (c *Chat) LastMessage() (*gql.TextMessage, error) {
+	message, err := c.store.LastMessage(c.ChatID)
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	return message, err
 }
 
 func (c *Chat) stopChat() {}
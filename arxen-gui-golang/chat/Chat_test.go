@@ -0,0 +1,135 @@
+package chat
+
+import (
+	"main/gql"
+	"main/store"
+	"testing"
+)
+
+func TestChat_clock(t *testing.T) {
+	st := store.NewMemoryStore()
+	c := NewChat("1", []string{"a", "b"}, st)
+
+	if clock := c.Tick(); clock != 1 {
+		t.Errorf("Tick() of new chat = %d, want 1", clock)
+	}
+	// message of other participant moves clock forward, older one does not
+	for _, m := range []*gql.TextMessage{{MessageID: "x", ChatID: "1", Clock: 5}, {MessageID: "y", ChatID: "1", Clock: 3}} {
+		if _, err := c.AddMessage(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if clock := c.Tick(); clock != 6 {
+		t.Errorf("Tick() after received messages = %d, want 6", clock)
+	}
+
+	// restored chat continues after saved messages
+	if err := st.SaveChat(c.Record()); err != nil {
+		t.Fatal(err)
+	}
+	if clock := FromRecord(c.Record(), st).Tick(); clock != 6 {
+		t.Errorf("Tick() of restored chat = %d, want 6", clock)
+	}
+}
+
+func TestChat_roles(t *testing.T) {
+	c := NewChat("1", []string{"a", "b"}, store.NewMemoryStore())
+
+	// chat without roles
+	if !c.IsAdmin("b") {
+		t.Error("participant of chat without roles is not admin")
+	}
+	c.SetRoles("a", []string{"a"}, nil)
+	if c.Role("a") != ROLE_ADMIN || c.Role("b") != ROLE_MEMBER || c.Role("c") != ROLE_NONE {
+		t.Errorf("roles = %v %v %v, want admin, member and none", c.Role("a"), c.Role("b"), c.Role("c"))
+	}
+
+	if previous, ok := c.ApplyRole("c", ROLE_ADMIN, store.MemberVersion{Clock: 2, Actor: "a"}); !ok || previous != ROLE_NONE {
+		t.Errorf("ApplyRole() = %v, %v, want applied to new user", previous, ok)
+	}
+	// concurrent change of other actor wins in every order
+	if _, ok := c.ApplyRole("c", ROLE_NONE, store.MemberVersion{Clock: 2, Actor: "b"}); !ok {
+		t.Error("concurrent change of greater actor not applied")
+	}
+	if _, ok := c.ApplyRole("c", ROLE_ADMIN, store.MemberVersion{Clock: 2, Actor: "a"}); ok {
+		t.Error("replayed change applied")
+	}
+	if c.HasParticipant("c") || c.IsAdmin("c") {
+		t.Errorf("removed user participates: %v, admins %v", c.ClientsIPsList(), c.Admins())
+	}
+
+	c.ApplyRole("b", ROLE_ADMIN, store.MemberVersion{Clock: 3, Actor: "a"})
+	c.ApplyRole("a", ROLE_MEMBER, store.MemberVersion{Clock: 4, Actor: "b"})
+	if !c.IsAdmin("a") {
+		t.Error("owner is not admin")
+	}
+	// returned list is a copy
+	c.ClientsIPsList()[0] = "x"
+	restored := FromRecord(c.Record(), nil)
+	if participants := restored.ClientsIPsList(); len(participants) != 2 || participants[0] != "a" {
+		t.Errorf("restored participants = %v, want [a b]", participants)
+	}
+	if restored.Owner() != "a" || !restored.IsAdmin("b") || len(restored.Versions()) != 3 {
+		t.Errorf("restored roles: owner %s, admins %v, versions %v", restored.Owner(), restored.Admins(), restored.Versions())
+	}
+	// applied changes are kept in history in order they were applied
+	if history := restored.History(); len(history) != 4 || history[0].UserID != "c" || history[3].UserID != "a" {
+		t.Errorf("restored history = %v, want 4 applied changes", history)
+	}
+
+	// history of chat saved before it was kept starts with the last changes
+	legacy := c.Record()
+	legacy.History = nil
+	if history := FromRecord(legacy, nil).History(); len(history) != 3 || history[0].UserID != "c" || history[2].UserID != "a" {
+		t.Errorf("history from versions = %v, want the last changes ordered by clock", history)
+	}
+
+	// joining admin stays admin, new user joins as member
+	if previous, ok := c.ApplyJoin("b", store.MemberVersion{Clock: 5, Actor: "a"}); ok || previous != ROLE_ADMIN || !c.IsAdmin("b") {
+		t.Errorf("ApplyJoin() of admin = %v, %v, want ignored", previous, ok)
+	}
+	if previous, ok := c.ApplyJoin("d", store.MemberVersion{Clock: 5, Actor: "a"}); !ok || previous != ROLE_NONE || c.Role("d") != ROLE_MEMBER {
+		t.Errorf("ApplyJoin() of new user = %v, %v, want member", previous, ok)
+	}
+}
+
+func TestChat_metadata(t *testing.T) {
+	c := NewChat("1", []string{"a", "b"}, store.NewMemoryStore())
+
+	if !c.ApplyMetadata(METADATA_NAME, "first", store.MemberVersion{Clock: 2, Actor: "a"}) {
+		t.Error("rename not applied")
+	}
+	// concurrent renames end the same in every order
+	if !c.ApplyMetadata(METADATA_NAME, "second", store.MemberVersion{Clock: 2, Actor: "b"}) {
+		t.Error("concurrent rename of greater actor not applied")
+	}
+	if c.ApplyMetadata(METADATA_NAME, "first", store.MemberVersion{Clock: 2, Actor: "a"}) {
+		t.Error("replayed rename applied")
+	}
+	// fields have separate versions
+	if !c.ApplyMetadata(METADATA_AVATAR, "/static/a.png", store.MemberVersion{Clock: 1, Actor: "a"}) {
+		t.Error("avatar change not applied")
+	}
+	if c.ApplyMetadata("topic", "x", store.MemberVersion{Clock: 5, Actor: "a"}) {
+		t.Error("unknown field applied")
+	}
+
+	restored := FromRecord(c.Record(), nil)
+	if restored.ChatName() != "second" || restored.ChatAvatar() != "/static/a.png" || len(restored.MetadataVersions()) != 2 {
+		t.Errorf("restored metadata: %q, %q, versions %v", restored.ChatName(), restored.ChatAvatar(), restored.MetadataVersions())
+	}
+}
+
+func TestChat_SubscribeMessages(t *testing.T) {
+	c := NewChat("1", []string{"a", "b"}, store.NewMemoryStore())
+	messages, cancel := c.SubscribeMessages()
+	defer cancel()
+
+	c.PublishMessage(&gql.TextMessage{MessageID: "x", ChatID: "1"})
+	if m := <-messages; m.MessageID != "x" {
+		t.Errorf("message = %s, want x", m.MessageID)
+	}
+	if n := c.MessageSubscribers(); n != 1 {
+		t.Errorf("MessageSubscribers() = %d, want 1", n)
+	}
+}
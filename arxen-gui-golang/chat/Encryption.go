@@ -0,0 +1,157 @@
+package chat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// encryption of chat:
+// every chat has symmetric key shared by its participants (NaCl secretbox),
+// key is replaced by new one with higher epoch when participants change,
+// messages are encrypted with the current key and decrypted with key of their epoch
+// participants rekeying at the same time generate different keys of the same epoch,
+// all of them are kept to decrypt messages and the lowest one is used,
+// so every participant ends with the same key whichever arrived first
+// encrypted data = nonce | secretbox
+
+const (
+	// size of chat key in bytes
+	KEY_SIZE = 32
+	// size of nonce prepended to encrypted data
+	NONCE_SIZE = 24
+)
+
+var (
+	// ErrNoKey is returned when chat has no key of given epoch
+	ErrNoKey = errors.New("no chat key")
+	// ErrDecryptionFailed is returned when data was not encrypted with given key or was modified
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+// NewKey returns new random chat key
+func NewKey() ([]byte, error) {
+	key := make([]byte, KEY_SIZE)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encrypt encrypts plaintext with chat key
+func Encrypt(key []byte, plaintext []byte) ([]byte, error) {
+	secretKey, err := toSecretKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [NONCE_SIZE]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	return secretbox.Seal(nonce[:], plaintext, &nonce, secretKey), nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt with the same key
+func Decrypt(key []byte, ciphertext []byte) ([]byte, error) {
+	secretKey, err := toSecretKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < NONCE_SIZE+secretbox.Overhead {
+		return nil, fmt.Errorf("%w: too short", ErrDecryptionFailed)
+	}
+
+	var nonce [NONCE_SIZE]byte
+	copy(nonce[:], ciphertext)
+	plaintext, ok := secretbox.Open(nil, ciphertext[NONCE_SIZE:], &nonce, secretKey)
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func toSecretKey(key []byte) (*[KEY_SIZE]byte, error) {
+	if len(key) != KEY_SIZE {
+		return nil, fmt.Errorf("%w: key of %d bytes", ErrNoKey, len(key))
+	}
+	var secretKey [KEY_SIZE]byte
+	copy(secretKey[:], key)
+	return &secretKey, nil
+}
+
+// AddKey adds key of given epoch, the key of the highest epoch becomes current one
+// of concurrent keys of the same epoch the lowest one is used, the others are kept for decryption
+// returns false if chat already has this key
+func (c *Chat) AddKey(epoch uint32, key []byte) bool {
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+
+	if c.keys == nil {
+		c.keys = make(map[uint32][]byte)
+	}
+	used, ok := c.keys[epoch]
+	if !ok {
+		c.keys[epoch] = append([]byte(nil), key...)
+		if epoch > c.keyEpoch {
+			c.keyEpoch = epoch
+		}
+		return true
+	}
+	if bytes.Equal(used, key) {
+		return false
+	}
+	for _, concurrent := range c.concurrentKeys[epoch] {
+		if bytes.Equal(concurrent, key) {
+			return false
+		}
+	}
+
+	key = append([]byte(nil), key...)
+	if bytes.Compare(key, used) < 0 {
+		c.keys[epoch], key = key, used
+	}
+	if c.concurrentKeys == nil {
+		c.concurrentKeys = make(map[uint32][][]byte)
+	}
+	c.concurrentKeys[epoch] = append(c.concurrentKeys[epoch], key)
+	return true
+}
+
+// CurrentKey returns key used for encrypting new messages and its epoch
+func (c *Chat) CurrentKey() (uint32, []byte, error) {
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+
+	key, ok := c.keys[c.keyEpoch]
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: chat %s", ErrNoKey, c.ChatID)
+	}
+	return c.keyEpoch, key, nil
+}
+
+// Key returns key used in given epoch
+func (c *Chat) Key(epoch uint32) ([]byte, error) {
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+
+	key, ok := c.keys[epoch]
+	if !ok {
+		return nil, fmt.Errorf("%w: chat %s epoch %d", ErrNoKey, c.ChatID, epoch)
+	}
+	return key, nil
+}
+
+// Keys returns all keys of given epoch, the used one first and then concurrent ones
+func (c *Chat) Keys(epoch uint32) ([][]byte, error) {
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+
+	key, ok := c.keys[epoch]
+	if !ok {
+		return nil, fmt.Errorf("%w: chat %s epoch %d", ErrNoKey, c.ChatID, epoch)
+	}
+	return append([][]byte{key}, c.concurrentKeys[epoch]...), nil
+}
@@ -0,0 +1,101 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := Encrypt(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := Decrypt(key, ciphertext); err != nil || string(plaintext) != "secret" {
+		t.Errorf("Decrypt() = %q, %v, want secret", plaintext, err)
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	tests := []struct {
+		name       string
+		key        []byte
+		ciphertext []byte
+		wantErr    error
+	}{
+		{"other key", other, ciphertext, ErrDecryptionFailed},
+		{"tampered", key, tampered, ErrDecryptionFailed},
+		{"too short", key, ciphertext[:NONCE_SIZE], ErrDecryptionFailed},
+		{"no key", nil, ciphertext, ErrNoKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decrypt(tt.key, tt.ciphertext); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChat_Keys(t *testing.T) {
+	c := NewChat("1", nil, nil)
+	if _, _, err := c.CurrentKey(); !errors.Is(err, ErrNoKey) {
+		t.Errorf("CurrentKey() of new chat error = %v, want %v", err, ErrNoKey)
+	}
+
+	c.AddKey(2, []byte{2})
+	c.AddKey(1, []byte{1})
+	if c.AddKey(2, []byte{2}) {
+		t.Error("AddKey() added known key")
+	}
+
+	if epoch, key, err := c.CurrentKey(); err != nil || epoch != 2 || key[0] != 2 {
+		t.Errorf("CurrentKey() = %d, %v, %v, want key of epoch 2", epoch, key, err)
+	}
+	if key, err := c.Key(1); err != nil || key[0] != 1 {
+		t.Errorf("Key(1) = %v, %v, want old key", key, err)
+	}
+	if restored := FromRecord(c.Record(), nil); restored.Record().KeyEpoch != 2 || len(restored.Record().Keys) != 2 {
+		t.Errorf("restored chat keys = %v", restored.Record())
+	}
+}
+
+func TestChat_concurrentKeys(t *testing.T) {
+	// participants get keys generated at the same time in different order
+	orders := [][][]byte{{{3}, {1}, {2}}, {{2}, {3}, {1}}, {{1}, {2}, {3}}}
+	for _, order := range orders {
+		c := NewChat("1", nil, nil)
+		for _, key := range order {
+			if !c.AddKey(2, key) {
+				t.Errorf("AddKey(%v) of order %v = false, want true", key, order)
+			}
+		}
+		if c.AddKey(2, []byte{3}) {
+			t.Error("AddKey() added known concurrent key")
+		}
+
+		if epoch, key, err := c.CurrentKey(); err != nil || epoch != 2 || key[0] != 1 {
+			t.Errorf("CurrentKey() of order %v = %d, %v, %v, want the lowest key", order, epoch, key, err)
+		}
+		keys, err := c.Keys(2)
+		if err != nil || len(keys) != 3 || keys[0][0] != 1 {
+			t.Errorf("Keys(2) of order %v = %v, %v, want the lowest key first and the others", order, keys, err)
+		}
+		restored := FromRecord(c.Record(), nil)
+		if keys, err := restored.Keys(2); err != nil || len(keys) != 3 || keys[0][0] != 1 {
+			t.Errorf("restored Keys(2) = %v, %v, want all keys", keys, err)
+		}
+	}
+
+	if _, err := NewChat("1", nil, nil).Keys(1); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Keys() of new chat error = %v, want %v", err, ErrNoKey)
+	}
+}
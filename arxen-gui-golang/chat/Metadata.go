@@ -0,0 +1,89 @@
+package chat
+
+import (
+	"main/store"
+)
+
+// metadata of chat, its name and avatar:
+// they are changed by admins of chat (see Roles.go), every change is stamped with Lamport clock of chat
+// and its actor, change of field is applied only if it is newer than the last one applied for that field,
+// so concurrent renames end the same for all participants and replayed changes are ignored
+
+// MetadataField is part of chat metadata changed as a whole
+type MetadataField string
+
+const (
+	METADATA_NAME   MetadataField = "name"
+	METADATA_AVATAR MetadataField = "avatar"
+)
+
+// maximal length in bytes of name and avatar address of chat
+const MAX_METADATA_LENGTH = 1024
+
+// SetMetadata replaces name, avatar and versions of their changes
+func (c *Chat) SetMetadata(name, avatar string, versions map[string]store.MemberVersion) {
+	c.metadataMutex.Lock()
+	defer c.metadataMutex.Unlock()
+
+	c.chatName = name
+	c.chatAvatar = avatar
+	c.metadataVersions = make(map[string]store.MemberVersion, len(versions))
+	for field, version := range versions {
+		c.metadataVersions[field] = version
+	}
+}
+
+// ChatName returns name of chat set by user
+func (c *Chat) ChatName() string {
+	c.metadataMutex.RLock()
+	defer c.metadataMutex.RUnlock()
+
+	return c.chatName
+}
+
+// ChatAvatar returns address of avatar of chat
+func (c *Chat) ChatAvatar() string {
+	c.metadataMutex.RLock()
+	defer c.metadataMutex.RUnlock()
+
+	return c.chatAvatar
+}
+
+// MetadataVersions returns the last applied change of every field
+func (c *Chat) MetadataVersions() map[string]store.MemberVersion {
+	c.metadataMutex.RLock()
+	defer c.metadataMutex.RUnlock()
+
+	if len(c.metadataVersions) == 0 {
+		return nil
+	}
+	versions := make(map[string]store.MemberVersion, len(c.metadataVersions))
+	for field, version := range c.metadataVersions {
+		versions[field] = version
+	}
+	return versions
+}
+
+// ApplyMetadata sets field to value if version is newer than the last one applied for it
+// returns false if change was outdated or field is unknown
+func (c *Chat) ApplyMetadata(field MetadataField, value string, version store.MemberVersion) bool {
+	c.metadataMutex.Lock()
+	defer c.metadataMutex.Unlock()
+
+	if last, ok := c.metadataVersions[string(field)]; ok && !version.Newer(last) {
+		return false
+	}
+	switch field {
+	case METADATA_NAME:
+		c.chatName = value
+	case METADATA_AVATAR:
+		c.chatAvatar = value
+	default:
+		return false
+	}
+	if c.metadataVersions == nil {
+		c.metadataVersions = make(map[string]store.MemberVersion)
+	}
+	c.metadataVersions[string(field)] = version
+	return true
+}
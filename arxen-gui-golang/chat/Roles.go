@@ -0,0 +1,218 @@
+package chat
+
+import (
+	"main/store"
+	"sort"
+)
+
+// roles of chat participants:
+// owner is user who created chat, it is admin which cannot be removed or demoted by others,
+// admins add and remove members, promote and demote other admins and rename chat
+// chats saved before roles were introduced have no owner nor admins, all their participants are admins
+//
+// every change of role of single user is stamped with Lamport clock of chat (see Tick) and its actor,
+// change is applied only if it is newer than the last one applied for that user (see store.MemberVersion),
+// so concurrent changes of the same user end the same for all participants and replayed changes are ignored
+// creation of chat signed by owner and history of applied changes are kept for users joining later,
+// which replay them to check every change against roles at the time it was made
+
+// Role of user in chat
+type Role string
+
+const (
+	ROLE_NONE   Role = "" // not a participant
+	ROLE_MEMBER Role = "member"
+	ROLE_ADMIN  Role = "admin"
+)
+
+// SetRoles replaces owner, admins and versions of roles of chat
+func (c *Chat) SetRoles(owner string, admins []string, versions map[string]store.MemberVersion) {
+	c.participantsMutex.Lock()
+	defer c.participantsMutex.Unlock()
+
+	c.owner = owner
+	c.admins = make(map[string]bool, len(admins))
+	for _, userID := range admins {
+		c.admins[userID] = true
+	}
+	c.versions = make(map[string]store.MemberVersion, len(versions))
+	for userID, version := range versions {
+		c.versions[userID] = version
+	}
+}
+
+// SetHistory replaces creation of chat signed by owner and history of applied changes of roles
+func (c *Chat) SetHistory(creation *store.MemberVersion, history []store.MemberVersion) {
+	c.participantsMutex.Lock()
+	defer c.participantsMutex.Unlock()
+
+	c.creation = nil
+	if creation != nil {
+		copied := *creation
+		c.creation = &copied
+	}
+	c.history = append([]store.MemberVersion(nil), history...)
+}
+
+// Creation returns creation of chat signed by owner, false if chat has none
+func (c *Chat) Creation() (store.MemberVersion, bool) {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	if c.creation == nil {
+		return store.MemberVersion{}, false
+	}
+	return *c.creation, true
+}
+
+// History returns applied changes of roles in order they were applied, UserID of each is set
+func (c *Chat) History() []store.MemberVersion {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	if len(c.history) == 0 {
+		return nil
+	}
+	return append([]store.MemberVersion(nil), c.history...)
+}
+
+// versionsHistory returns the last changes of users as history ordered by MemberVersion.Newer
+func versionsHistory(versions map[string]store.MemberVersion) []store.MemberVersion {
+	var history []store.MemberVersion
+	for userID, version := range versions {
+		version.UserID = userID
+		history = append(history, version)
+	}
+	sort.Slice(history, func(i, j int) bool { return history[j].Newer(history[i]) })
+	return history
+}
+
+// Owner returns user ID of creator of chat, empty for chats created before roles
+func (c *Chat) Owner() string {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	return c.owner
+}
+
+// Admins returns sorted user IDs of admins of chat, nil if chat has no roles
+func (c *Chat) Admins() []string {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	if len(c.admins) == 0 {
+		return nil
+	}
+	admins := make([]string, 0, len(c.admins))
+	for userID := range c.admins {
+		admins = append(admins, userID)
+	}
+	sort.Strings(admins)
+	return admins
+}
+
+// Versions returns the last applied change of role of every user
+func (c *Chat) Versions() map[string]store.MemberVersion {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	if len(c.versions) == 0 {
+		return nil
+	}
+	versions := make(map[string]store.MemberVersion, len(c.versions))
+	for userID, version := range c.versions {
+		versions[userID] = version
+	}
+	return versions
+}
+
+// Role returns role of user in chat
+func (c *Chat) Role(userID string) Role {
+	c.participantsMutex.RLock()
+	defer c.participantsMutex.RUnlock()
+
+	return c.role(userID)
+}
+
+// IsAdmin reports if user participates in chat as admin
+func (c *Chat) IsAdmin(userID string) bool {
+	return c.Role(userID) == ROLE_ADMIN
+}
+
+// role is Role with participantsMutex held
+func (c *Chat) role(userID string) Role {
+	participates := false
+	for _, participant := range c.clientsIPsList {
+		if participant == userID {
+			participates = true
+			break
+		}
+	}
+	switch {
+	case !participates:
+		return ROLE_NONE
+	case c.admins[userID] || userID == c.owner:
+		return ROLE_ADMIN
+	case c.owner == "" && len(c.admins) == 0:
+		// chat without roles
+		return ROLE_ADMIN
+	}
+	return ROLE_MEMBER
+}
+
+// ApplyJoin adds user as member if version is newer than the last one applied for it,
+// joining of user which already participates is ignored, so it does not lose its role
+// returns previous role of user and false if change was not applied
+func (c *Chat) ApplyJoin(userID string, version store.MemberVersion) (Role, bool) {
+	c.participantsMutex.Lock()
+	defer c.participantsMutex.Unlock()
+
+	if previous := c.role(userID); previous != ROLE_NONE {
+		return previous, false
+	}
+	return c.applyRole(userID, ROLE_MEMBER, version)
+}
+
+// ApplyRole sets role of user if version is newer than the last one applied for it
+// returns previous role of user and false if change was outdated
+func (c *Chat) ApplyRole(userID string, role Role, version store.MemberVersion) (Role, bool) {
+	c.participantsMutex.Lock()
+	defer c.participantsMutex.Unlock()
+
+	return c.applyRole(userID, role, version)
+}
+
+// applyRole is ApplyRole with participantsMutex held
+func (c *Chat) applyRole(userID string, role Role, version store.MemberVersion) (Role, bool) {
+	previous := c.role(userID)
+	if last, ok := c.versions[userID]; ok && !version.Newer(last) {
+		return previous, false
+	}
+	if c.versions == nil {
+		c.versions = make(map[string]store.MemberVersion)
+	}
+	c.versions[userID] = version
+	version.UserID = userID
+	c.history = append(c.history, version)
+
+	if c.admins == nil {
+		c.admins = make(map[string]bool)
+	}
+	delete(c.admins, userID)
+	switch role {
+	case ROLE_NONE:
+		for i, participant := range c.clientsIPsList {
+			if participant == userID {
+				c.clientsIPsList = append(c.clientsIPsList[:i:i], c.clientsIPsList[i+1:]...)
+				break
+			}
+		}
+		return previous, true
+	case ROLE_ADMIN:
+		c.admins[userID] = true
+	}
+	if previous == ROLE_NONE {
+		c.clientsIPsList = append(c.clientsIPsList, userID)
+	}
+	return previous, true
+}
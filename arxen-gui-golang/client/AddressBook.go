@@ -0,0 +1,184 @@
+package client
+
+import (
+	"errors"
+	logger "github.com/sirupsen/logrus"
+	"main/identity"
+)
+
+// users are identified by user ID derived from their public key (see identity package),
+// addresses are only the way to reach them and are tracked separately:
+// - client announces its identity in setup payload when connecting,
+// - accepting client proves its identity during handshake (see Handshake.go),
+// - addresses of other participants come with CHAT_PARTICIPANTS_RESPONSE, they are only hints for users without known address
+// envelopes are queued only to link of their recipient, so wrong address cannot redirect them (see PeerRegistry.Send)
+// the last known address and public key of every authenticated user is kept in store
+
+// ErrMissingIdentity is returned when other client did not announce its identity
+var ErrMissingIdentity = errors.New("missing identity")
+
+// PeerIdentity is identity of client announced to other clients
+type PeerIdentity struct {
+	UserID    string `json:"userID"`
+	PublicKey string `json:"publicKey"` // hex encoded Ed25519 public key
+	Address   string `json:"address"`   // address client listens on
+}
+
+// Verify checks that identity is complete and UserID was derived from PublicKey
+func (p PeerIdentity) Verify() error {
+	if p.UserID == "" || p.PublicKey == "" {
+		return ErrMissingIdentity
+	}
+	_, err := identity.VerifyUserID(p.UserID, p.PublicKey)
+	return err
+}
+
+// localIdentity returns identity of this client
+func (c *Client) localIdentity() PeerIdentity {
+	return PeerIdentity{
+		UserID:    c.userID,
+		PublicKey: c.identity.PublicKeyHex(),
+		Address:   c.userIP,
+	}
+}
+
+// AddressOf returns last known address of user
+func (c *Client) AddressOf(userID string) (string, bool) {
+	if userID == c.userID {
+		return c.userIP, true
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	addr, ok := c.addresses[userID]
+	return addr, ok
+}
+
+// userIDAt returns user last seen at given address
+func (c *Client) userIDAt(addr string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for userID, userAddr := range c.addresses {
+		if userAddr == addr {
+			return userID, true
+		}
+	}
+	return "", false
+}
+
+// confirmAddress remembers address at which user proved its identity, in handshake or signed address record
+func (c *Client) confirmAddress(userID, addr string) {
+	if userID == "" || addr == "" || userID == c.userID {
+		return
+	}
+
+	c.mutex.Lock()
+	changed := c.putAddress(userID, addr)
+	c.mutex.Unlock()
+
+	if changed {
+		c.saveAddress(userID, addr)
+	}
+}
+
+// setAddress remembers address claimed by user unless it is own address or address of other user,
+// reports if address belongs to user now
+func (c *Client) setAddress(userID, addr string) bool {
+	return c.claimAddress(userID, addr, false)
+}
+
+// addressHint remembers address of user told by other user, hints are not verified,
+// so they are used only for users without known address and only if setAddress would accept them,
+// user at the address proves its identity when connecting and envelopes are sent only to it (see PeerRegistry.Send)
+func (c *Client) addressHint(userID, addr string) bool {
+	return c.claimAddress(userID, addr, true)
+}
+
+// claimAddress is setAddress, which keeps known address of user if onlyNew is set
+func (c *Client) claimAddress(userID, addr string, onlyNew bool) bool {
+	if userID == "" || addr == "" || userID == c.userID || addr == c.userIP {
+		return false
+	}
+
+	c.mutex.Lock()
+	if _, ok := c.addresses[userID]; ok && onlyNew {
+		c.mutex.Unlock()
+		return false
+	}
+	for other, otherAddr := range c.addresses {
+		if otherAddr == addr && other != userID {
+			c.mutex.Unlock()
+			return false
+		}
+	}
+	changed := c.putAddress(userID, addr)
+	c.mutex.Unlock()
+
+	if changed {
+		c.saveAddress(userID, addr)
+	}
+	return true
+}
+
+// putAddress sets address of user, reports if it changed, mutex has to be locked
+func (c *Client) putAddress(userID, addr string) bool {
+	if c.addresses == nil {
+		c.addresses = make(map[string]string)
+	}
+	changed := c.addresses[userID] != addr
+	c.addresses[userID] = addr
+	return changed
+}
+
+// saveAddress saves changed address of user in store
+func (c *Client) saveAddress(userID, addr string) {
+	logger.WithFields(logger.Fields{"userID": userID, "addr": addr}).Info("setAddress: new address of user")
+	if err := c.store.SetAddress(userID, addr); err != nil {
+		logger.WithError(err).WithField("userID", userID).Error("setAddress: cannot save address")
+	}
+}
+
+// inboundAddress returns address keying link of connecting client authenticated as remote in peer registry
+// address claimed in setup is not verified, so it is used only if it is not address of other user,
+// otherwise client could take over link and outbox of that user, then the last known address of remote is used,
+// false if there is none
+func (c *Client) inboundAddress(remote PeerIdentity) (string, bool) {
+	if c.setAddress(remote.UserID, remote.Address) {
+		return remote.Address, true
+	}
+	addr, ok := c.AddressOf(remote.UserID)
+	if !ok || addr == c.userIP {
+		return "", false
+	}
+	return addr, true
+}
+
+// rememberPeer saves address and public key of authenticated user
+func (c *Client) rememberPeer(remote PeerIdentity, addr string) {
+	c.confirmAddress(remote.UserID, addr)
+
+	publicKey, err := identity.ParsePublicKey(remote.PublicKey)
+	if err != nil {
+		return
+	}
+	if err := c.store.SetPublicKey(remote.UserID, publicKey); err != nil {
+		logger.WithError(err).WithField("userID", remote.UserID).Error("rememberPeer: cannot save public key")
+	}
+}
+
+// loadAddresses restores addresses of users saved before restart
+func (c *Client) loadAddresses() {
+	addrs, err := c.store.Addresses()
+	if err != nil {
+		logger.WithError(err).Error("loadAddresses: cannot read addresses from store")
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for userID, addr := range addrs {
+		c.addresses[userID] = addr
+	}
+}
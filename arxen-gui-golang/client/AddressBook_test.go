@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rsocket/rsocket-go/payload"
+	"main/identity"
+	"testing"
+)
+
+func TestParseSetup(t *testing.T) {
+	id, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		identity PeerIdentity
+		wantErr  error
+	}{
+		{"valid", PeerIdentity{UserID: id.UserID(), PublicKey: id.PublicKeyHex(), Address: "tcp://10.5.0.2:7878"}, nil},
+		{"missing identity", PeerIdentity{}, ErrMissingIdentity},
+		{"key of other user", PeerIdentity{UserID: id.UserID(), PublicKey: other.PublicKeyHex()}, identity.ErrIDMismatch},
+		{"broken key", PeerIdentity{UserID: id.UserID(), PublicKey: "xyz"}, identity.ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := json.Marshal(SetupMetadata{VersionRange: LocalVersionRange(), Identity: tt.identity})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseSetup(payload.New([]byte(tt.identity.Address), metadata))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseSetup() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Identity != tt.identity {
+				t.Errorf("parseSetup() identity = %v, want %v", got.Identity, tt.identity)
+			}
+		})
+	}
+}
+
+func TestClient_setAddress(t *testing.T) {
+	c := newTestClient(t)
+
+	c.setAddress("b", "tcp://10.5.0.2:7878")
+	c.setAddress("b", "tcp://10.5.0.3:7878")
+	c.setAddress(c.userID, "tcp://10.5.0.4:7878")
+
+	if addr, ok := c.AddressOf("b"); !ok || addr != "tcp://10.5.0.3:7878" {
+		t.Errorf("AddressOf(b) = %s, %v, want the newest address", addr, ok)
+	}
+	if addr, ok := c.AddressOf(c.userID); !ok || addr != c.userIP {
+		t.Errorf("AddressOf() of own user ID = %s, %v, want own address", addr, ok)
+	}
+	if userID, ok := c.userIDAt("tcp://10.5.0.3:7878"); !ok || userID != "b" {
+		t.Errorf("userIDAt() = %s, %v, want b", userID, ok)
+	}
+
+	// address of other user is not taken
+	if c.setAddress("c", "tcp://10.5.0.3:7878") {
+		t.Error("setAddress() took address of other user")
+	}
+
+	saved, err := c.store.Addresses()
+	if err != nil || len(saved) != 1 || saved["b"] != "tcp://10.5.0.3:7878" {
+		t.Errorf("saved addresses = %v, %v, want only address of b", saved, err)
+	}
+}
+
+func TestClient_addressHint(t *testing.T) {
+	c := newTestClient(t)
+	c.confirmAddress("b", "tcp://10.5.0.2:7878")
+
+	tests := []struct {
+		name   string
+		userID string
+		addr   string
+		want   bool
+	}{
+		{"user without address", "c", "tcp://10.5.0.3:7878", true},
+		{"known user", "b", "tcp://10.5.0.4:7878", false},
+		{"address of other user", "d", "tcp://10.5.0.2:7878", false},
+		{"address of client", "d", c.userIP, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.addressHint(tt.userID, tt.addr); got != tt.want {
+				t.Errorf("addressHint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if addr, _ := c.AddressOf("b"); addr != "tcp://10.5.0.2:7878" {
+		t.Errorf("confirmed address replaced by hint %s", addr)
+	}
+}
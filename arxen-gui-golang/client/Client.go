@@ -3,6 +3,8 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/jjeffcaii/reactor-go/scheduler"
 	"github.com/rsocket/rsocket-go"
 	"github.com/rsocket/rsocket-go/payload"
@@ -13,11 +15,15 @@ import (
 	logger "github.com/sirupsen/logrus"
 	"log"
 	"main/chat"
+	"main/dht"
 	"main/gql"
+	"main/identity"
+	"main/store"
 	"net"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,6 +36,7 @@ import (
 //	|   Router daemon         |
 //	+-------------------------+
 //
+// router daemon is DHT node (dht package) run by client, see Discovery.go
 //
 // Client type
 // basic tasks are:
@@ -41,25 +48,140 @@ import (
 // rate of refreshing connections with other clients
 const CONNECTIONS_UPDATE_REFRESH_RATE = 10 * time.Second
 
+// file of store used when STORE_PATH is not set
+const DEFAULT_STORE_PATH = "arxen.db"
+
+// file of user keypair used when IDENTITY_PATH is not set
+const DEFAULT_IDENTITY_PATH = "identity.key"
+
+var (
+	// ErrUnknownPeer is returned when there is no connection channel for client
+	ErrUnknownPeer = errors.New("unknown peer")
+	// ErrUnknownChat is returned when client does not participate in chat
+	ErrUnknownChat = errors.New("unknown chat")
+	// ErrDuplicateLink is returned when client is already connected with peer by preferred connection
+	ErrDuplicateLink = errors.New("duplicate connection")
+)
+
 // Client: basic struct handling connections between other clients
 type Client struct {
-	userIP     string
-	clientsIPs map[string]bool // clientIP : status
-	// not in use rn
-	clientsSockets      map[rsocket.Client]string       // socket : clientIP
+	userID     string             // derived from public key of identity
+	identity   *identity.Identity // keypair of user
+	userIP     string             // address other clients connect to
+	addresses  map[string]string  // userID : last known address
+	peers      *PeerRegistry      // connections with other clients and envelopes waiting for them
+	// guarded by mutex
 	chatList            map[string]*chat.Chat           // chatID, *Chat
-	sendDataList        map[string]chan payload.Payload // payload and target chat format: map[clientIP] payload(message, chatID)
 	receivedPayloadChan chan payload.Payload            // channel with all incoming payloads
-
-	FriendsList map[string]*gql.Friend // map[friendsNick]Friend
-	secretKey   string            // used for authentication
+	codec               Codec                           // preferred codec announced when connecting to other clients
+	dispatcher          *Dispatcher                     // routes incoming payloads to handlers
+	dht                 *dht.DHT                        // finds addresses of users, nil until discovery starts
+	flushing            map[string]bool                 // users whose spilled envelopes are being sent, guarded by mutex
+	deliveries          *DeliveryTracker                // sent messages waiting for receipts
+	events              EventBus                        // changes of chats and friends (see Events.go)
+	presence            presenceTracker                 // presence of users derived from heartbeats (see Presence.go)
+	typing              typingTracker                   // participants typing in chats (see Typing.go)
+	typingSent          typingLimiter                   // typing events sent by user
+	lookups             lookups                         // users being looked up in DHT (see Discovery.go)
+	participantsAsked   participantsRequests            // CHAT_PARTICIPANTS_REQUEST waiting for answer (see Membership.go)
+	keyRequests         keyRequests                     // CHAT_KEY_REQUEST sent for missing keys (see Encryption.go)
+	pendingKeys         pendingKeys                     // keys waiting for participants with unknown public key
+	rekeyers            rekeyers                        // participants allowed to send new keys (see Encryption.go)
+
+	store     store.Store // chats, messages and friends
+	secretKey string      // used for authentication
 
 	mutex 		sync.Mutex			// to prevent access to same data by two goroutines
 }
 
-// GetChatList returns chat list map
+// GetChatList returns copy of chat list map
 func (c *Client) GetChatList() map[string]*chat.Chat {
-	return c.chatList
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	chats := make(map[string]*chat.Chat, len(c.chatList))
+	for chatID, tmpChat := range c.chatList {
+		chats[chatID] = tmpChat
+	}
+	return chats
+}
+
+// GetChat returns chat with given ID
+func (c *Client) GetChat(chatID string) (*chat.Chat, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	tmpChat, ok := c.chatList[chatID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChat, chatID)
+	}
+	return tmpChat, nil
+}
+
+// Messages returns all messages of chat from store, the oldest first
+func (c *Client) Messages(chatID string) ([]*gql.TextMessage, error) {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := tmpChat.Messages()
+	if err != nil {
+		return nil, err
+	}
+	return c.withReceipts(messages)
+}
+
+// MessagesPage returns page of messages of chat from store between given messages, the oldest first
+// (see store.Store.MessagesPage)
+func (c *Client) MessagesPage(chatID, afterMessageID, beforeMessageID string, limit int, newest bool) (store.MessagePage, error) {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return store.MessagePage{}, err
+	}
+	page, err := tmpChat.MessagesPage(afterMessageID, beforeMessageID, limit, newest)
+	if err != nil {
+		return store.MessagePage{}, err
+	}
+	page.Messages, err = c.withReceipts(page.Messages)
+	return page, err
+}
+
+// Friends returns friends of user from store, their status is set from state of connection
+func (c *Client) Friends() ([]*gql.Friend, error) {
+	friends, err := c.store.Friends()
+	if err != nil {
+		return nil, err
+	}
+	for _, friend := range friends {
+		online := c.IsOnline(friend.UserID)
+		friend.Status = &online
+		friend.State = friendState(friend)
+		c.fillPresence(friend)
+	}
+	return friends, nil
+}
+
+// IsOnline reports whether client of user is connected
+func (c *Client) IsOnline(userID string) bool {
+	addr, ok := c.AddressOf(userID)
+	return ok && c.peers.IsConnected(addr)
+}
+
+// Peers returns state of connections with other clients
+func (c *Client) Peers() []PeerEvent {
+	return c.peers.Peers()
+}
+
+// SubscribePeerEvents returns channel with changes of connection state and function ending subscription
+func (c *Client) SubscribePeerEvents() (<-chan PeerEvent, func()) {
+	return c.peers.Subscribe()
+}
+
+// saveFriend stores friend, errors are only logged
+func (c *Client) saveFriend(friend *gql.Friend) {
+	if err := c.store.SaveFriend(friend); err != nil {
+		logger.WithError(err).WithField("userID", friend.UserID).Error("saveFriend: cannot save friend")
+	}
 }
 
 // NewClient returns new Client
@@ -81,23 +203,112 @@ func NewClient() *Client {
 		log.Println("NewClient: obtained predefined addr = " + userAddr)
 	}
 
-	// init channels
-	_clientsIPs := make(map[string]bool)
-	_clientsSockets := make(map[rsocket.Client]string)
-	_chatList := make(map[string]*chat.Chat)
-	_sendMessageList := make(map[string]chan payload.Payload)
-	_receivedPayloadChan := make(chan payload.Payload)
-	_FriendsList := make(map[string]*gql.Friend)
+	return newClient(openIdentity(), userAddr, openStore(), OUTBOX_SIZE)
+}
 
-	return &Client{
+// newClient returns client of user with given identity reachable at userAddr, keeping its data in st,
+// outboxes of its peers buffer outboxSize envelopes, addresses and chats saved before restart are restored
+func newClient(userIdentity *identity.Identity, userAddr string, st store.Store, outboxSize int) *Client {
+	c := &Client{
+		userID:              userIdentity.UserID(),
+		identity:            userIdentity,
 		userIP:              userAddr,
-		clientsIPs:          _clientsIPs,
-		clientsSockets:      _clientsSockets,
-		chatList:            _chatList,
-		sendDataList:        _sendMessageList,
-		receivedPayloadChan: _receivedPayloadChan,
-		FriendsList:		 _FriendsList,
+		addresses:           make(map[string]string),
+		peers:               NewPeerRegistry(outboxSize),
+		deliveries:          NewDeliveryTracker(),
+		chatList:            make(map[string]*chat.Chat),
+		receivedPayloadChan: make(chan payload.Payload),
+		store:               st,
+		codec:               preferredCodec(),
+	}
+	c.dispatcher = c.newDispatcher()
+
+	// restore addresses and chats saved before restart
+	c.loadAddresses()
+	c.loadChats()
+
+	return c
+}
+
+// openStore opens store at path from STORE_PATH env variable (DEFAULT_STORE_PATH otherwise)
+// if it cannot be opened, everything is kept in memory only
+func openStore() store.Store {
+	path := DEFAULT_STORE_PATH
+	if value, ok := os.LookupEnv("STORE_PATH"); ok {
+		path = value
+		log.Println("NewClient: obtained predefined store path = " + path)
+	}
+
+	st, err := store.OpenBoltStore(path)
+	if err != nil {
+		logger.WithError(err).WithField("path", path).Error("NewClient: cannot open store, history will not be persisted")
+		return store.NewMemoryStore()
+	}
+	return st
+}
+
+// openIdentity loads keypair of user from path from IDENTITY_PATH env variable (DEFAULT_IDENTITY_PATH otherwise)
+// if it cannot be loaded, temporary identity is used and user gets new user ID after restart
+func openIdentity() *identity.Identity {
+	path := DEFAULT_IDENTITY_PATH
+	if value, ok := os.LookupEnv("IDENTITY_PATH"); ok {
+		path = value
+		log.Println("NewClient: obtained predefined identity path = " + path)
+	}
+
+	userIdentity, err := identity.LoadOrCreate(path)
+	if err != nil {
+		logger.WithError(err).WithField("path", path).Error("NewClient: cannot load identity, using temporary one")
+		if userIdentity, err = identity.New(); err != nil {
+			panic(err)
+		}
+	}
+	log.Println("NewClient: user ID = " + userIdentity.UserID())
+	return userIdentity
+}
+
+// loadChats restores chats from store and starts their handlers
+func (c *Client) loadChats() {
+	records, err := c.store.Chats()
+	if err != nil {
+		logger.WithError(err).Error("loadChats: cannot read chats from store")
+		return
+	}
+
+	for _, record := range records {
+		c.startChat(chat.FromRecord(record, c.store))
+	}
+	logger.WithField("chats", len(records)).Info("loadChats: chats restored")
+}
+
+// startChat registers chat, marks its participants to be connected and starts its handler
+// returns false if chat with the same ID is already started
+func (c *Client) startChat(tmpChat *chat.Chat) bool {
+	c.mutex.Lock()
+	if _, exists := c.chatList[tmpChat.ChatID]; exists {
+		c.mutex.Unlock()
+		return false
+	}
+	c.chatList[tmpChat.ChatID] = tmpChat
+	c.mutex.Unlock()
+
+	// get all users IP I want to connect
+	for _, userID := range tmpChat.ClientsIPsList() {
+		c.connectParticipant(userID)
+	}
+
+	go c.chatMessagesHandler(tmpChat)
+	return true
+}
+
+// preferredCodec returns codec set by DATA_MIME_TYPE env variable, DefaultCodec otherwise
+// setting it to MIME_TYPE_JSON keeps client talking JSON with not migrated clients
+func preferredCodec() Codec {
+	if value, ok := os.LookupEnv("DATA_MIME_TYPE"); ok {
+		log.Println("NewClient: obtained predefined data MIME type = " + value)
+		return CodecForMimeType(value)
 	}
+	return DefaultCodec
 }
 
 // eventListener is method listening and handling new connections to client
@@ -106,19 +317,42 @@ func (c *Client) eventListener() {
 	err := rsocket.Receive().
 		Resume().
 		Fragment(1024).
-		Acceptor(func(setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
-			log.Println("eventListener: GOT REQUEST ", setup.DataUTF8())
-			sendingSocket.OnClose(func(err error) {
-				log.Println("eventListener: socket disconnected because ", err, " with ", setup.DataUTF8())
-			})
-			// returns custom handler
-			return c.responder(setup), nil
-		}).
+		Acceptor(c.acceptor).
 		Transport(c.userIP).
 		Serve(context.Background())
 	panic(err)
 }
 
+// acceptor accepts or rejects new connection based on its setup payload
+func (c *Client) acceptor(setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+	log.Println("eventListener: GOT REQUEST ", setup.DataUTF8())
+	// reject clients speaking incompatible protocol version or without valid identity
+	remote, err := parseSetup(setup)
+	if err != nil {
+		logger.WithError(err).WithField("addr", setup.DataUTF8()).Warn("eventListener: rejecting connection")
+		return nil, err
+	}
+	// identity is only claimed until client answers challenge
+	session, err := newAuthSession(remote.Identity, func() { _ = sendingSocket.Close() })
+	if err != nil {
+		logger.WithError(err).WithField("addr", setup.DataUTF8()).Warn("eventListener: rejecting connection")
+		return nil, err
+	}
+	l := newPeerLink(remote.Identity.UserID)
+	go func() {
+		<-l.Done()
+		_ = sendingSocket.Close()
+	}()
+	sendingSocket.OnClose(func(err error) {
+		log.Println("eventListener: socket disconnected because ", err, " with ", setup.DataUTF8())
+		// only authenticated client was marked connected
+		c.peers.MarkDown(session.address(), l)
+		l.Close()
+	})
+	// returns custom handler
+	return c.responder(setup, session, l), nil
+}
+
 // CreateChat method is used to create new chat
 // TODO implement till the end
 func (c *Client) CreateChat(initList []string) *chat.Chat {
@@ -128,31 +362,28 @@ func (c *Client) CreateChat(initList []string) *chat.Chat {
 	logger.WithField("chatID", chatIDstr).Info("CreateChat: creating new chat")
 
 	// init new chat with complete users list
-	// add userIP ex"tcp://10.5.0.2:7878" to that list
-	tmpChat := chat.NewChat(chatIDstr, append(initList, c.userIP))
-
-	// TODO fix me
-	// go tmpChat.messagePrinter()
-
-	// TODO TMP IMPLEMENTATION WARNING
-	// not working if already connected to this user
-	// get all users IP I want to connect
-	for _, cli := range initList {
-		c.mutex.Lock()
-		if _, ok := c.clientsIPs[cli]; !ok {
-			c.clientsIPs[cli] = false
-		}
-		c.mutex.Unlock()
+	// add own user ID to that list
+	tmpChat := chat.NewChat(chatIDstr, append(initList, c.userID), c.store)
+	// creator owns chat
+	tmpChat.SetRoles(c.userID, []string{c.userID}, nil)
+	c.signCreation(tmpChat, tmpChat.Tick())
+
+	// the first key of chat, participants get it with CHAT_PARTICIPANTS_RESPONSE
+	if key, err := chat.NewKey(); err != nil {
+		logger.WithError(err).Error("CreateChat: cannot generate chat key")
+	} else {
+		tmpChat.AddKey(1, key)
 	}
 
-	go c.chatMessagesHandler(tmpChat)
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		logger.WithError(err).Error("CreateChat: cannot save chat")
+	}
 
-	c.mutex.Lock()
-	c.chatList[chatIDstr] = tmpChat
-	c.mutex.Unlock()
+	c.startChat(tmpChat)
+	c.events.Publish(ChatEvent{Kind: CHAT_EVENT_CREATED, ChatID: chatIDstr})
 
 	// advert new chat
-	c.receivedPayloadChan <- payload.New([]byte(chatIDstr), c.getMetadataTag(CHAT_ADVERT_REQUEST))
+	c.forwardToSelf(&ChatAdvertRequest{ChatID: chatIDstr})
 
 	return tmpChat
 
@@ -181,107 +412,167 @@ func (c *Client) CreateChat(initList []string) *chat.Chat {
 }
 
 // createSlaveChat is version of CreateChat used when chatID is already known
-func (c *Client) createSlaveChat(initList []string, chatIDstr string) {
-	// init new chat with complete users list
-	// add userIP ex"tcp://10.5.0.2:7878" to that list
-	tmpChat := chat.NewChat(chatIDstr, append(initList, c.userIP))
-
-	// TODO fix me
-	// go tmpChat.messagePrinter()
+// returns false if chat already existed
+func (c *Client) createSlaveChat(participants []string, chatIDstr string) bool {
+	c.mutex.Lock()
+	_, exists := c.chatList[chatIDstr]
+	c.mutex.Unlock()
+	if exists {
+		logger.WithField("chatID", chatIDstr).Debug("createSlaveChat: chat already exists")
+		return false
+	}
 
-	// TODO TMP IMPLEMENTATION WARNING
-	// not working if already connected to this user
-	// get all users IP I want to connect
-	for _, cli := range initList {
-		c.mutex.Lock()
-		if _, ok := c.clientsIPs[cli]; !ok {
-			c.clientsIPs[cli] = false
+	// participants list received from other client already contains own user ID
+	var initList []string
+	for _, userID := range participants {
+		if userID != c.userID {
+			initList = append(initList, userID)
 		}
-		c.mutex.Unlock()
 	}
 
-	go c.chatMessagesHandler(tmpChat)
+	// init new chat with complete users list
+	// add own user ID to that list
+	tmpChat := chat.NewChat(chatIDstr, append(initList, c.userID), c.store)
 
-	c.mutex.Lock()
-	c.chatList[chatIDstr] = tmpChat
-	c.mutex.Unlock()
+	// other response could create it in the meantime
+	if !c.startChat(tmpChat) {
+		logger.WithField("chatID", chatIDstr).Debug("createSlaveChat: chat already exists")
+		return false
+	}
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		logger.WithError(err).Error("createSlaveChat: cannot save chat")
+	}
+	c.events.Publish(ChatEvent{Kind: CHAT_EVENT_CREATED, ChatID: chatIDstr})
 
 	log.Println("createSlaveChat: Created new Chat")
-
+	return true
 }
 
 // connectionsHandler is a handler of all connections across itself and other clients
+// it connects to every peer which is due, then sleeps until the next attempt (at most CONNECTIONS_UPDATE_REFRESH_RATE)
 func (c *Client) connectionsHandler() {
 	for {
-		// refresh at rate
-		time.Sleep(CONNECTIONS_UPDATE_REFRESH_RATE)
-
-		for addr, status := range c.clientsIPs {
-			// if client not connected to particular client try to connect
-			// find if chan for that client exists
-			// TODO can be written better
-			if c.sendDataList[addr] == nil {
-				log.Println("connectionsHandler: chan non existing - creating ", addr)
-				c.mutex.Lock()
-				ch := make(chan payload.Payload)
-				c.sendDataList[addr] = ch
-				c.mutex.Unlock()
+		// envelopes for offline users are sent when they are back
+		c.retrySpillover()
+		// and messages which were not acknowledged are sent again
+		c.retryDeliveries()
+
+		// if client not connected to particular client try to connect
+		for _, addr := range c.peers.Due(time.Now()) {
+			// peer could connect in the meantime
+			if !c.peers.MarkConnecting(addr) {
+				continue
 			}
-			if !status {
-				go c.connectToClient(c.sendDataList[addr], addr)
-				// after finished update record
-				c.mutex.Lock()
-				c.clientsIPs[addr] = true
-				c.mutex.Unlock()
+			outbox, _ := c.peers.Outbox(addr)
+			go c.connectToClient(outbox, addr)
+		}
+
+		wait := CONNECTIONS_UPDATE_REFRESH_RATE
+		if next, ok := c.peers.NextAttempt(); ok {
+			if d := time.Until(next); d < wait {
+				wait = d
 			}
 		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-c.peers.Wake():
+			timer.Stop()
+		}
 	}
 }
 
-// connectToClient periodically check if client is connected to desired clients
+// connectToClient connects to client at addr and exchanges envelopes with it until connection is closed
+// peer has to be marked connecting, failed attempt is retried by connectionsHandler after backoff
 // Possible type problem: struct vs payload
-func (c *Client) connectToClient(ch chan payload.Payload, addr string) {
+func (c *Client) connectToClient(ch chan *Envelope, addr string) {
 	// goroutine for connecting to clients
 	// handle channels
 
 	// in advanced scenario ask host for chat clients ips
 
+	l := newPeerLink(c.userID)
+
 	// new client
 	// TODO change literals to constants
 	cli, err := rsocket.
 		Connect().
-		SetupPayload(payload.NewString(c.userIP, "1234")).
+		SetupPayload(payload.New([]byte(c.userIP), c.setupMetadata())).
+		DataMimeType(c.codec.MimeType()).
 		Resume().
 		Fragment(1024).
 		OnClose(func(err error) {
 			log.Println("connectToClient: connection with ", addr, " closed because ", err)
-			c.clientsIPs[addr] = false
+			c.peers.MarkDown(addr, l)
+			l.Close()
 		}).
 		Transport(addr).
 		Start(context.Background())
 	if err != nil {
-		logger.WithField("err", err).Warn("Connection Error occurred")
-		switch err.Error() {
-		case "Connection Error occurred":
-			logger.WithField("err", err.Error()).Warn("connectToClient: connection was not established")
-			return
-		default:
-			panic(err)
-		}
+		logger.WithError(err).WithField("addr", addr).Warn("connectToClient: connection was not established")
+		c.peers.MarkFailed(addr)
+		return
 	}
 
 	defer cli.Close()
+	// link is closed also when connection from peer replaces it
+	go func() {
+		<-l.Done()
+		_ = cli.Close()
+	}()
+
+	// the address may now belong to other user, so check who is there
+	remote, err := c.authenticate(cli)
+	if err != nil {
+		logger.WithError(err).WithField("addr", addr).Warn("connectToClient: client did not prove its identity")
+		c.peers.MarkFailed(addr)
+		return
+	}
+	c.rememberPeer(*remote, addr)
+	defer l.Close()
+	// peer could connect to client in the meantime, or other user is connected from address
+	if !c.peers.MarkConnected(addr, remote.UserID, l) {
+		logger.WithField("addr", addr).Info("connectToClient: other connection is preferred, closing")
+		return
+	}
+	defer c.peers.MarkDown(addr, l)
+
+	// backfill messages sent while this client was offline
+	go c.requestHistorySync(remote.UserID)
+	// and send what was waiting for the other one
+	go c.flushSpillover(remote.UserID)
+	go c.sendPendingKeys(remote.UserID)
+
+	// codec of connection, not migrated clients ignore announced MIME type and keep sending JSON
+	var codec atomic.Value
+	codec.Store(c.codec)
 
 	// create tmp flux
 	// TODO problem: who is the target
 	// TODO add option of sending custom messages
 	// TODO make this flux never cancel!
+	// envelopes are sent only after client accepts link
+	accepted := make(chan struct{})
+	var acceptOnce sync.Once
+
 	f := flux.Create(func(ctx context.Context, s flux.Sink) {
+		s.Next(payload.NewString("", LINK_OPEN))
+		select {
+		case <-accepted:
+		case <-l.Done():
+			s.Complete()
+			return
+		}
 		//log.Println("STARTED sending new message")
-		for mess := range ch {
+		l.forward(ch, func(mess *Envelope) {
 			//log.Println("SENDING new message")
-			s.Next(mess)
-		}
+			payl, err := codec.Load().(Codec).Marshal(mess)
+			if err != nil {
+				logger.WithError(err).Error("connectToClient: cannot encode payload")
+				return
+			}
+			s.Next(payl)
+		})
 		log.Println("connectToClient: transmission completed")
 		s.Complete()
 	}).DoFinally(func(s rx.SignalType) {
@@ -299,7 +590,15 @@ func (c *Client) connectToClient(ch chan payload.Payload, addr string) {
 			//tmpChatID, _ := elem.MetadataUTF8()
 			// TODO check if fixed
 			//c.chatList[tmpChatID].MessagesChan <- elem
-			c.receivedPayloadChan <- elem
+			if isLinkOpen(elem) {
+				acceptOnce.Do(func() { close(accepted) })
+				return
+			}
+			if metadata, ok := elem.Metadata(); ok && detectCodec(metadata) != codec.Load().(Codec) {
+				logger.WithField("addr", addr).Info("connectToClient: client answers in JSON, switching codec")
+				codec.Store(detectCodec(metadata))
+			}
+			c.receiveFrom(remote.UserID, elem)
 		}).DoOnComplete(func() {
 			log.Println("connectToClient: job completed")
 		}).DoOnError(func(e error) {
@@ -316,11 +615,9 @@ func (c *Client) clientManager() {
 
 }
 
-// GetUserID returns userIP/ID
-// TODO solve userID/IP
+// GetUserID returns user ID derived from public key of user
 func (c *Client) GetUserID() string {
-	// TODO change in the future
-	return c.userIP
+	return c.userID
 }
 
 // GetOutboundIP can be used to obtain machine IP address
@@ -337,7 +634,11 @@ func GetOutboundIP() (net.IP, bool) {
 }
 
 // responder is factory for rsocket.RSocket instance
-func (c *Client) responder(setup payload.SetupPayload) rsocket.RSocket {
+// session is handshake with connecting client, nothing but handshake is served before it is finished
+// l is connection with the client, it is attached when client opens channel
+func (c *Client) responder(setup payload.SetupPayload, session *authSession, l *peerLink) rsocket.RSocket {
+	remote := session.remote
+
 	// custom responder
 	return rsocket.NewAbstractSocket(
 		rsocket.MetadataPush(func(item payload.Payload) {
@@ -349,6 +650,10 @@ func (c *Client) responder(setup payload.SetupPayload) rsocket.RSocket {
 		rsocket.RequestResponse(func(pl payload.Payload) mono.Mono {
 			if meta, _ := pl.MetadataUTF8(); strings.EqualFold(meta, "REJECT_ME") {
 				return nil
+			} else if meta == AUTH_CHALLENGE {
+				return c.answerChallenge(session, pl)
+			} else if meta == AUTH_RESPONSE {
+				return c.checkResponse(session, pl)
 			}
 
 			return mono.Just(pl)
@@ -358,10 +663,22 @@ func (c *Client) responder(setup payload.SetupPayload) rsocket.RSocket {
 			// m, _ := pl.MetadataUTF8()
 			// log.Println("data:", s, "metadata:", m)
 
+			if !session.isAuthenticated() {
+				return flux.Error(ErrNotAuthenticated)
+			}
+
 			// handle getHosts request
 			if dat, _ := pl.MetadataUTF8(); strings.EqualFold(dat, "CHAT_PARTICIPANTS_REQ") { // [chatID, REQ type]
+				tmpChat, err := c.GetChat(pl.DataUTF8())
+				if err != nil {
+					return flux.Error(err)
+				}
+				// participants are told only to other participants
+				if !isParticipant(tmpChat, remote.UserID) {
+					return flux.Error(fmt.Errorf("%w: %s in chat %s", ErrNotParticipant, remote.UserID, tmpChat.ChatID))
+				}
 				return flux.Create(func(ctx context.Context, emitter flux.Sink) {
-					for _, ip := range c.chatList[pl.DataUTF8()].ClientsIPsList() {
+					for _, ip := range tmpChat.ClientsIPsList() {
 						emitter.Next(payload.NewString(ip, "CHAT_PARTICIPANTS_RESP"))
 					}
 					emitter.Complete()
@@ -376,14 +693,28 @@ func (c *Client) responder(setup payload.SetupPayload) rsocket.RSocket {
 			// get connecting hostIP and update user array
 
 			// format: setup[clientIP]
+			if !session.isAuthenticated() {
+				logger.WithField("userID", remote.UserID).Warn("responder: channel requested before handshake")
+				return flux.Error(ErrNotAuthenticated)
+			}
 
-			c.clientsIPs[setup.DataUTF8()] = true
-
-			if c.sendDataList[setup.DataUTF8()] == nil {
-				log.Println("responder: chan non existing - creating ", setup.DataUTF8())
-				ch := make(chan payload.Payload)
-				c.sendDataList[setup.DataUTF8()] = ch
+			// connection dialed by client could be preferred, or other user is connected from address
+			addr := session.address()
+			if !c.peers.MarkConnected(addr, remote.UserID, l) {
+				logger.WithField("userID", remote.UserID).Info("responder: other connection is preferred, closing")
+				l.Close()
+				return flux.Error(ErrDuplicateLink)
 			}
+			outbox, _ := c.peers.Outbox(addr)
+
+			// answer using codec announced by connecting client
+			codec := CodecForMimeType(setup.DataMimeType())
+
+			// backfill messages sent while this client was offline
+			go c.requestHistorySync(remote.UserID)
+			// and send what was waiting for the other one
+			go c.flushSpillover(remote.UserID)
+			go c.sendPendingKeys(remote.UserID)
 
 			// TODO possibly remove
 
@@ -397,22 +728,30 @@ func (c *Client) responder(setup payload.SetupPayload) rsocket.RSocket {
 				log.Println("responder: ERROR ", e)
 			}).Subscribe(context.Background(), rx.OnNext(func(input payload.Payload) {
 
-				// TODO sort messages
-
 				// log.Println(input)
+				if isLinkOpen(input) {
+					return
+				}
 
 				// TODO FIX ME ERROR HERE: "runtime error: invalid memory address or nil pointer dereference"
 				log.Println("responder: GOT MESSAGE: ", input.DataUTF8())
 				// tmpChatID, _ := input.MetadataUTF8()
 				// c.chatList[tmpChatID].MessagesChan <- input
 
-				c.receivedPayloadChan <- input
+				c.receiveFrom(remote.UserID, input)
 			}))
 
 			return flux.Create(func(ctx context.Context, s flux.Sink) {
-				for mess := range c.sendDataList[setup.DataUTF8()] {
-					s.Next(mess)
-				}
+				// accept link
+				s.Next(payload.NewString("", LINK_OPEN))
+				l.forward(outbox, func(mess *Envelope) {
+					payl, err := codec.Marshal(mess)
+					if err != nil {
+						logger.WithError(err).Error("responder: cannot encode payload")
+						return
+					}
+					s.Next(payl)
+				})
 				s.Complete()
 			}).DoFinally(func(s rx.SignalType) {
 				log.Println("responder: Got signal ", s)
@@ -421,205 +760,143 @@ func (c *Client) responder(setup payload.SetupPayload) rsocket.RSocket {
 	)
 }
 
-// payloads:
-// CHAT_MESSAGE:			  {message,{source, type, chatID}}
-// CHAT_PARTICIPANTS_REQUEST: {chatID, {source, type}}
+// payloads (see CommunicationPayloads.go):
+// CHAT_MESSAGE:			  {ChatMessage, {version, type, source}}
+// CHAT_PARTICIPANTS_REQUEST: {ChatParticipantsRequest, {version, type, source}}
 
 // receivedPayloadHandler is helper, handling all incoming messages from each connection
+// every payload goes through dispatcher (see PayloadHandlers.go), invalid ones are rejected and counted
 func (c *Client) receivedPayloadHandler() {
 	// this "for" is basically onNext()
 	for payl := range c.receivedPayloadChan {
-
-		// read message data/metadata
-		// based on input do something
-		metaByteJson, _ := payl.Metadata()
-		var metadata map[string]interface{}
-		if err := json.Unmarshal(metaByteJson, &metadata); err != nil {
-			// TODO better handle error
-			panic(err)
-		}
-
-		// TODO add authentication process for request (client not participating in chat can get its participants)
-
-		// TODO implement me till the end
-		// log.Println("receivedPayloadHandler: INCOMING: ", payl)
-
 		logger.WithField("payl", payl).Trace("receivedPayloadHandler: INCOMING")
 
-		switch metadata["type"].(string) {
-		case CHAT_MESSAGE:
-			// TODO handle incoming messages
-			// the source
-			// authentication
-			if dest := metadata["chatId"]; dest != nil {
-				// send to appropriate chat
-				tmpTextMessage := PayloadToGraphqlTextMessage(payl)
-				c.chatList[dest.(string)].MessagesChan <- &tmpTextMessage
-				logger.Trace("receivedPayloadHandler: After CHAN")
-				c.chatList[dest.(string)].TextMessageList = append(c.chatList[dest.(string)].TextMessageList, &tmpTextMessage)
-				//<- chat.TextMessage{
-				//	Data:      payl.DataUTF8(),
-				//	Author:    metadata["source"].(string),
-				//	Timestamp: time.Now(),
-				//}
-
-				logger.Trace("receivedPayloadHandler: Left CHAT_MESSAGE section")
-			}
-		case CHAT_PARTICIPANTS_REQUEST:
-			// send all participating clients IPs to requester
-			// v1
-			//for _, addr := range c.chatList[payl.DataUTF8()].ClientsIPsList() {
-			//	tmpSendPayl := payload.New([]byte(addr), c.getMetadataTag(CHAT_PARTICIPANTS_RESPONSE))
-			//	c.sendDataList[metadata["source"].(string)] <- tmpSendPayl
-			//}
-			//v2 possible problem is size limit of payload
-			log.Println("receivedPayloadHandler: got new CHAT_PARTICIPANTS_REQUEST")
-			if _, ok := c.chatList[payl.DataUTF8()]; !ok {
-				logger.Warn("receivedPayloadHandler: chatID not found in clients chatList")
-				break
-			}
-			addrString := strings.Join(c.chatList[payl.DataUTF8()].ClientsIPsList(), ",")
-			c.sendDataList[metadata["source"].(string)] <- payload.New([]byte(addrString), c.getMetadataTag(CHAT_PARTICIPANTS_RESPONSE, payl.DataUTF8()))
-			log.Println("receivedPayloadHandler: sending chat CHAT_PARTICIPANTS_RESPONSE")
-
-		case CHAT_ADVERT_REQUEST:
-			// phantom request
-			// should work :/
-			for _, addr := range c.chatList[payl.DataUTF8()].ClientsIPsList() {
-				if addr != c.userIP {
-					// check if corresponding chan exists
-					if c.sendDataList[addr] == nil {
-						log.Println("receivedPayloadHandler: chan non existing - creating ", addr)
-						// tmp solution
-						ch := make(chan payload.Payload)
-						c.sendDataList[addr] = ch
-					}
-					// send to each chan CHAT_ADVERT
-					c.sendDataList[addr] <- payload.New(payl.Data(), c.getMetadataTag(CHAT_ADVERT, payl.DataUTF8()))
-				}
-			}
-		case CHAT_ADVERT:
-			// ask for all participants
-			c.sendDataList[metadata["source"].(string)] <- payload.New(payl.Data(), c.getMetadataTag(CHAT_PARTICIPANTS_REQUEST))
-			log.Println("receivedPayloadHandler: asking by CHAT_PARTICIPANTS_REQUEST")
+		// error is already logged and counted by dispatcher
+		_ = c.dispatchPayload(payl)
+	}
+}
 
-		case CHAT_PARTICIPANTS_RESPONSE:
-			// create new chat
-			// not ideal solution
-			addrArray := strings.Split(payl.DataUTF8(), ",")
-			log.Println("receivedPayloadHandler: beginning creation of new chat")
-			c.createSlaveChat(addrArray, metadata["chatID"].(string))
-		default:
-			log.Println("ERROR! UNSUPPORTED PAYLOAD METADATA TYPE")
+// dispatchPayload decodes payload and passes it to dispatcher
+// adverts, messages and friendship changes of blocked users are dropped (see Friends.go)
+func (c *Client) dispatchPayload(p payload.Payload) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = c.dispatcher.reject(REJECT_PANIC, fmt.Errorf("decoding panicked: %v", r))
 		}
+	}()
 
+	e, err := UnmarshalEnvelope(p)
+	if err != nil {
+		// dispatcher rejects and counts it with reason
+		return c.dispatcher.Dispatch(p)
+	}
+	return c.dispatchEnvelope(e)
+}
+
+// dispatchEnvelope passes decoded envelope to dispatcher unless its sender is blocked
+func (c *Client) dispatchEnvelope(e *Envelope) error {
+	if blockedTypes[e.Type] && c.IsBlocked(e.Source) {
+		return c.dispatcher.reject(REJECT_BLOCKED, fmt.Errorf("%s of blocked user %s", e.Type, e.Source))
 	}
+	return c.dispatcher.DispatchEnvelope(e)
+}
+
+// RejectedPayloads returns number of rejected incoming payloads per reason
+func (c *Client) RejectedPayloads() map[string]uint64 {
+	return c.dispatcher.Rejected()
 }
 
 // chatMessagesHandler handles forwarding messages from particular chat
 func (c *Client) chatMessagesHandler(chat *chat.Chat) {
 	for newMessageToBeSend := range chat.SendMessageChan {
+		// chat was left or client was removed from it
+		if !chat.HasParticipant(c.userID) {
+			logger.WithField("chatID", chat.ChatID).Warn("chatMessagesHandler: not a participant, message dropped")
+			continue
+		}
 
-		// transform message
-		payloadMessage := payload.New([]byte(newMessageToBeSend.Text), c.getMetadataTag(CHAT_MESSAGE, chat.ChatID, newMessageToBeSend.User, newMessageToBeSend.TimeStamp.String(), newMessageToBeSend.MessageID))
+		// order after every message known so far, sign and encrypt message
+		if newMessageToBeSend.Clock == 0 {
+			newMessageToBeSend.Clock = chat.Tick()
+		}
+		c.signMessage(&newMessageToBeSend)
+		message, err := c.encryptMessage(chat, newMessageToBeSend)
+		if err != nil {
+			logger.WithError(err).WithField("chatID", chat.ChatID).Error("chatMessagesHandler: cannot encrypt message")
+			continue
+		}
 
 		// forward to oneself
-		c.receivedPayloadChan <- payloadMessage
+		c.forwardToSelf(message)
 
-		log.Println("chatMessagesHandler: Message to be send: ", payloadMessage)
+		log.Println("chatMessagesHandler: Message to be send: ", message)
 
 		// forward to all connected hosts
-		for _, clientIP := range chat.ClientsIPsList() {
-			if clientIP != c.userIP {
-				c.sendDataList[clientIP] <- payloadMessage
+		for _, userID := range chat.ClientsIPsList() {
+			if userID != c.userID {
+				c.deliveries.Track(userID, message)
+				if err := c.sendTo(userID, message); err != nil {
+					logger.WithError(err).Warn("chatMessagesHandler: message not sent")
+				}
 			}
 		}
 	}
 }
 
-// getMetadataTag: function returning metadata for payload
-// args:
-// args[0]: type of request/response to be generated
-// args[1:]: extra arguments:
-func (c *Client) getMetadataTag(args ...string) []byte {
-	switch args[0] {
-	case CHAT_PARTICIPANTS_REQUEST:
-		return []byte(`{"source":"` + c.userIP + `", "type":"` + args[0] + `"}`)
-	case CHAT_PARTICIPANTS_RESPONSE:
-		// args[1]: chatID
-		if len(args) < 2 {
-			panic("getMetadataTag: Too few arguments")
-		}
-		return []byte(`{"source":"` + c.userIP + `", "type":"` + args[0] + `","chatID":"` + args[1] + `"}`)
-	case CHAT_MESSAGE:
-		// args[1]: chatID, args[2]: user, args[3]: timeStamp, args[4]: MessageID
-		if len(args) < 5 {
-			panic("getMetadataTag: Too few arguments")
-		}
-		return []byte(`{"source":"` + c.userIP + `", "type":"` + args[0] + `","chatId":"` + args[1] + `", "user":"` + args[2] + `", "timeStamp":"` + args[3] + `", "MessageID": "`+ args[4] +`"}`)
-	case CHAT_ADVERT_REQUEST:
-		return []byte(`{"source":"` + c.userIP + `", "type":"` + args[0] + `"}`)
-	case CHAT_ADVERT:
-		// args[1]: chatName
-		if len(args) < 2 {
-			panic("getMetadataTag: Too few arguments")
-		}
-		return []byte(`{"source":"` + c.userIP + `", "type":"` + args[0] + `", "chatName": "` + args[1] + `"}`)
-	default:
-		log.Fatalln("getMetadataTag: Bad message type")
-		return nil
+// sendTo sends body to user with given ID, it is encoded by codec of the connection
+// it never blocks, if user is not connected or its connection is busy envelope is spilled to store,
+// ephemeral messages are dropped instead
+func (c *Client) sendTo(userID string, body Message) error {
+	e := NewEnvelope(c.userID, body)
+	if _, ok := body.(ephemeral); ok {
+		return c.sendEphemeral(userID, e)
 	}
-}
-
-// PayloadToGraphqlTextMessage converts incoming payload to TextMessage (defined in gql module)
-// CHAT_MESSAGE:			  {text,{source, type, chatID/chatId, user, timeStamp}}
-// where text is part of TextMessage
-func PayloadToGraphqlTextMessage(p payload.Payload) gql.TextMessage {
-	// TODO better solution for escaping json
 
-	tmpMetadata, _ := p.Metadata()
-	var metadata map[string]interface{}
-	//
-	//dataJson, err := strconv.Unquote(string(tmpJson))
-	//if err != nil {
-	//	logger.WithError(err).Fatal("PayloadToGraphqlTextMessage: dataJson")
-	//}
-
-	if err := json.Unmarshal(tmpMetadata, &metadata); err != nil {
-		panic(err)
+	addr, ok := c.AddressOf(userID)
+	if !ok {
+		c.lookUpParticipant(userID)
+		return c.spill(userID, e)
 	}
+	c.peers.Register(addr)
 
-	// TODO what if empty data
-
-	date, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", metadata["timeStamp"].(string))
-	if err != nil {
-		log.Fatalln(err)
+	// envelopes spilled before have to be sent first
+	if !c.hasSpillover(userID) {
+		err := c.peers.Send(addr, userID, e)
+		if err == nil {
+			return nil
+		}
+		// address was wrong hint, the right one is looked up
+		if errors.Is(err, ErrOtherUser) {
+			c.lookUpParticipant(userID)
+		}
+		logger.WithError(err).WithField("userID", userID).Debug("sendTo: spilling envelope")
 	}
+	return c.spill(userID, e)
+}
 
-	var chatID string
-
-	if chatID :=  metadata["chatId"].(string); len(chatID) == 0 {
-		chatID = metadata["chatID"].(string)
+// sendEphemeral sends envelope to connected user, it is dropped when user is not connected
+func (c *Client) sendEphemeral(userID string, e *Envelope) error {
+	addr, ok := c.AddressOf(userID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPeer, userID)
 	}
+	return c.peers.Send(addr, userID, e)
+}
 
-	return gql.TextMessage{
-		MessageID: metadata["MessageID"].(string),
-		ChatID:    chatID,
-		User:      metadata["user"].(string),
-		TimeStamp: date,
-		Text:      p.DataUTF8(),
+// forwardToSelf encodes body and puts it into own incoming payloads
+func (c *Client) forwardToSelf(body Message) {
+	payl, err := MarshalEnvelope(NewEnvelope(c.userID, body))
+	if err != nil {
+		logger.WithError(err).WithField("type", body.MessageType()).Error("forwardToSelf: cannot encode payload")
+		return
 	}
+	c.receivedPayloadChan <- payl
 }
 
 // GraphqlTextMessageToByte converts text message format to bytes
 // probably redundant in the future
-func GraphqlTextMessageToByte(message gql.TextMessage) []byte {
-	jsonMessage, err := json.Marshal(message)
-	if err != nil {
-		log.Fatalln("GraphqlTextMessageToByte: ", err)
-	}
-	log.Println("GraphqlTextMessageToByte: ",jsonMessage)
-	return []byte(`{"chatId": "` + message.ChatID + `", "user": "` + message.User + `", "timeStamp": "` + message.TimeStamp.String() + `", "text": "` + message.Text + `" }`)
+func GraphqlTextMessageToByte(message gql.TextMessage) ([]byte, error) {
+	return json.Marshal(message)
 }
 
 // --------------------------------------------------------
@@ -703,6 +980,35 @@ func GraphqlTextMessageToByte(message gql.TextMessage) []byte {
 //	}
 //}
 
+// testSetupParticipants waits until clients at given addresses are connected,
+// saves them as friends and returns their user IDs
+func (c *Client) testSetupParticipants(addrs []string) []string {
+	var participants []string
+	for _, addr := range addrs {
+		var userID string
+		for i := 0; i < 3; i++ {
+			var ok bool
+			if userID, ok = c.userIDAt(addr); ok {
+				break
+			}
+			time.Sleep(CONNECTIONS_UPDATE_REFRESH_RATE)
+		}
+		if userID == "" {
+			logger.WithField("addr", addr).Warn("TestSetup: client not connected, skipping")
+			continue
+		}
+
+		tmpNick := addr
+		c.saveFriend(&gql.Friend{
+			Nick:   &tmpNick,
+			UserID: userID,
+			UserIP: &tmpNick,
+		})
+		participants = append(participants, userID)
+	}
+	return participants
+}
+
 // TestSetup setups test env
 // TEST SETUP
 func (c *Client) TestSetup() {
@@ -710,39 +1016,25 @@ func (c *Client) TestSetup() {
 	go c.connectionsHandler()
 	go c.receivedPayloadHandler()
 	go c.eventListener()
+	go c.startDiscovery()
+	go c.presenceHeartbeats()
 
-	var participants []string
-
-	tmpFriend := "tcp://127.0.0.3:7878"
-
-	c.FriendsList[tmpFriend] = &gql.Friend{
-		Nick:   &tmpFriend,
-		UserID: tmpFriend,
-		UserIP: &tmpFriend,
-	}
-
-	logger.Debug("TestSetup: friendslist = ", c.FriendsList)
+	var addrs []string
 
 	if value, ok := os.LookupEnv("SAMPLE_CHAT_SETUP_ADDR"); ok {
-		participants = strings.Split(value, ",")
-		logger.Info("TestSetup: chat setup connect to = ", participants)
-
-		for _, part := range participants {
-			tmpNick := part
-			c.FriendsList[part] = &gql.Friend{
-				Nick:       &tmpNick,
-				UserID:     part,
-				UserIP: 	&tmpNick,
-			}
+		addrs = strings.Split(value, ",")
+		logger.Info("TestSetup: chat setup connect to = ", addrs)
+
+		// connect to know user IDs of clients at these addresses
+		for _, addr := range addrs {
+			c.peers.Register(addr)
 		}
 	}
 
-	logger.Debug("TestSetup: and now, friendslist = ", c.FriendsList)
-
 	if value, ok := os.LookupEnv("MAIN_MACHINE"); ok && value == "0" {
 		time.Sleep(5 * time.Second)
 		log.Println("Main Machine")
-		c.CreateChat(participants)
+		c.CreateChat(c.testSetupParticipants(addrs))
 	} else if value, ok := os.LookupEnv("MAIN_MACHINE"); ok && value == "1"  {
 		log.Println("Second Machine")
 	} else if value, ok := os.LookupEnv("MAIN_MACHINE"); ok && value == "2" {
@@ -1,9 +1,13 @@
 package client
 
 import (
+	"fmt"
 	"github.com/rsocket/rsocket-go"
 	"github.com/rsocket/rsocket-go/payload"
 	"main/chat"
+	"main/gql"
+	"main/identity"
+	"main/store"
 	"net"
 	"reflect"
 	"sync"
@@ -11,13 +15,31 @@ import (
 	"time"
 )
 
+// newTestClient returns client created like by NewClient, with fresh identity and memory store,
+// listening on free local port, received payloads wait in channel until test reads them
+func newTestClient(t *testing.T) *Client {
+	userIdentity, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	c := newClient(userIdentity, fmt.Sprintf("tcp://127.0.0.1:%d", port), store.NewMemoryStore(), OUTBOX_SIZE)
+	c.receivedPayloadChan = make(chan payload.Payload, OUTBOX_SIZE)
+	return c
+}
+
 func TestClient_receivedPayloadHandler(t *testing.T) {
 	type fields struct {
+		userID              string
 		userIP              string
-		clientsIPs          map[string]bool
-		clientsSockets      map[rsocket.Client]string
+		peers               *PeerRegistry
 		chatList            map[string]*chat.Chat
-		sendDataList        map[string]chan payload.Payload
 		receivedPayloadChan chan payload.Payload
 		secretKey           string
 	}
@@ -30,10 +52,10 @@ func TestClient_receivedPayloadHandler(t *testing.T) {
 		{"test_CHAT_PARTICIPANTS_REQUEST",
 			"2",
 			fields{
+				userID:              "5",
 				userIP:              "tcp://10.5.0.3:7878",
 				receivedPayloadChan: make(chan payload.Payload),
-				sendDataList:        make(map[string]chan payload.Payload),
-				clientsIPs:          make(map[string]bool),
+				peers:               NewPeerRegistry(5),
 				chatList:            make(map[string]*chat.Chat),
 			},
 			[]string{"1", "2", "3", "4"},
@@ -41,22 +63,30 @@ func TestClient_receivedPayloadHandler(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			userIdentity, err := identity.New()
+			if err != nil {
+				t.Fatal(err)
+			}
 			c := &Client{
+				userID:              tt.fields.userID,
 				userIP:              tt.fields.userIP,
-				clientsIPs:          tt.fields.clientsIPs,
-				clientsSockets:      tt.fields.clientsSockets,
+				identity:            userIdentity,
+				addresses:           make(map[string]string),
+				peers:               tt.fields.peers,
+				deliveries:          NewDeliveryTracker(),
 				chatList:            tt.fields.chatList,
-				sendDataList:        tt.fields.sendDataList,
 				receivedPayloadChan: tt.fields.receivedPayloadChan,
 				secretKey:           tt.fields.secretKey,
 			}
+			c.dispatcher = c.newDispatcher()
+			c.store = store.NewMemoryStore()
 
 			go c.receivedPayloadHandler()
 
-			// tmp solution
-			for _, addr := range tt.initList {
-				ch := make(chan payload.Payload, 5)
-				c.sendDataList[addr] = ch
+			// tmp solution, users are reachable at addresses equal to their IDs
+			for _, userID := range tt.initList {
+				c.peers.MarkConnected(userID, userID, newPeerLink(userID))
+				c.setAddress(userID, userID)
 			}
 
 			go c.CreateChat(tt.initList)
@@ -65,15 +95,18 @@ func TestClient_receivedPayloadHandler(t *testing.T) {
 
 			nameString := "123"
 
-			for name := range c.chatList {
+			for name := range c.GetChatList() {
 				nameString = name
 			}
 
-			data01 := payload.New([]byte(nameString), []byte(`{"source":"`+tt.source+`", "type":"CHAT_PARTICIPANTS_REQUEST"}`))
+			data01, err := MarshalEnvelope(NewEnvelope(tt.source, &ChatParticipantsRequest{ChatID: nameString}))
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			c.receivedPayloadChan <- data01
 
-			var rcvData02 []payload.Payload
+			var rcvData02 []*Envelope
 
 			quit := make(chan struct{})
 			go func() {
@@ -84,24 +117,38 @@ func TestClient_receivedPayloadHandler(t *testing.T) {
 					default:
 						for _, addr := range tt.initList {
 							if addr != tt.source {
-								<-c.sendDataList[addr]
+								<-outboxOf(t, c, addr)
 							}
 						}
 					}
 				}
 			}()
 
-			for data := range c.sendDataList[tt.source] {
+			for data := range outboxOf(t, c, tt.source) {
 				rcvData02 = append(rcvData02, data)
 				if len(rcvData02) == 2 {
 					break
 				}
 			}
 
-			resp := payload.New([]byte(`1,2,3,4,tcp://10.5.0.3:7878`), []byte(`{"source":"`+c.userIP+`", "type":"CHAT_PARTICIPANTS_RESPONSE"}`))
+			resp := &ChatParticipantsResponse{
+				ChatID:       nameString,
+				Participants: []string{"1", "2", "3", "4", "5"},
+				Addresses:    map[string]string{"1": "1", "2": "2", "3": "3", "4": "4", "5": "tcp://10.5.0.3:7878"},
+				Owner:        "5",
+				Admins:       []string{"5"},
+				PublicKeys:   map[string][]byte{"5": userIdentity.PublicKey},
+			}
+			// creator signed creation of chat
+			creation, ok := c.GetChatList()[nameString].Creation()
+			if !ok || creation.Actor != "5" || creation.Action != string(MEMBERSHIP_CREATE) {
+				t.Fatalf("creation of chat = %+v, %v, want signed by creator", creation, ok)
+			}
+			resp.Creation = &creation
 
-			if rcvData02[1].DataUTF8() != resp.DataUTF8() {
-				t.Errorf("Test failed: \"%v\" is not equal to \"%v\"", rcvData02[1], resp)
+			got := rcvData02[1]
+			if !reflect.DeepEqual(got.Body, resp) {
+				t.Errorf("Test failed: \"%v\" is not equal to \"%v\"", got.Body, resp)
 			}
 
 			close(quit)
@@ -109,14 +156,12 @@ func TestClient_receivedPayloadHandler(t *testing.T) {
 	}
 }
 
-// sometimes fails due to: "fatal error: concurrent map writes"
 func TestClient_CHAT_ADVERT(t *testing.T) {
 	type fields struct {
+		userID              string
 		userIP              string
-		clientsIPs          map[string]bool
-		clientsSockets      map[rsocket.Client]string
+		peers               *PeerRegistry
 		chatList            map[string]*chat.Chat
-		sendDataList        map[string]chan payload.Payload
 		receivedPayloadChan chan payload.Payload
 		secretKey           string
 	}
@@ -124,40 +169,49 @@ func TestClient_CHAT_ADVERT(t *testing.T) {
 		name            string
 		otherClientsIPs []string
 		fields          fields
-		output          map[string][]payload.Payload
+		output          map[string][]*Envelope
 		chatID          string
 	}{
 		{
 			"test_CHAT_ADVERT",
 			[]string{"tcp://10.5.0.2:7878", "tcp://10.5.0.3:7878", "tcp://10.5.0.4:7878"},
 			fields{
+				userID:              "0",
 				userIP:              "tcp://10.5.0.1:7878",
 				receivedPayloadChan: make(chan payload.Payload),
-				sendDataList:        make(map[string]chan payload.Payload),
-				clientsIPs:          make(map[string]bool),
+				peers:               NewPeerRegistry(5),
 				chatList:            make(map[string]*chat.Chat),
 			},
-			make(map[string][]payload.Payload),
+			make(map[string][]*Envelope),
 			"123",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			userIdentity, err := identity.New()
+			if err != nil {
+				t.Fatal(err)
+			}
 			c := &Client{
+				userID:              tt.fields.userID,
 				userIP:              tt.fields.userIP,
-				clientsIPs:          tt.fields.clientsIPs,
-				clientsSockets:      tt.fields.clientsSockets,
+				identity:            userIdentity,
+				addresses:           make(map[string]string),
+				peers:               tt.fields.peers,
+				deliveries:          NewDeliveryTracker(),
 				chatList:            tt.fields.chatList,
-				sendDataList:        tt.fields.sendDataList,
 				receivedPayloadChan: tt.fields.receivedPayloadChan,
 				secretKey:           tt.fields.secretKey,
 			}
+			c.dispatcher = c.newDispatcher()
+			c.store = store.NewMemoryStore()
 
 			var wg sync.WaitGroup
 
+			// users are reachable at addresses equal to their IDs
 			for _, item := range tt.otherClientsIPs {
-				ch := make(chan payload.Payload, 5)
-				c.sendDataList[item] = ch
+				c.peers.MarkConnected(item, item, newPeerLink(item))
+				c.setAddress(item, item)
 			}
 
 			go c.receivedPayloadHandler()
@@ -170,11 +224,12 @@ func TestClient_CHAT_ADVERT(t *testing.T) {
 				wg.Add(1)
 				go func(_wg *sync.WaitGroup, lis string) {
 					defer _wg.Done()
-					for payl := range c.sendDataList[lis] {
+					for payl := range outboxOf(t, c, lis) {
 						mu.Lock()
 						tt.output[lis] = append(tt.output[lis], payl)
+						received := len(tt.output[lis])
 						mu.Unlock()
-						if len(tt.output[lis]) > 0 {
+						if received > 0 {
 							break
 						}
 					}
@@ -187,33 +242,69 @@ func TestClient_CHAT_ADVERT(t *testing.T) {
 
 			nameString := "123"
 
-			for name := range c.chatList {
+			for name := range c.GetChatList() {
 				nameString = name
 			}
 
 			wg.Wait()
 
 			for _, item := range tt.output {
-				if item[0].DataUTF8() != nameString {
-					t.Errorf("Test FAILED: output \"%s\" != \"%s\"!", item[0].DataUTF8(), nameString)
+				got := item[0]
+				if advert, ok := got.Body.(*ChatAdvert); !ok || advert.ChatID != nameString {
+					t.Errorf("Test FAILED: output \"%v\" != \"%s\"!", got.Body, nameString)
 				}
 			}
 		})
 	}
 }
 
+func TestClient_loadChats(t *testing.T) {
+	st := store.NewMemoryStore()
+	record := store.ChatRecord{ChatID: "123", ChatName: "name", Participants: []string{"b", "a"}}
+	if err := st.SaveChat(record); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.AddMessage(&gql.TextMessage{MessageID: "1", ChatID: "123", User: "b", Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetAddress("b", "tcp://10.5.0.2:7878"); err != nil {
+		t.Fatal(err)
+	}
+
+	userIdentity, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(userIdentity, "tcp://10.5.0.1:7878", st, OUTBOX_SIZE)
+
+	tmpChat, err := c.GetChat("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tmpChat.Record(), record) {
+		t.Errorf("restored chat = %v, want %v", tmpChat.Record(), record)
+	}
+	if messages, err := c.Messages("123"); err != nil || len(messages) != 1 || messages[0].Text != "hello" {
+		t.Errorf("Messages() = %v, %v, want restored message", messages, err)
+	}
+	if due := c.peers.Due(time.Now()); !reflect.DeepEqual(due, []string{"tcp://10.5.0.2:7878"}) {
+		t.Errorf("peers to be connected = %v, want only participant", due)
+	}
+}
+
 func TestClient_responder(t *testing.T) {
 	type fields struct {
+		userID              string
 		userIP              string
-		clientsIPs          map[string]bool
-		clientsSockets      map[rsocket.Client]string
+		peers               *PeerRegistry
 		chatList            map[string]*chat.Chat
-		sendDataList        map[string]chan payload.Payload
 		receivedPayloadChan chan payload.Payload
 		secretKey           string
 	}
 	type args struct {
-		setup payload.SetupPayload
+		setup   payload.SetupPayload
+		session *authSession
+		l       *peerLink
 	}
 	tests := []struct {
 		name   string
@@ -226,15 +317,16 @@ func TestClient_responder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := &Client{
+				userID:              tt.fields.userID,
 				userIP:              tt.fields.userIP,
-				clientsIPs:          tt.fields.clientsIPs,
-				clientsSockets:      tt.fields.clientsSockets,
+				addresses:           make(map[string]string),
+				peers:               tt.fields.peers,
+				deliveries:          NewDeliveryTracker(),
 				chatList:            tt.fields.chatList,
-				sendDataList:        tt.fields.sendDataList,
 				receivedPayloadChan: tt.fields.receivedPayloadChan,
 				secretKey:           tt.fields.secretKey,
 			}
-			if got := c.responder(tt.args.setup); !reflect.DeepEqual(got, tt.want) {
+			if got := c.responder(tt.args.setup, tt.args.session, tt.args.l); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("responder() = %v, want %v", got, tt.want)
 			}
 		})
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+// MIME types announced in setup payload (DataMimeType) to choose codec of connection
+const (
+	MIME_TYPE_JSON     = "application/json"
+	MIME_TYPE_PROTOBUF = "application/x-protobuf"
+)
+
+// Codec converts envelopes to payloads and back
+type Codec interface {
+	// MimeType returns MIME type announced for this codec
+	MimeType() string
+	Marshal(e *Envelope) (payload.Payload, error)
+	Unmarshal(p payload.Payload) (*Envelope, error)
+}
+
+var (
+	JsonCodec     Codec = jsonCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+
+	// DefaultCodec is used when nothing else was negotiated
+	DefaultCodec = ProtobufCodec
+)
+
+// CodecForMimeType returns codec for MIME type announced by other client
+// clients which do not announce known type are assumed to speak JSON
+func CodecForMimeType(mimeType string) Codec {
+	switch mimeType {
+	case MIME_TYPE_PROTOBUF:
+		return ProtobufCodec
+	default:
+		return JsonCodec
+	}
+}
+
+// detectCodec returns codec which encoded given metadata
+// JSON header is always an object, protobuf header never starts with '{'
+func detectCodec(metadata []byte) Codec {
+	if trimmed := bytes.TrimLeft(metadata, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		return JsonCodec
+	}
+	return ProtobufCodec
+}
+
+// jsonCodec encodes header and body as JSON objects
+type jsonCodec struct{}
+
+func (jsonCodec) MimeType() string { return MIME_TYPE_JSON }
+
+func (jsonCodec) Marshal(e *Envelope) (payload.Payload, error) {
+	if err := checkBody(e); err != nil {
+		return nil, err
+	}
+
+	metadata, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(e.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload.New(data, metadata), nil
+}
+
+func (jsonCodec) Unmarshal(p payload.Payload) (*Envelope, error) {
+	metadata, _ := p.Metadata()
+
+	var e Envelope
+	if err := json.Unmarshal(metadata, &e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+
+	body, err := checkHeader(&e)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(p.Data(), body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+	e.Body = body
+
+	return &e, nil
+}
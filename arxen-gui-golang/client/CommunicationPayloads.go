@@ -1,6 +1,13 @@
 package client
 
-import "github.com/rsocket/rsocket-go/payload"
+import (
+	"errors"
+	"main/chat"
+	"main/gql"
+	"main/store"
+	"math"
+	"time"
+)
 
 // Constant types
 // used for communication between hosts
@@ -12,14 +19,394 @@ const (
 	CHAT_MESSAGE               = "CHAT_MESSAGE"
 	CHAT_ADVERT_REQUEST        = "CHAT_ADVERT_REQUEST"
 	CHAT_ADVERT                = "CHAT_ADVERT"
+	HISTORY_SYNC_REQUEST       = "HISTORY_SYNC_REQUEST"
+	HISTORY_SYNC_RESPONSE      = "HISTORY_SYNC_RESPONSE"
+	CHAT_KEY                   = "CHAT_KEY"
+	CHAT_KEY_REQUEST           = "CHAT_KEY_REQUEST"
+	MESSAGE_RECEIPT            = "MESSAGE_RECEIPT"
+	CHAT_MEMBERSHIP            = "CHAT_MEMBERSHIP"
+	CHAT_METADATA              = "CHAT_METADATA"
+	FRIENDSHIP                 = "FRIENDSHIP"
+	PRESENCE                   = "PRESENCE"
+	TYPING                     = "TYPING"
 )
 
-type CommunicationPayload interface {
-	ChatParticipantsResponse() payload.Payload
+// Message is body of an Envelope, each message kind has its own type
+type Message interface {
+	// MessageType returns one of the constant types above
+	MessageType() string
+}
+
+// ephemeral is implemented by messages useful only right away,
+// they are sent only to connected users and never spilled, stored or synced (see sendEphemeral)
+type ephemeral interface {
+	isEphemeral()
+}
+
+// messageFactories maps message type to constructor of its empty body
+var messageFactories = map[string]func() Message{
+	CHAT_MESSAGE:               func() Message { return &ChatMessage{} },
+	CHAT_ADVERT_REQUEST:        func() Message { return &ChatAdvertRequest{} },
+	CHAT_ADVERT:                func() Message { return &ChatAdvert{} },
+	CHAT_PARTICIPANTS_REQUEST:  func() Message { return &ChatParticipantsRequest{} },
+	CHAT_PARTICIPANTS_RESPONSE: func() Message { return &ChatParticipantsResponse{} },
+	HISTORY_SYNC_REQUEST:       func() Message { return &HistorySyncRequest{} },
+	HISTORY_SYNC_RESPONSE:      func() Message { return &HistorySyncResponse{} },
+	CHAT_KEY:                   func() Message { return &ChatKey{} },
+	CHAT_KEY_REQUEST:           func() Message { return &ChatKeyRequest{} },
+	MESSAGE_RECEIPT:            func() Message { return &MessageReceipt{} },
+	CHAT_MEMBERSHIP:            func() Message { return &ChatMembership{} },
+	CHAT_METADATA:              func() Message { return &ChatMetadata{} },
+	FRIENDSHIP:                 func() Message { return &Friendship{} },
+	PRESENCE:                   func() Message { return &Presence{} },
+	TYPING:                     func() Message { return &Typing{} },
+}
+
+// ChatMessage is single text message posted in chat
+// on the wire User, TimeStamp and Text are empty and encrypted in Ciphertext with chat key of Epoch (see Encryption.go)
+type ChatMessage struct {
+	ChatID     string    `json:"chatID"`
+	MessageID  string    `json:"messageID"`
+	User       string    `json:"user,omitempty"`
+	TimeStamp  time.Time `json:"timeStamp"`
+	Text       string    `json:"text,omitempty"`
+	Epoch      uint32    `json:"epoch,omitempty"`
+	Ciphertext []byte    `json:"ciphertext,omitempty"`
+}
+
+// MessageType implements Message
+func (m *ChatMessage) MessageType() string { return CHAT_MESSAGE }
+
+// Validate implements validator
+func (m *ChatMessage) Validate() error {
+	if m.ChatID == "" || m.MessageID == "" || (m.User == "" && len(m.Ciphertext) == 0) {
+		return errors.New("chatID, messageID and user or ciphertext are required")
+	}
+	return nil
+}
+
+// NewChatMessage converts TextMessage (defined in gql module) to ChatMessage
+func NewChatMessage(message gql.TextMessage) *ChatMessage {
+	return &ChatMessage{
+		ChatID:    message.ChatID,
+		MessageID: message.MessageID,
+		User:      message.User,
+		TimeStamp: message.TimeStamp,
+		Text:      message.Text,
+	}
+}
+
+// TextMessage converts ChatMessage to TextMessage (defined in gql module)
+func (m *ChatMessage) TextMessage() gql.TextMessage {
+	return gql.TextMessage{
+		MessageID: m.MessageID,
+		ChatID:    m.ChatID,
+		User:      m.User,
+		TimeStamp: m.TimeStamp,
+		Text:      m.Text,
+	}
+}
+
+// ChatAdvertRequest asks own client to advert chat to all its participants
+type ChatAdvertRequest struct {
+	ChatID string `json:"chatID"`
+}
+
+// MessageType implements Message
+func (m *ChatAdvertRequest) MessageType() string { return CHAT_ADVERT_REQUEST }
+
+// Validate implements validator
+func (m *ChatAdvertRequest) Validate() error { return requireChatID(m.ChatID) }
+
+// ChatAdvert informs participant that it was added to chat
+type ChatAdvert struct {
+	ChatID   string `json:"chatID"`
+	ChatName string `json:"chatName"`
+}
+
+// MessageType implements Message
+func (m *ChatAdvert) MessageType() string { return CHAT_ADVERT }
+
+// Validate implements validator
+func (m *ChatAdvert) Validate() error { return requireChatID(m.ChatID) }
+
+// ChatParticipantsRequest asks for list of chat participants
+type ChatParticipantsRequest struct {
+	ChatID string `json:"chatID"`
+}
+
+// MessageType implements Message
+func (m *ChatParticipantsRequest) MessageType() string { return CHAT_PARTICIPANTS_REQUEST }
+
+// Validate implements validator
+func (m *ChatParticipantsRequest) Validate() error { return requireChatID(m.ChatID) }
+
+// ChatParticipantsResponse carries user IDs of chat participants, their known addresses and roles
+// and current chat key sealed for requester
+// History and MetadataVersions carry signed changes, requester derives admins and metadata from them
+// and verifies them with PublicKeys of their actors, Owner is accepted only as signer of Creation,
+// Admins and Versions are informative only
+type ChatParticipantsResponse struct {
+	ChatID       string                         `json:"chatID"`
+	Participants []string                       `json:"participants"`
+	Addresses    map[string]string              `json:"addresses,omitempty"` // userID : address
+	Key          *ChatKey                       `json:"key,omitempty"`
+	Owner        string                         `json:"owner,omitempty"`
+	Admins       []string                       `json:"admins,omitempty"`
+	Versions     map[string]store.MemberVersion `json:"versions,omitempty"` // see chat/Roles.go
+	ChatName     string                         `json:"chatName,omitempty"`
+	ChatAvatar   string                         `json:"chatAvatar,omitempty"`
+	// see chat/Metadata.go
+	MetadataVersions map[string]store.MemberVersion `json:"metadataVersions,omitempty"`
+	PublicKeys       map[string][]byte              `json:"publicKeys,omitempty"` // actor : Ed25519 public key
+	// creation of chat signed by Owner and all signed changes of roles, see Membership.go
+	Creation *store.MemberVersion  `json:"creation,omitempty"`
+	History  []store.MemberVersion `json:"history,omitempty"`
+}
+
+// MessageType implements Message
+func (m *ChatParticipantsResponse) MessageType() string { return CHAT_PARTICIPANTS_RESPONSE }
+
+// Validate implements validator
+func (m *ChatParticipantsResponse) Validate() error {
+	if err := requireChatID(m.ChatID); err != nil {
+		return err
+	}
+	if len(m.ChatName) > chat.MAX_METADATA_LENGTH || len(m.ChatAvatar) > chat.MAX_METADATA_LENGTH {
+		return errors.New("metadata too long")
+	}
+	if m.Key != nil {
+		if m.Key.ChatID != m.ChatID {
+			return errors.New("key of other chat")
+		}
+		return m.Key.Validate()
+	}
+	return nil
+}
+
+// ChatKey carries chat key of given epoch sealed for recipient with public key of recipient and sender
+type ChatKey struct {
+	ChatID    string `json:"chatID"`
+	Epoch     uint32 `json:"epoch"`
+	SealedKey []byte `json:"sealedKey"`
+}
+
+// MessageType implements Message
+func (m *ChatKey) MessageType() string { return CHAT_KEY }
+
+// Validate implements validator
+func (m *ChatKey) Validate() error {
+	if m.ChatID == "" || m.Epoch == 0 || len(m.SealedKey) == 0 {
+		return errors.New("chatID, epoch and sealedKey are required")
+	}
+	// chat could not be rekeyed after the last epoch
+	if m.Epoch == math.MaxUint32 {
+		return errors.New("epoch out of range")
+	}
+	return nil
+}
+
+// ChatKeyRequest asks participant for keys of epoch missing to decrypt its message (see Encryption.go)
+type ChatKeyRequest struct {
+	ChatID string `json:"chatID"`
+	Epoch  uint32 `json:"epoch"`
+}
+
+// MessageType implements Message
+func (m *ChatKeyRequest) MessageType() string { return CHAT_KEY_REQUEST }
+
+// Validate implements validator
+func (m *ChatKeyRequest) Validate() error {
+	if m.ChatID == "" || m.Epoch == 0 {
+		return errors.New("chatID and epoch are required")
+	}
+	return nil
 }
 
-type CommunicationPayloadGenerator struct{}
+// HistorySyncRequest asks participant for messages of chat newer than SinceMessageID
+// empty SinceMessageID means whole history
+type HistorySyncRequest struct {
+	ChatID         string `json:"chatID"`
+	SinceMessageID string `json:"sinceMessageID"`
+}
+
+// MessageType implements Message
+func (m *HistorySyncRequest) MessageType() string { return HISTORY_SYNC_REQUEST }
+
+// Validate implements validator
+func (m *HistorySyncRequest) Validate() error { return requireChatID(m.ChatID) }
+
+// HistorySyncResponse carries batch of messages of chat, the oldest first
+type HistorySyncResponse struct {
+	ChatID   string         `json:"chatID"`
+	Messages []*ChatMessage `json:"messages"`
+}
+
+// MessageType implements Message
+func (m *HistorySyncResponse) MessageType() string { return HISTORY_SYNC_RESPONSE }
+
+// Validate implements validator
+func (m *HistorySyncResponse) Validate() error {
+	if err := requireChatID(m.ChatID); err != nil {
+		return err
+	}
+	for _, message := range m.Messages {
+		if message == nil || message.ChatID != m.ChatID {
+			return errors.New("message of other chat in history")
+		}
+		if err := message.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MessageReceipt informs author that its messages were delivered to or read by sender
+type MessageReceipt struct {
+	ChatID     string              `json:"chatID"`
+	MessageIDs []string            `json:"messageIDs"`
+	Status     store.ReceiptStatus `json:"status"`
+}
+
+// MessageType implements Message
+func (m *MessageReceipt) MessageType() string { return MESSAGE_RECEIPT }
+
+// Validate implements validator
+func (m *MessageReceipt) Validate() error {
+	if err := requireChatID(m.ChatID); err != nil {
+		return err
+	}
+	if len(m.MessageIDs) == 0 {
+		return errors.New("messageIDs are required")
+	}
+	if m.Status != store.RECEIPT_DELIVERED && m.Status != store.RECEIPT_READ {
+		return errors.New("unknown receipt status")
+	}
+	return nil
+}
+
+// ChatMembership informs participants that role of user in chat was changed by Actor (see Membership.go)
+type ChatMembership struct {
+	ChatID    string           `json:"chatID"`
+	Action    MembershipAction `json:"action"`
+	UserID    string           `json:"userID"`
+	Actor     string           `json:"actor"`
+	Clock     int              `json:"clock"`
+	Signature []byte           `json:"signature"`
+}
+
+// MessageType implements Message
+func (m *ChatMembership) MessageType() string { return CHAT_MEMBERSHIP }
+
+// Validate implements validator
+func (m *ChatMembership) Validate() error {
+	if err := requireChatID(m.ChatID); err != nil {
+		return err
+	}
+	if m.UserID == "" || m.Actor == "" || len(m.Signature) == 0 {
+		return errors.New("userID, actor and signature are required")
+	}
+	if _, ok := membershipRoles[m.Action]; !ok {
+		return errors.New("unknown membership action")
+	}
+	return nil
+}
+
+// ChatMetadata informs participants that name or avatar of chat was changed by Actor (see Metadata.go)
+type ChatMetadata struct {
+	ChatID    string             `json:"chatID"`
+	Field     chat.MetadataField `json:"field"`
+	Value     string             `json:"value"`
+	Actor     string             `json:"actor"`
+	Clock     int                `json:"clock"`
+	Signature []byte             `json:"signature"`
+}
+
+// MessageType implements Message
+func (m *ChatMetadata) MessageType() string { return CHAT_METADATA }
+
+// Validate implements validator
+func (m *ChatMetadata) Validate() error {
+	if err := requireChatID(m.ChatID); err != nil {
+		return err
+	}
+	if m.Actor == "" || len(m.Signature) == 0 {
+		return errors.New("actor and signature are required")
+	}
+	if m.Field != chat.METADATA_NAME && m.Field != chat.METADATA_AVATAR {
+		return errors.New("unknown metadata field")
+	}
+	if len(m.Value) > chat.MAX_METADATA_LENGTH {
+		return errors.New("value too long")
+	}
+	return nil
+}
+
+// Friendship is request for friendship, answer to it or change of friend (see Friends.go)
+type Friendship struct {
+	Action    FriendshipAction `json:"action"`
+	Nick      string           `json:"nick,omitempty"`
+	PublicKey []byte           `json:"publicKey,omitempty"` // Ed25519 public key of sender
+	Address   string           `json:"address,omitempty"`   // address of sender
+}
+
+// MessageType implements Message
+func (m *Friendship) MessageType() string { return FRIENDSHIP }
+
+// Validate implements validator
+func (m *Friendship) Validate() error {
+	if !friendshipActions[m.Action] {
+		return errors.New("unknown friendship action")
+	}
+	if (m.Action == FRIENDSHIP_REQUEST || m.Action == FRIENDSHIP_ACCEPT) && len(m.PublicKey) == 0 {
+		return errors.New("publicKey is required")
+	}
+	if len(m.Nick) > MAX_NICK_LENGTH {
+		return errors.New("nick too long")
+	}
+	return nil
+}
+
+// Presence is heartbeat with status of sender (see Presence.go)
+type Presence struct {
+	Status PresenceStatus `json:"status"`
+}
+
+// MessageType implements Message
+func (m *Presence) MessageType() string { return PRESENCE }
+
+// isEphemeral implements ephemeral
+func (m *Presence) isEphemeral() {}
+
+// Validate implements validator
+func (m *Presence) Validate() error {
+	if !presenceStatuses[m.Status] {
+		return errors.New("unknown presence status")
+	}
+	return nil
+}
+
+// Typing is ephemeral event of user starting or stopping typing in chat (see Typing.go)
+type Typing struct {
+	ChatID string `json:"chatID"`
+	Typing bool   `json:"typing"`
+}
+
+// MessageType implements Message
+func (m *Typing) MessageType() string { return TYPING }
+
+// isEphemeral implements ephemeral
+func (m *Typing) isEphemeral() {}
+
+// Validate implements validator
+func (m *Typing) Validate() error {
+	return requireChatID(m.ChatID)
+}
 
-func ChatParticipantsResponse(clientAddr string) payload.Payload {
-	return payload.New([]byte(``), []byte(`{"source":"+", "type":"CHAT_PARTICIPANTS_RESPONSE"}`))
+// requireChatID is validation shared by messages referring to chat
+func requireChatID(chatID string) error {
+	if chatID == "" {
+		return errors.New("chatID is required")
+	}
+	return nil
 }
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"log"
+	"main/dht"
+	"main/identity"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// peer discovery:
+// client runs DHT node (see dht package) with node ID equal to its user ID
+// and stores there signed record with its current address under the same key,
+// address of user not known yet is looked up in DHT and record is accepted only
+// if it is signed by key user ID was derived from, so nobody can redirect connections to other user
+
+// UDP address of DHT node used when DHT_ADDR is not set
+const DEFAULT_DHT_ADDR = ":7879"
+
+// how often own address record is stored again, it expires after dht.VALUE_TTL
+const ADDRESS_RECORD_REPUBLISH = dht.VALUE_TTL / 3
+
+// time after failed lookup of user before it is looked up again
+const DISCOVERY_RETRY_BACKOFF = time.Minute
+
+// how far in the future time stamp of address record can be (clocks of users differ)
+const ADDRESS_RECORD_MAX_SKEW = 5 * time.Minute
+
+// signed together with address record
+const ADDRESS_RECORD_CONTEXT = "arxen-address-v1"
+
+// ErrInvalidRecord is returned when address record is malformed, not signed by its user or outdated
+var ErrInvalidRecord = errors.New("invalid address record")
+
+// addressRecord is value stored in DHT under user ID
+type addressRecord struct {
+	UserID    string    `json:"userID"`
+	PublicKey string    `json:"publicKey"` // hex encoded Ed25519 public key
+	Address   string    `json:"address"`
+	TimeStamp time.Time `json:"timeStamp"`
+	Signature []byte    `json:"signature"`
+}
+
+// signedContent returns bytes signed by user publishing record
+func (r addressRecord) signedContent() []byte {
+	content := []byte(ADDRESS_RECORD_CONTEXT)
+	content = appendField(content, []byte(r.UserID))
+	content = appendField(content, []byte(r.Address))
+	var timeStamp [8]byte
+	binary.BigEndian.PutUint64(timeStamp[:], uint64(r.TimeStamp.UnixNano()))
+	return appendField(content, timeStamp[:])
+}
+
+// parseAddressRecord decodes record and checks it was published by user whose ID is key
+func parseAddressRecord(key dht.NodeID, value []byte) (addressRecord, error) {
+	var record addressRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return record, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+	if id, err := dht.ParseNodeID(record.UserID); err != nil || id != key {
+		return record, fmt.Errorf("%w: stored under %s by %s", ErrInvalidRecord, key, record.UserID)
+	}
+	publicKey, err := identity.VerifyUserID(record.UserID, record.PublicKey)
+	if err != nil {
+		return record, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+	if !identity.Verify(publicKey, record.signedContent(), record.Signature) {
+		return record, fmt.Errorf("%w: invalid signature of %s", ErrInvalidRecord, record.UserID)
+	}
+	if record.TimeStamp.After(time.Now().Add(ADDRESS_RECORD_MAX_SKEW)) {
+		return record, fmt.Errorf("%w: time stamp %v in the future", ErrInvalidRecord, record.TimeStamp)
+	}
+	return record, nil
+}
+
+// validateAddressRecord is dht.Validator accepting only valid records newer than current one
+func validateAddressRecord(key dht.NodeID, value, current []byte) error {
+	record, err := parseAddressRecord(key, value)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+	if currentRecord, err := parseAddressRecord(key, current); err == nil && !record.TimeStamp.After(currentRecord.TimeStamp) {
+		return fmt.Errorf("%w: older than stored one", ErrInvalidRecord)
+	}
+	return nil
+}
+
+// newAddressRecord returns own address record signed now
+func (c *Client) newAddressRecord() ([]byte, error) {
+	record := addressRecord{
+		UserID:    c.userID,
+		PublicKey: c.identity.PublicKeyHex(),
+		Address:   c.userIP,
+		TimeStamp: time.Now().UTC(),
+	}
+	record.Signature = c.identity.Sign(record.signedContent())
+	return json.Marshal(record)
+}
+
+// startDiscovery starts DHT node at DHT_ADDR, joins network through nodes from DHT_BOOTSTRAP
+// (comma separated host:port) and keeps own address record published
+func (c *Client) startDiscovery() {
+	addr := DEFAULT_DHT_ADDR
+	if value, ok := os.LookupEnv("DHT_ADDR"); ok {
+		addr = value
+		log.Println("startDiscovery: obtained predefined DHT addr = " + addr)
+	}
+	var bootstrap []string
+	if value, ok := os.LookupEnv("DHT_BOOTSTRAP"); ok && value != "" {
+		bootstrap = strings.Split(value, ",")
+	}
+
+	nodeID, err := dht.ParseNodeID(c.userID)
+	if err != nil {
+		logger.WithError(err).Error("startDiscovery: user ID is not valid node ID")
+		return
+	}
+	node, err := dht.Listen(nodeID, addr, validateAddressRecord)
+	if err != nil {
+		logger.WithError(err).WithField("addr", addr).Error("startDiscovery: cannot start DHT node, users can be reached only at known addresses")
+		return
+	}
+	c.mutex.Lock()
+	c.dht = node
+	c.mutex.Unlock()
+
+	for {
+		// nodes from bootstrap list could be not started yet
+		if node.Size() == 0 && len(bootstrap) > 0 {
+			if err := node.Bootstrap(bootstrap); err != nil {
+				logger.WithError(err).Warn("startDiscovery: cannot join DHT")
+			}
+		}
+		c.publishAddress()
+		time.Sleep(ADDRESS_RECORD_REPUBLISH)
+	}
+}
+
+// publishAddress stores own address record in DHT
+func (c *Client) publishAddress() {
+	node := c.discoveryNode()
+	if node == nil {
+		return
+	}
+
+	record, err := c.newAddressRecord()
+	if err != nil {
+		logger.WithError(err).Error("publishAddress: cannot create address record")
+		return
+	}
+	if err := node.Store(node.ID(), record); err != nil {
+		logger.WithError(err).Warn("publishAddress: address not published")
+		return
+	}
+	logger.WithField("addr", c.userIP).Info("publishAddress: address published")
+}
+
+// resolveAddress looks up current address of user in DHT and remembers it
+func (c *Client) resolveAddress(userID string) (string, error) {
+	node := c.discoveryNode()
+	if node == nil {
+		return "", fmt.Errorf("%w: %s, discovery not running", ErrUnknownPeer, userID)
+	}
+	key, err := dht.ParseNodeID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := node.FindValue(key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrUnknownPeer, userID, err)
+	}
+	record, err := parseAddressRecord(key, value)
+	if err != nil {
+		return "", err
+	}
+
+	// record was signed by user, so its key can be trusted as well
+	c.rememberPeer(PeerIdentity{UserID: record.UserID, PublicKey: record.PublicKey}, record.Address)
+	return record.Address, nil
+}
+
+// lookups limits lookups of users, zero value is ready to use
+type lookups struct {
+	mutex    sync.Mutex
+	inFlight map[string]bool      // userID : being looked up
+	failed   map[string]time.Time // userID : time of the last failed lookup
+}
+
+// begin reports if user should be looked up, it is not when lookup is in flight
+// or the last one failed less than DISCOVERY_RETRY_BACKOFF ago
+func (l *lookups) begin(userID string, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.inFlight[userID] {
+		return false
+	}
+	if failed, ok := l.failed[userID]; ok && now.Sub(failed) < DISCOVERY_RETRY_BACKOFF {
+		return false
+	}
+	if l.inFlight == nil {
+		l.inFlight = make(map[string]bool)
+	}
+	l.inFlight[userID] = true
+	return true
+}
+
+// end records result of lookup started by begin
+func (l *lookups) end(userID string, ok bool, now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.inFlight, userID)
+	if ok {
+		delete(l.failed, userID)
+		return
+	}
+	if l.failed == nil {
+		l.failed = make(map[string]time.Time)
+	}
+	l.failed[userID] = now
+}
+
+// lookUpParticipant starts discovery of chat participant unless discovery is not running,
+// it is already being looked up or the last lookup failed recently
+func (c *Client) lookUpParticipant(userID string) {
+	if c.discoveryNode() == nil || !c.lookups.begin(userID, time.Now()) {
+		return
+	}
+	go c.discoverParticipant(userID)
+}
+
+// discoverParticipant resolves address of chat participant and marks it to be connected,
+// lookup has to be started by lookups.begin
+func (c *Client) discoverParticipant(userID string) {
+	addr, err := c.resolveAddress(userID)
+	c.lookups.end(userID, err == nil, time.Now())
+	if err != nil {
+		logger.WithError(err).WithField("userID", userID).Warn("discoverParticipant: address of participant unknown")
+		return
+	}
+
+	c.peers.Register(addr)
+}
+
+// discoveryNode returns DHT node, nil if it is not running
+func (c *Client) discoveryNode() *dht.DHT {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.dht
+}
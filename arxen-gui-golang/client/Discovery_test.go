@@ -0,0 +1,149 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"main/dht"
+	"testing"
+	"time"
+)
+
+// startTestDiscovery starts DHT node of client on loopback, joining network through bootstrap node if given
+func startTestDiscovery(t *testing.T, c *Client, bootstrap *dht.DHT) *dht.DHT {
+	nodeID, err := dht.ParseNodeID(c.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := dht.Listen(nodeID, "127.0.0.1:0", validateAddressRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.dht = node
+	if bootstrap != nil {
+		if err := node.Bootstrap([]string{bootstrap.Addr().String()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return node
+}
+
+func TestClient_resolveAddress(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	relay := newTestClient(t)
+	relayNode := startTestDiscovery(t, relay, nil)
+	defer relayNode.Close()
+	defer startTestDiscovery(t, a, relayNode).Close()
+	defer startTestDiscovery(t, b, relayNode).Close()
+
+	if _, err := b.resolveAddress(a.userID); !errors.Is(err, ErrUnknownPeer) {
+		t.Errorf("resolveAddress() of not published user error = %v, want %v", err, ErrUnknownPeer)
+	}
+
+	a.publishAddress()
+	addr, err := b.resolveAddress(a.userID)
+	if err != nil || addr != a.userIP {
+		t.Fatalf("resolveAddress() = %s, %v, want %s", addr, err, a.userIP)
+	}
+	if known, ok := b.AddressOf(a.userID); !ok || known != a.userIP {
+		t.Errorf("AddressOf() after resolving = %s, %v", known, ok)
+	}
+	if key, err := b.store.PublicKey(a.userID); err != nil || string(key) != string(a.identity.PublicKey) {
+		t.Errorf("public key after resolving = %x, %v", key, err)
+	}
+
+	// address changes, newer record replaces old one
+	a.userIP = "tcp://127.0.0.1:1"
+	a.publishAddress()
+	if addr, err := b.resolveAddress(a.userID); err != nil || addr != a.userIP {
+		t.Errorf("resolveAddress() after address change = %s, %v, want %s", addr, err, a.userIP)
+	}
+}
+
+func TestValidateAddressRecord(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	key, _ := dht.ParseNodeID(a.userID)
+
+	older, err := a.newAddressRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	newer, err := a.newAddressRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateAddressRecord(key, older, nil); err != nil {
+		t.Errorf("validateAddressRecord() of valid record error = %v", err)
+	}
+	if err := validateAddressRecord(key, newer, older); err != nil {
+		t.Errorf("validateAddressRecord() of newer record error = %v", err)
+	}
+
+	modify := func(record []byte, change func(r *addressRecord)) []byte {
+		var r addressRecord
+		if err := json.Unmarshal(record, &r); err != nil {
+			t.Fatal(err)
+		}
+		change(&r)
+		data, _ := json.Marshal(r)
+		return data
+	}
+	bRecord, err := b.newAddressRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		value, current []byte
+	}{
+		{"older than stored", older, newer},
+		{"malformed", []byte("{"), nil},
+		{"stored under other user", bRecord, nil},
+		{"redirected", modify(newer, func(r *addressRecord) { r.Address = "tcp://10.6.0.9:7878" }), nil},
+		{"key of other user", modify(bRecord, func(r *addressRecord) { r.UserID = a.userID }), nil},
+		{"in the future", modify(newer, func(r *addressRecord) {
+			r.TimeStamp = time.Now().Add(time.Hour)
+			r.Signature = a.identity.Sign(r.signedContent())
+		}), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAddressRecord(key, tt.value, tt.current); !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("validateAddressRecord() error = %v, want %v", err, ErrInvalidRecord)
+			}
+		})
+	}
+}
+
+func TestLookups(t *testing.T) {
+	var l lookups
+	now := time.Now()
+
+	if !l.begin("a", now) {
+		t.Fatal("begin() of the first lookup = false, want true")
+	}
+	if l.begin("a", now) {
+		t.Error("begin() while lookup is in flight = true, want false")
+	}
+	if !l.begin("b", now) {
+		t.Error("begin() of other user = false, want true")
+	}
+
+	// failed lookup is not repeated until backoff passes
+	l.end("a", false, now)
+	if l.begin("a", now.Add(DISCOVERY_RETRY_BACKOFF/2)) {
+		t.Error("begin() after failed lookup = true, want false")
+	}
+	if !l.begin("a", now.Add(DISCOVERY_RETRY_BACKOFF)) {
+		t.Error("begin() after backoff = false, want true")
+	}
+
+	// successful lookup can be repeated at once
+	l.end("b", true, now)
+	if !l.begin("b", now) {
+		t.Error("begin() after successful lookup = false, want true")
+	}
+}
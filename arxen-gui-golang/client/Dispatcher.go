@@ -0,0 +1,143 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rsocket/rsocket-go/payload"
+	logger "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// reasons of rejecting incoming payloads
+const (
+	REJECT_MALFORMED           = "malformed"
+	REJECT_UNSUPPORTED_VERSION = "unsupported_version"
+	REJECT_UNKNOWN_TYPE        = "unknown_type"
+	REJECT_NO_HANDLER          = "no_handler"
+	REJECT_INVALID             = "invalid"
+	REJECT_HANDLER_ERROR       = "handler_error"
+	REJECT_PANIC               = "panic"
+	REJECT_SPOOFED             = "spoofed_source"
+	REJECT_BLOCKED             = "blocked"
+)
+
+// ErrInvalidMessage is returned for messages which decoded correctly but can not be accepted
+var ErrInvalidMessage = errors.New("invalid message")
+
+// HandlerFunc handles envelope of single message type
+type HandlerFunc func(e *Envelope) error
+
+// validator is implemented by messages which can check their own content
+type validator interface {
+	Validate() error
+}
+
+// Dispatcher routes incoming payloads to handlers registered for their message type
+// every payload which can not be handled is rejected and counted with a reason
+type Dispatcher struct {
+	mutex    sync.RWMutex
+	handlers map[string]HandlerFunc // message type : handler
+	rejected map[string]uint64      // reason : number of rejected payloads
+}
+
+// NewDispatcher returns Dispatcher without any handlers
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		handlers: make(map[string]HandlerFunc),
+		rejected: make(map[string]uint64),
+	}
+}
+
+// Register sets handler for message type, replacing previous one
+func (d *Dispatcher) Register(messageType string, handler HandlerFunc) {
+	d.mutex.Lock()
+	d.handlers[messageType] = handler
+	d.mutex.Unlock()
+}
+
+// Dispatch decodes payload and passes it to handler of its type
+// never panics, returned error is already counted as rejection
+func (d *Dispatcher) Dispatch(p payload.Payload) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = d.reject(REJECT_PANIC, fmt.Errorf("decoding panicked: %v", r))
+		}
+	}()
+
+	envelope, err := UnmarshalEnvelope(p)
+	if err != nil {
+		return d.reject(rejectReason(err), err)
+	}
+	return d.DispatchEnvelope(envelope)
+}
+
+// DispatchEnvelope passes already decoded envelope to handler of its type
+func (d *Dispatcher) DispatchEnvelope(e *Envelope) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = d.reject(REJECT_PANIC, fmt.Errorf("handler of %s panicked: %v", e.Type, r))
+		}
+	}()
+
+	if e.Body == nil || e.Body.MessageType() != e.Type {
+		return d.reject(REJECT_MALFORMED, fmt.Errorf("%w: body does not match type %q", ErrMalformedPayload, e.Type))
+	}
+
+	if v, ok := e.Body.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return d.reject(REJECT_INVALID, fmt.Errorf("%w: %s: %v", ErrInvalidMessage, e.Type, err))
+		}
+	}
+
+	d.mutex.RLock()
+	handler, ok := d.handlers[e.Type]
+	d.mutex.RUnlock()
+	if !ok {
+		return d.reject(REJECT_NO_HANDLER, fmt.Errorf("no handler registered for %q", e.Type))
+	}
+
+	if err := handler(e); err != nil {
+		if errors.Is(err, ErrInvalidMessage) {
+			return d.reject(REJECT_INVALID, err)
+		}
+		if errors.Is(err, ErrBlocked) {
+			return d.reject(REJECT_BLOCKED, err)
+		}
+		return d.reject(REJECT_HANDLER_ERROR, err)
+	}
+	return nil
+}
+
+// Rejected returns number of rejected payloads per reason
+func (d *Dispatcher) Rejected() map[string]uint64 {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	rejected := make(map[string]uint64, len(d.rejected))
+	for reason, count := range d.rejected {
+		rejected[reason] = count
+	}
+	return rejected
+}
+
+// reject counts rejection and returns err
+func (d *Dispatcher) reject(reason string, err error) error {
+	d.mutex.Lock()
+	d.rejected[reason]++
+	d.mutex.Unlock()
+
+	logger.WithError(err).WithField("reason", reason).Warn("Dispatcher: payload rejected")
+	return err
+}
+
+// rejectReason maps decoding error to reason of rejection
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrUnsupportedVersion):
+		return REJECT_UNSUPPORTED_VERSION
+	case errors.Is(err, ErrUnknownMessageType):
+		return REJECT_UNKNOWN_TYPE
+	default:
+		return REJECT_MALFORMED
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"github.com/rsocket/rsocket-go/payload"
+	"reflect"
+	"testing"
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	validAdvert, err := MarshalEnvelope(NewEnvelope("a", &ChatAdvert{ChatID: "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emptyAdvert, err := JsonCodec.Marshal(NewEnvelope("a", &ChatAdvert{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	panicking, err := MarshalEnvelope(NewEnvelope("a", &ChatParticipantsRequest{ChatID: "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unhandled, err := MarshalEnvelope(NewEnvelope("a", &ChatAdvertRequest{ChatID: "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handled []*Envelope
+	d := NewDispatcher()
+	d.Register(CHAT_ADVERT, func(e *Envelope) error {
+		handled = append(handled, e)
+		return nil
+	})
+	d.Register(CHAT_PARTICIPANTS_REQUEST, func(e *Envelope) error {
+		var chats map[string]string
+		chats["1"] = "assignment to nil map"
+		return nil
+	})
+
+	tests := []struct {
+		name    string
+		payload payload.Payload
+		wantErr bool
+	}{
+		{"valid", validAdvert, false},
+		{"garbage metadata", payload.New([]byte{0x01}, []byte{0xff, 0xff}), true},
+		{"unversioned json", payload.NewString("1", `{"source":"a","type":"CHAT_MESSAGE"}`), true},
+		{"missing chatID", emptyAdvert, true},
+		{"panicking handler", panicking, true},
+		{"no handler", unhandled, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.Dispatch(tt.payload); (err != nil) != tt.wantErr {
+				t.Errorf("Dispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if len(handled) != 1 {
+		t.Errorf("handled %d payloads, want 1", len(handled))
+	}
+
+	want := map[string]uint64{
+		REJECT_MALFORMED:           1,
+		REJECT_UNSUPPORTED_VERSION: 1,
+		REJECT_INVALID:             1,
+		REJECT_PANIC:               1,
+		REJECT_NO_HANDLER:          1,
+	}
+	if got := d.Rejected(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Rejected() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,479 @@
+package client
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/chat"
+	"main/gql"
+	"math"
+	"sync"
+	"time"
+)
+
+// end-to-end encryption of chats:
+// - creator of chat generates chat key (epoch 1),
+// - participant gets current key sealed with its public key in CHAT_PARTICIPANTS_RESPONSE,
+// - when participants change, new key with higher epoch is sent to every participant in CHAT_KEY,
+//   participant whose public key is unknown gets it when it connects, meanwhile it is looked up,
+// - participants rekeying at the same time send different keys of the same epoch,
+//   every participant uses the lowest one and keeps the others to decrypt messages (see chat.AddKey),
+// - new key is accepted only from participant allowed to rekey (see rekeyers): the one who removed user,
+//   or remaining participant with the lowest user ID after user left, and only for the current or the next epoch,
+//   keys of older epochs are accepted only from participant they were requested from, the last epoch is never used
+// - CHAT_MESSAGE carries User, TimeStamp, Clock and Text encrypted with current key,
+//   messages are decrypted before being stored and passed to subscribers of chat
+// - message of epoch without key, or which none of its keys decrypts, is rejected and CHAT_KEY_REQUEST {chatID, epoch} is sent to its sender
+//   at most once per KEY_REQUEST_INTERVAL, sender answers with all keys of epoch in CHAT_KEY
+//   and the message is accepted when author sends it again (see Receipts.go)
+// keys and messages are kept decrypted in local store only
+
+// ErrNoEpochLeft is returned when chat cannot be rekeyed, its key is already of the last epoch
+var ErrNoEpochLeft = errors.New("no epoch left for new chat key")
+
+// time between requests for the same missing key sent to the same user
+const KEY_REQUEST_INTERVAL = 30 * time.Second
+
+// keyRequests limits CHAT_KEY_REQUEST sent for missing keys, zero value is ready to use
+type keyRequests struct {
+	mutex sync.Mutex
+	sent  map[string]time.Time // chatID/epoch/userID : time of the last request
+}
+
+// allow reports if key of epoch can be requested from user now, it records the request if so
+func (r *keyRequests) allow(chatID string, epoch uint32, userID string, now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := fmt.Sprintf("%s/%d/%s", chatID, epoch, userID)
+	if sent, ok := r.sent[id]; ok && now.Sub(sent) < KEY_REQUEST_INTERVAL {
+		return false
+	}
+	if r.sent == nil {
+		r.sent = make(map[string]time.Time)
+	}
+	// forget old requests, so map does not grow
+	for other, sent := range r.sent {
+		if now.Sub(sent) >= KEY_REQUEST_INTERVAL {
+			delete(r.sent, other)
+		}
+	}
+	r.sent[id] = now
+	return true
+}
+
+// requested reports if key of epoch was requested from user recently
+func (r *keyRequests) requested(chatID string, epoch uint32, userID string, now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sent, ok := r.sent[fmt.Sprintf("%s/%d/%s", chatID, epoch, userID)]
+	return ok && now.Sub(sent) < KEY_REQUEST_INTERVAL
+}
+
+// rekeyers are participants allowed to send new key of chat after participants changed,
+// zero value is ready to use
+type rekeyers struct {
+	mutex sync.Mutex
+	users map[string]map[string]uint32 // chatID : userID : the lowest epoch of key it can send
+}
+
+// allow lets user send one new key of chat of given epoch or higher one
+func (r *rekeyers) allow(chatID, userID string, epoch uint32) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.users == nil {
+		r.users = make(map[string]map[string]uint32)
+	}
+	if r.users[chatID] == nil {
+		r.users[chatID] = make(map[string]uint32)
+	}
+	if from, ok := r.users[chatID][userID]; !ok || epoch < from {
+		r.users[chatID][userID] = epoch
+	}
+}
+
+// take reports if user can send key of chat of given epoch and forgets it if so
+func (r *rekeyers) take(chatID, userID string, epoch uint32) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	from, ok := r.users[chatID][userID]
+	if !ok || epoch < from {
+		return false
+	}
+	delete(r.users[chatID], userID)
+	if len(r.users[chatID]) == 0 {
+		delete(r.users, chatID)
+	}
+	return true
+}
+
+// pendingKeys are chats whose key could not be sealed for participant with unknown public key,
+// zero value is ready to use
+type pendingKeys struct {
+	mutex sync.Mutex
+	chats map[string]map[string]bool // userID : chatIDs
+}
+
+// add remembers that user waits for key of chat
+func (p *pendingKeys) add(userID, chatID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.chats == nil {
+		p.chats = make(map[string]map[string]bool)
+	}
+	if p.chats[userID] == nil {
+		p.chats[userID] = make(map[string]bool)
+	}
+	p.chats[userID][chatID] = true
+}
+
+// take returns chats whose keys user waits for and forgets them
+func (p *pendingKeys) take(userID string) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var chatIDs []string
+	for chatID := range p.chats[userID] {
+		chatIDs = append(chatIDs, chatID)
+	}
+	delete(p.chats, userID)
+	return chatIDs
+}
+
+// sealedChatMessage is content of CHAT_MESSAGE encrypted in Ciphertext
+// ChatID and MessageID are repeated, so ciphertext cannot be moved to other message
+// Signature and AuthorKey are described in Signature.go
+type sealedChatMessage struct {
+	ChatID    string    `json:"chatID"`
+	MessageID string    `json:"messageID"`
+	User      string    `json:"user"`
+	TimeStamp time.Time `json:"timeStamp"`
+	Clock     int       `json:"clock,omitempty"` // Lamport clock of chat, see store.MessageBefore
+	Text      string    `json:"text"`
+	Signature []byte    `json:"signature,omitempty"`
+	AuthorKey []byte    `json:"authorKey,omitempty"`
+}
+
+// encryptMessage returns CHAT_MESSAGE with message encrypted by current key of chat
+// chats without any key (created before encryption) get the first one
+func (c *Client) encryptMessage(tmpChat *chat.Chat, message gql.TextMessage) (*ChatMessage, error) {
+	epoch, key, err := tmpChat.CurrentKey()
+	if errors.Is(err, chat.ErrNoKey) {
+		if err := c.rekeyChat(tmpChat); err != nil {
+			return nil, err
+		}
+		epoch, key, err = tmpChat.CurrentKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signature, authorKey := c.messageSignature(message)
+	plaintext, err := json.Marshal(sealedChatMessage{
+		ChatID:    message.ChatID,
+		MessageID: message.MessageID,
+		User:      message.User,
+		TimeStamp: message.TimeStamp,
+		Clock:     message.Clock,
+		Text:      message.Text,
+		Signature: signature,
+		AuthorKey: authorKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := chat.Encrypt(key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChatMessage{
+		ChatID:     message.ChatID,
+		MessageID:  message.MessageID,
+		Epoch:      epoch,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// decryptMessage returns content of encrypted CHAT_MESSAGE, message is returned only if its signature is valid
+// keys of epoch unknown to this client are requested from sender of message
+func (c *Client) decryptMessage(tmpChat *chat.Chat, sender string, m *ChatMessage) (gql.TextMessage, error) {
+	if len(m.Ciphertext) == 0 {
+		return gql.TextMessage{}, fmt.Errorf("%w: plaintext message %s", ErrInvalidMessage, m.MessageID)
+	}
+	keys, err := tmpChat.Keys(m.Epoch)
+	if err != nil {
+		c.requestChatKey(tmpChat.ChatID, m.Epoch, sender)
+		return gql.TextMessage{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	// message could be encrypted with any of concurrent keys
+	var plaintext []byte
+	for _, key := range keys {
+		if plaintext, err = chat.Decrypt(key, m.Ciphertext); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		// concurrent key of epoch could be missing
+		c.requestChatKey(tmpChat.ChatID, m.Epoch, sender)
+		return gql.TextMessage{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	var sealed sealedChatMessage
+	if err := json.Unmarshal(plaintext, &sealed); err != nil {
+		return gql.TextMessage{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if sealed.ChatID != m.ChatID || sealed.MessageID != m.MessageID {
+		return gql.TextMessage{}, fmt.Errorf("%w: ciphertext of other message", ErrInvalidMessage)
+	}
+	if sealed.Clock < 0 {
+		return gql.TextMessage{}, fmt.Errorf("%w: negative clock of message %s", ErrInvalidMessage, m.MessageID)
+	}
+
+	message := gql.TextMessage{
+		MessageID: sealed.MessageID,
+		ChatID:    sealed.ChatID,
+		User:      sealed.User,
+		TimeStamp: sealed.TimeStamp,
+		Clock:     sealed.Clock,
+		Text:      sealed.Text,
+	}
+	if err := c.verifyMessage(&message, sealed.Signature, sealed.AuthorKey); err != nil {
+		return gql.TextMessage{}, err
+	}
+	return message, nil
+}
+
+// sealChatKey returns current key of chat sealed for user
+func (c *Client) sealChatKey(tmpChat *chat.Chat, userID string) (*ChatKey, error) {
+	epoch, key, err := tmpChat.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	return c.sealKey(tmpChat.ChatID, epoch, key, userID)
+}
+
+// sealKey returns key of chat of given epoch sealed for user
+func (c *Client) sealKey(chatID string, epoch uint32, key []byte, userID string) (*ChatKey, error) {
+	publicKey, err := c.publicKeyOf(userID)
+	if err != nil {
+		return nil, err
+	}
+	sealedKey, err := c.identity.Seal(publicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatKey{ChatID: chatID, Epoch: epoch, SealedKey: sealedKey}, nil
+}
+
+// openChatKey adds key sealed for this client by sender to chat
+// solicited key came with participants of chat asked by this client, other keys have to be allowed (see keyAllowed)
+func (c *Client) openChatKey(tmpChat *chat.Chat, sender string, k *ChatKey, solicited bool) error {
+	current, _, err := tmpChat.CurrentKey()
+	if err != nil && !errors.Is(err, chat.ErrNoKey) {
+		return err
+	}
+	if !solicited && !c.keyAllowed(tmpChat.ChatID, sender, k.Epoch, current) {
+		return fmt.Errorf("%w: %s cannot send key of epoch %d of chat %s at epoch %d",
+			ErrInvalidMessage, sender, k.Epoch, tmpChat.ChatID, current)
+	}
+
+	publicKey, err := c.publicKeyOf(sender)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	key, err := c.identity.Open(publicKey, k.SealedKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if len(key) != chat.KEY_SIZE {
+		return fmt.Errorf("%w: key of %d bytes", ErrInvalidMessage, len(key))
+	}
+
+	log := logger.WithFields(logger.Fields{"chatID": tmpChat.ChatID, "epoch": k.Epoch})
+	if !tmpChat.AddKey(k.Epoch, key) {
+		log.Debug("openChatKey: key already known")
+		return nil
+	}
+	if keys, err := tmpChat.Keys(k.Epoch); err == nil && len(keys) > 1 {
+		log.WithField("keys", len(keys)).Info("openChatKey: concurrent keys of epoch, the lowest one is used")
+	}
+	return c.store.SaveChat(tmpChat.Record())
+}
+
+// keyAllowed reports if sender can give key of epoch to chat whose current epoch is given (0 without key):
+// the first key of chat without any, key requested from sender up to the next epoch,
+// or key of the current or the next epoch from participant allowed to rekey
+// allowance of rekeyer is used up by its key
+func (c *Client) keyAllowed(chatID, sender string, epoch, current uint32) bool {
+	switch {
+	case current == 0 && epoch == 1:
+		return true
+	case current == math.MaxUint32 || epoch > current+1:
+		return false
+	case c.keyRequests.requested(chatID, epoch, sender, time.Now()):
+		return true
+	case epoch >= current:
+		return c.rekeyers.take(chatID, sender, epoch)
+	}
+	return false
+}
+
+// rekeyChat generates new key of chat and sends it to all participants
+// called when participants of chat change, so removed ones cannot read new messages
+// key is sent only after it became current one, if other key of the next epoch is used
+// the new key takes the epoch after it
+func (c *Client) rekeyChat(tmpChat *chat.Chat) error {
+	key, err := chat.NewKey()
+	if err != nil {
+		return err
+	}
+	epoch, _, err := tmpChat.CurrentKey()
+	if err != nil && !errors.Is(err, chat.ErrNoKey) {
+		return err
+	}
+	for {
+		if epoch == math.MaxUint32-1 {
+			return fmt.Errorf("%w: chat %s", ErrNoEpochLeft, tmpChat.ChatID)
+		}
+		epoch++
+		if !tmpChat.AddKey(epoch, key) {
+			return fmt.Errorf("new key of chat %s already known", tmpChat.ChatID)
+		}
+		current, currentKey, err := tmpChat.CurrentKey()
+		if err != nil {
+			return err
+		}
+		if current == epoch && bytes.Equal(currentKey, key) {
+			break
+		}
+		// other key of the same or higher epoch is used, next try follows it
+		epoch = current
+	}
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		return err
+	}
+
+	logger.WithFields(logger.Fields{"chatID": tmpChat.ChatID, "epoch": epoch}).Info("rekeyChat: new chat key")
+	for _, userID := range tmpChat.ClientsIPsList() {
+		if userID == c.userID {
+			continue
+		}
+		sealedKey, err := c.sealChatKey(tmpChat, userID)
+		if errors.Is(err, ErrUnknownPeer) {
+			// participant has to connect before it can read new messages, key is sent then
+			logger.WithError(err).WithField("userID", userID).Warn("rekeyChat: public key of participant unknown, key sent when it connects")
+			c.pendingKeys.add(userID, tmpChat.ChatID)
+			c.lookUpParticipant(userID)
+			continue
+		}
+		if err != nil {
+			logger.WithError(err).WithField("userID", userID).Warn("rekeyChat: cannot seal key")
+			continue
+		}
+		// sent before any message encrypted with it
+		if err := c.sendTo(userID, sealedKey); err != nil {
+			logger.WithError(err).WithField("userID", userID).Warn("rekeyChat: key not sent")
+		}
+	}
+	return nil
+}
+
+// sendPendingKeys sends current keys of chats to connected participant whose public key was unknown when rekeying
+func (c *Client) sendPendingKeys(userID string) {
+	for _, chatID := range c.pendingKeys.take(userID) {
+		tmpChat, err := c.GetChat(chatID)
+		if err != nil || !isParticipant(tmpChat, userID) {
+			continue
+		}
+		log := logger.WithFields(logger.Fields{"chatID": chatID, "userID": userID})
+		sealedKey, err := c.sealChatKey(tmpChat, userID)
+		if err != nil {
+			log.WithError(err).Warn("sendPendingKeys: cannot seal key")
+			continue
+		}
+		if err := c.sendTo(userID, sealedKey); err != nil {
+			log.WithError(err).Warn("sendPendingKeys: key not sent")
+		}
+	}
+}
+
+// requestChatKey asks user for keys of epoch missing to decrypt its message, unless it was asked recently
+func (c *Client) requestChatKey(chatID string, epoch uint32, userID string) {
+	if !c.keyRequests.allow(chatID, epoch, userID, time.Now()) {
+		return
+	}
+	log := logger.WithFields(logger.Fields{"chatID": chatID, "epoch": epoch, "userID": userID})
+	log.Info("requestChatKey: key of epoch unknown, requesting it")
+	if err := c.sendTo(userID, &ChatKeyRequest{ChatID: chatID, Epoch: epoch}); err != nil {
+		log.WithError(err).Warn("requestChatKey: request not sent")
+	}
+}
+
+// handleChatKeyRequest sends all keys of requested epoch to participant missing them
+func (c *Client) handleChatKeyRequest(e *Envelope) error {
+	body, ok := e.Body.(*ChatKeyRequest)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	// removed users cannot get keys of messages posted after they left
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+	keys, err := tmpChat.Keys(body.Epoch)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	for _, key := range keys {
+		sealedKey, err := c.sealKey(body.ChatID, body.Epoch, key, e.Source)
+		if err != nil {
+			return err
+		}
+		if err := c.sendTo(e.Source, sealedKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleChatKey adds new key of chat sent by other participant
+func (c *Client) handleChatKey(e *Envelope) error {
+	body, ok := e.Body.(*ChatKey)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+	return c.openChatKey(tmpChat, e.Source, body, false)
+}
+
+// publicKeyOf returns public key of user saved after authenticating it
+func (c *Client) publicKeyOf(userID string) (ed25519.PublicKey, error) {
+	if userID == c.userID {
+		return c.identity.PublicKey, nil
+	}
+	publicKey, err := c.store.PublicKey(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: public key of %s: %v", ErrUnknownPeer, userID, err)
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,458 @@
+package client
+
+import (
+	"errors"
+	"main/chat"
+	"main/gql"
+	"math"
+	"testing"
+	"time"
+)
+
+// link makes clients reachable by each other through buffered channels and lets them know their keys
+func link(t *testing.T, a, b *Client) {
+	for _, pair := range [][2]*Client{{a, b}, {b, a}} {
+		from, to := pair[0], pair[1]
+		from.setAddress(to.userID, to.userIP)
+		from.peers.MarkConnected(to.userIP, to.userID, newPeerLink(to.userID))
+		if err := from.store.SetPublicKey(to.userID, to.identity.PublicKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// deliver passes envelope sent from one client to the other to its dispatcher
+func deliver(t *testing.T, from, to *Client) error {
+	select {
+	case e := <-outboxOf(t, from, to.userIP):
+		return to.dispatcher.DispatchEnvelope(e)
+	case <-time.After(time.Second):
+		t.Fatal("nothing sent")
+		return nil
+	}
+}
+
+func TestClient_chatEncryption(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+
+	// creator has the first key, participant gets it with participants list
+	tmpChat := chat.NewChat("123", []string{b.userID, a.userID}, a.store)
+	tmpChat.AddKey(1, testChatKey)
+	a.chatList["123"] = tmpChat
+
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, &ChatAdvert{ChatID: "123"})); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range [][2]*Client{{b, a}, {a, b}} {
+		if err := deliver(t, step[0], step[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bChat, err := b.GetChat("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := bChat.Key(1); err != nil || string(key) != string(testChatKey) {
+		t.Fatalf("participant key = %x, %v, want key of creator", key, err)
+	}
+
+	message := gql.TextMessage{MessageID: "1", ChatID: "123", User: a.userID, TimeStamp: time.Now().UTC(), Text: "secret"}
+	a.signMessage(&message)
+	encrypted, err := a.encryptMessage(tmpChat, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted.Text != "" || encrypted.User != "" || len(encrypted.Ciphertext) == 0 {
+		t.Errorf("encryptMessage() = %+v, content not hidden", encrypted)
+	}
+
+	messages, cancel := bChat.SubscribeMessages()
+	defer cancel()
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, encrypted)); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-messages; got.Text != "secret" || !got.TimeStamp.Equal(message.TimeStamp) {
+		t.Errorf("decrypted message = %+v, want %+v", got, message)
+	}
+
+	// ciphertext of one message cannot be posted as other one
+	moved := *encrypted
+	moved.MessageID = "2"
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, &moved)); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("moved ciphertext error = %v, want %v", err, ErrInvalidMessage)
+	}
+	// plaintext messages are not accepted
+	plaintext := NewChatMessage(gql.TextMessage{MessageID: "3", ChatID: "123", User: a.userID, Text: "plain"})
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, plaintext)); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("plaintext message error = %v, want %v", err, ErrInvalidMessage)
+	}
+}
+
+func TestClient_rekeyChat(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	stranger := newTestClient(t)
+	link(t, a, b)
+	link(t, stranger, b)
+
+	for _, c := range []*Client{a, b} {
+		tmpChat := chat.NewChat("123", []string{a.userID, b.userID}, c.store)
+		tmpChat.AddKey(1, testChatKey)
+		c.chatList["123"] = tmpChat
+	}
+
+	// key is accepted only from participant which removed other one
+	if err := a.rekeyChat(a.chatList["123"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("key of participant not allowed to rekey error = %v, want %v", err, ErrInvalidMessage)
+	}
+	if epoch, _, _ := b.chatList["123"].CurrentKey(); epoch != 1 {
+		t.Errorf("key epoch after rejected key = %d, want 1", epoch)
+	}
+	b.allowRekey(b.chatList["123"], a.userID)
+	sealedKey, err := a.sealChatKey(a.chatList["123"], b.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, sealedKey)); err != nil {
+		t.Fatal(err)
+	}
+	aEpoch, aKey, _ := a.chatList["123"].CurrentKey()
+	bEpoch, bKey, err := b.chatList["123"].CurrentKey()
+	if err != nil || aEpoch != 2 || bEpoch != 2 || string(aKey) != string(bKey) {
+		t.Errorf("keys after rekey: %d %x and %d %x, %v, want the same key of epoch 2", aEpoch, aKey, bEpoch, bKey, err)
+	}
+	if record, err := b.store.Chat("123"); err == nil && record.KeyEpoch != 2 {
+		t.Errorf("saved key epoch = %d, want 2", record.KeyEpoch)
+	}
+
+	// allowance is used up, epochs cannot be skipped
+	for _, epoch := range []uint32{3, 5} {
+		key, err := a.sealKey("123", epoch, testChatKey, b.userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if epoch == 5 {
+			b.allowRekey(b.chatList["123"], a.userID)
+		}
+		if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, key)); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("key of epoch %d error = %v, want %v", epoch, err, ErrInvalidMessage)
+		}
+	}
+	if epoch, _, _ := b.chatList["123"].CurrentKey(); epoch != 2 {
+		t.Errorf("key epoch after rejected keys = %d, want 2", epoch)
+	}
+
+	// only participants can change key
+	strangerChat := chat.NewChat("123", []string{stranger.userID, b.userID}, stranger.store)
+	strangerChat.AddKey(1, testChatKey)
+	if err := stranger.rekeyChat(strangerChat); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, stranger, b); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("key of stranger error = %v, want %v", err, ErrInvalidMessage)
+	}
+}
+
+func TestClient_messageOrder(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+	for _, c := range []*Client{a, b} {
+		tmpChat := chat.NewChat("123", []string{a.userID, b.userID}, c.store)
+		tmpChat.AddKey(1, testChatKey)
+		c.chatList["123"] = tmpChat
+	}
+
+	// write saves message of client like chatMessagesHandler and sends it to the other one
+	write := func(from, to *Client, messageID string) {
+		tmpChat := from.chatList["123"]
+		message := gql.TextMessage{MessageID: messageID, ChatID: "123", User: from.userID, TimeStamp: time.Now().UTC(), Clock: tmpChat.Tick(), Text: messageID}
+		from.signMessage(&message)
+		encrypted, err := from.encryptMessage(tmpChat, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tmpChat.AddMessage(&message); err != nil {
+			t.Fatal(err)
+		}
+		if err := from.sendTo(to.userID, encrypted); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(a, b, "5")
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	// reply with lower MessageID (clock of b behind) follows message it answers,
+	// message a writes at the same time is concurrent to it
+	write(b, a, "1")
+	write(a, b, "4")
+	for _, pair := range [][2]*Client{{b, a}, {a, b}} {
+		// skip receipts
+		for {
+			select {
+			case e := <-outboxOf(t, pair[0], pair[1].userIP):
+				if e.Type != CHAT_MESSAGE {
+					continue
+				}
+				if err := pair[1].dispatcher.DispatchEnvelope(e); err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(100 * time.Millisecond):
+			}
+			break
+		}
+	}
+
+	for _, c := range []*Client{a, b} {
+		messages, err := c.Messages("123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, m := range messages {
+			ids = append(ids, m.MessageID)
+		}
+		if len(ids) != 3 || ids[0] != "5" || ids[1] != "1" || ids[2] != "4" {
+			t.Errorf("messages of %s = %v, want [5 1 4]", c.userID, ids)
+		}
+	}
+	if clock := b.chatList["123"].Tick(); clock != 3 {
+		t.Errorf("clock after concurrent messages = %d, want 3", clock)
+	}
+}
+
+func TestClient_concurrentRekey(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, a, c)
+	link(t, b, c)
+	newRolesTestChats(a, b, c)
+
+	// a and b removed other participants at the same time and rekey, a writes before it gets key of b
+	for _, participant := range []*Client{a, b, c} {
+		participant.allowRekey(participant.chatList["123"], a.userID)
+		participant.allowRekey(participant.chatList["123"], b.userID)
+	}
+	for _, rekeying := range []*Client{a, b} {
+		if err := rekeying.rekeyChat(rekeying.chatList["123"]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	message := gql.TextMessage{MessageID: "1", ChatID: "123", User: a.userID, TimeStamp: time.Now().UTC(), Text: "early"}
+	a.signMessage(&message)
+	early, err := a.encryptMessage(a.chatList["123"], message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys arrive in different order
+	for _, step := range [][2]*Client{{a, c}, {b, c}, {b, a}, {a, b}} {
+		if err := deliver(t, step[0], step[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, want, _ := a.chatList["123"].CurrentKey()
+	for _, participant := range []*Client{a, b, c} {
+		epoch, key, err := participant.chatList["123"].CurrentKey()
+		if err != nil || epoch != 2 || string(key) != string(want) {
+			t.Errorf("key of %s = %d %x, %v, want the same key of epoch 2", participant.userID, epoch, key, err)
+		}
+	}
+
+	// message encrypted with the other key is still readable
+	for _, participant := range []*Client{b, c} {
+		if _, err := participant.decryptMessage(participant.chatList["123"], a.userID, early); err != nil {
+			t.Errorf("message encrypted with concurrent key, %s error = %v", participant.userID, err)
+		}
+	}
+}
+
+func TestClient_chatKeyRequest(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	stranger := newTestClient(t)
+	link(t, a, b)
+	link(t, a, stranger)
+	newRolesTestChats(a, b)
+
+	// b missed new key of a
+	for i := 0; i < 2; i++ {
+		key, err := chat.NewKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.chatList["123"].AddKey(2, key)
+	}
+	message := gql.TextMessage{MessageID: "1", ChatID: "123", User: a.userID, TimeStamp: time.Now().UTC(), Text: "new"}
+	a.signMessage(&message)
+	encrypted, err := a.encryptMessage(a.chatList["123"], message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, encrypted)); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("message of unknown epoch error = %v, want %v", err, ErrInvalidMessage)
+	}
+	// key is requested once, author sends message again later
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, encrypted)); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("message of unknown epoch error = %v, want %v", err, ErrInvalidMessage)
+	}
+	outbox := outboxOf(t, b, a.userIP)
+	if len(outbox) != 1 {
+		t.Fatalf("%d envelopes sent, want single request", len(outbox))
+	}
+	request := <-outbox
+	if body, ok := request.Body.(*ChatKeyRequest); !ok || body.ChatID != "123" || body.Epoch != 2 {
+		t.Fatalf("sent %+v, want request for key of epoch 2", request.Body)
+	}
+
+	// all keys of epoch are sent
+	if err := a.dispatcher.DispatchEnvelope(request); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := deliver(t, a, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if keys, err := b.chatList["123"].Keys(2); err != nil || len(keys) != 2 {
+		t.Errorf("keys of epoch 2 = %d, %v, want both keys", len(keys), err)
+	}
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, encrypted)); err != nil {
+		t.Errorf("message after getting key error = %v", err)
+	}
+
+	// only participants get keys
+	if err := a.dispatcher.DispatchEnvelope(NewEnvelope(stranger.userID, &ChatKeyRequest{ChatID: "123", Epoch: 2})); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("request of stranger error = %v, want %v", err, ErrInvalidMessage)
+	}
+}
+
+func TestClient_pendingKeys(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	newRolesTestChats(a, b)
+
+	// a removed other participant, public key of b is not known yet
+	b.allowRekey(b.chatList["123"], a.userID)
+	if err := a.rekeyChat(a.chatList["123"]); err != nil {
+		t.Fatal(err)
+	}
+	link(t, a, b)
+	a.sendPendingKeys(b.userID)
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	aEpoch, aKey, _ := a.chatList["123"].CurrentKey()
+	bEpoch, bKey, err := b.chatList["123"].CurrentKey()
+	if err != nil || aEpoch != 2 || bEpoch != 2 || string(aKey) != string(bKey) {
+		t.Errorf("keys after connecting: %d %x and %d %x, %v, want the same key of epoch 2", aEpoch, aKey, bEpoch, bKey, err)
+	}
+	if chatIDs := a.pendingKeys.take(b.userID); len(chatIDs) != 0 {
+		t.Errorf("pending keys after sending = %v, want none", chatIDs)
+	}
+}
+
+func TestClient_rekeyLastEpoch(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+	newRolesTestChats(a, b)
+
+	// the last epoch is never used, so epochs cannot wrap around
+	a.chatList["123"].AddKey(math.MaxUint32-1, testChatKey)
+	if err := a.rekeyChat(a.chatList["123"]); !errors.Is(err, ErrNoEpochLeft) {
+		t.Errorf("rekey at the last epoch error = %v, want %v", err, ErrNoEpochLeft)
+	}
+	if epoch, _, _ := a.chatList["123"].CurrentKey(); epoch != math.MaxUint32-1 {
+		t.Errorf("key epoch after refused rekey = %d, want %d", epoch, uint32(math.MaxUint32-1))
+	}
+	if err := (&ChatKey{ChatID: "123", Epoch: math.MaxUint32, SealedKey: []byte{1}}).Validate(); err == nil {
+		t.Error("key of the last epoch is valid")
+	}
+}
+
+func TestClient_rekeyAfterLeave(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, a, c)
+	link(t, b, c)
+	newRolesTestChats(a, b, c)
+
+	// c leaves, remaining participant with the lowest user ID rekeys
+	if err := c.LeaveChat("123"); err != nil {
+		t.Fatal(err)
+	}
+	for _, participant := range []*Client{a, b} {
+		if err := deliver(t, c, participant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rekeyer, other := a, b
+	if b.userID < a.userID {
+		rekeyer, other = b, a
+	}
+	if err := deliver(t, rekeyer, other); err != nil {
+		t.Fatal(err)
+	}
+	rEpoch, rKey, _ := rekeyer.chatList["123"].CurrentKey()
+	oEpoch, oKey, err := other.chatList["123"].CurrentKey()
+	if err != nil || rEpoch != 2 || oEpoch != 2 || string(rKey) != string(oKey) {
+		t.Errorf("keys after leave: %d %x and %d %x, %v, want the same key of epoch 2", rEpoch, rKey, oEpoch, oKey, err)
+	}
+
+	// the other one is not allowed to rekey
+	if err := other.rekeyChat(other.chatList["123"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, other, rekeyer); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("key of participant not allowed to rekey error = %v, want %v", err, ErrInvalidMessage)
+	}
+	if epoch, _, _ := rekeyer.chatList["123"].CurrentKey(); epoch != 2 {
+		t.Errorf("key epoch after rejected key = %d, want 2", epoch)
+	}
+}
+
+func TestKeyRequests(t *testing.T) {
+	var r keyRequests
+	now := time.Now()
+	if !r.allow("1", 2, "a", now) {
+		t.Error("the first request not allowed")
+	}
+	if r.allow("1", 2, "a", now.Add(KEY_REQUEST_INTERVAL/2)) {
+		t.Error("repeated request allowed")
+	}
+	if !r.allow("1", 2, "b", now) || !r.allow("1", 3, "a", now) {
+		t.Error("request of other user or epoch not allowed")
+	}
+	if !r.allow("1", 2, "a", now.Add(KEY_REQUEST_INTERVAL)) {
+		t.Error("request after interval not allowed")
+	}
+}
+
+func TestRekeyers(t *testing.T) {
+	var r rekeyers
+	if r.take("1", "a", 2) {
+		t.Error("user not allowed to rekey can send key")
+	}
+	r.allow("1", "a", 2)
+	if r.take("1", "a", 1) || r.take("1", "b", 2) || r.take("2", "a", 2) {
+		t.Error("key of older epoch, other user or other chat allowed")
+	}
+	if !r.take("1", "a", 3) {
+		t.Error("allowed user cannot send key")
+	}
+	if r.take("1", "a", 3) {
+		t.Error("allowance not used up by key")
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+// protocol versions understood by this client
+const (
+	// PROTOCOL_VERSION is version used for sending envelopes
+	PROTOCOL_VERSION uint32 = 1
+	// MIN_PROTOCOL_VERSION is the oldest version still accepted
+	MIN_PROTOCOL_VERSION uint32 = 1
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	ErrUnknownMessageType = errors.New("unknown message type")
+	ErrMalformedPayload   = errors.New("malformed payload")
+)
+
+// Envelope is single unit of communication between clients
+// on the wire: metadata carries header (version, type, source), data carries typed Body
+// Source is user ID of sender
+type Envelope struct {
+	Version uint32  `json:"version"`
+	Type    string  `json:"type"`
+	Source  string  `json:"source"`
+	Body    Message `json:"-"`
+}
+
+// NewEnvelope wraps body sent by source into envelope of current protocol version
+func NewEnvelope(source string, body Message) *Envelope {
+	return &Envelope{
+		Version: PROTOCOL_VERSION,
+		Type:    body.MessageType(),
+		Source:  source,
+		Body:    body,
+	}
+}
+
+// MarshalEnvelope converts envelope to payload using DefaultCodec
+func MarshalEnvelope(e *Envelope) (payload.Payload, error) {
+	return DefaultCodec.Marshal(e)
+}
+
+// UnmarshalEnvelope converts payload encoded by any known codec to envelope
+// returns ErrUnsupportedVersion, ErrUnknownMessageType or ErrMalformedPayload if payload can not be accepted
+func UnmarshalEnvelope(p payload.Payload) (*Envelope, error) {
+	metadata, ok := p.Metadata()
+	if !ok || len(metadata) == 0 {
+		return nil, fmt.Errorf("%w: missing metadata", ErrMalformedPayload)
+	}
+	return detectCodec(metadata).Unmarshal(p)
+}
+
+// checkHeader validates decoded envelope header and returns empty body for its type
+func checkHeader(e *Envelope) (Message, error) {
+	if !IsVersionSupported(e.Version) {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.Version)
+	}
+
+	factory, ok := messageFactories[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMessageType, e.Type)
+	}
+
+	return factory(), nil
+}
+
+// checkBody validates envelope before encoding
+func checkBody(e *Envelope) error {
+	if e.Body == nil || e.Body.MessageType() != e.Type {
+		return fmt.Errorf("%w: body does not match type %q", ErrMalformedPayload, e.Type)
+	}
+	return nil
+}
+
+// IsVersionSupported reports if envelope of given version can be handled
+func IsVersionSupported(version uint32) bool {
+	return version >= MIN_PROTOCOL_VERSION && version <= PROTOCOL_VERSION
+}
+
+// VersionRange is carried in setup payload metadata to negotiate protocol version
+type VersionRange struct {
+	MinVersion uint32 `json:"minVersion"`
+	MaxVersion uint32 `json:"maxVersion"`
+}
+
+// LocalVersionRange returns versions supported by this client
+func LocalVersionRange() VersionRange {
+	return VersionRange{MinVersion: MIN_PROTOCOL_VERSION, MaxVersion: PROTOCOL_VERSION}
+}
+
+// NegotiateVersion returns the highest version supported by both sides
+func NegotiateVersion(local, remote VersionRange) (uint32, error) {
+	version := local.MaxVersion
+	if remote.MaxVersion < version {
+		version = remote.MaxVersion
+	}
+	if version < local.MinVersion || version < remote.MinVersion {
+		return 0, fmt.Errorf("%w: local %d-%d, remote %d-%d", ErrUnsupportedVersion,
+			local.MinVersion, local.MaxVersion, remote.MinVersion, remote.MaxVersion)
+	}
+	return version, nil
+}
+
+// SetupMetadata is carried in setup payload metadata sent when connecting
+// version range is kept at top level, so clients reading only VersionRange still understand it
+type SetupMetadata struct {
+	VersionRange
+	Identity PeerIdentity `json:"identity"`
+}
+
+// setupMetadata returns metadata of setup payload sent when connecting
+func (c *Client) setupMetadata() []byte {
+	// marshalling struct of integers and strings can not fail
+	metadata, _ := json.Marshal(SetupMetadata{
+		VersionRange: LocalVersionRange(),
+		Identity:     c.localIdentity(),
+	})
+	return metadata
+}
+
+// parseSetup checks if client which sent setup payload speaks compatible protocol version
+// and announced valid identity
+func parseSetup(setup payload.Payload) (*SetupMetadata, error) {
+	var remote SetupMetadata
+	metadata, _ := setup.Metadata()
+	if err := json.Unmarshal(metadata, &remote); err != nil {
+		return nil, fmt.Errorf("%w: unversioned setup", ErrUnsupportedVersion)
+	}
+	if _, err := NegotiateVersion(LocalVersionRange(), remote.VersionRange); err != nil {
+		return nil, err
+	}
+	if err := remote.Identity.Verify(); err != nil {
+		return nil, err
+	}
+	return &remote, nil
+}
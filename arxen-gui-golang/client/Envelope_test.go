@@ -0,0 +1,120 @@
+package client
+
+import (
+	"errors"
+	"github.com/rsocket/rsocket-go/payload"
+	"main/chat"
+	"main/store"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEnvelope_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		body Message
+	}{
+		{"CHAT_MESSAGE with special characters", &ChatMessage{
+			ChatID:    `chat "quoted"`,
+			MessageID: "1",
+			User:      `nick\"},{"type":"CHAT_ADVERT`,
+			TimeStamp: time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC),
+			Text:      "line\nbreak żółw",
+		}},
+		{"CHAT_ADVERT", &ChatAdvert{ChatID: "1", ChatName: `"name"`}},
+		{"CHAT_ADVERT_REQUEST", &ChatAdvertRequest{ChatID: "1"}},
+		{"CHAT_PARTICIPANTS_REQUEST", &ChatParticipantsRequest{ChatID: "1"}},
+		{"CHAT_PARTICIPANTS_RESPONSE", &ChatParticipantsResponse{ChatID: "1", Participants: []string{"a", `b"`}}},
+		{"encrypted CHAT_MESSAGE", &ChatMessage{ChatID: "1", MessageID: "1", Epoch: 2, Ciphertext: []byte{0, 1, 2}}},
+		{"CHAT_KEY", &ChatKey{ChatID: "1", Epoch: 2, SealedKey: []byte{0, 1, 2}}},
+		{"CHAT_KEY_REQUEST", &ChatKeyRequest{ChatID: "1", Epoch: 2}},
+		{"CHAT_PARTICIPANTS_RESPONSE with key", &ChatParticipantsResponse{ChatID: "1", Participants: []string{"a"},
+			Addresses: map[string]string{"a": "tcp://10.5.0.2:7878"}, Key: &ChatKey{ChatID: "1", Epoch: 1, SealedKey: []byte{1}}}},
+		{"HISTORY_SYNC_REQUEST", &HistorySyncRequest{ChatID: "1", SinceMessageID: "a"}},
+		{"HISTORY_SYNC_RESPONSE", &HistorySyncResponse{ChatID: "1", Messages: []*ChatMessage{
+			{ChatID: "1", MessageID: "a", User: "u", TimeStamp: time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC), Text: "a"},
+			{ChatID: "1", MessageID: "b", User: "u", TimeStamp: time.Date(2020, 5, 12, 10, 1, 0, 0, time.UTC), Text: "b"},
+		}}},
+		{"MESSAGE_RECEIPT", &MessageReceipt{ChatID: "1", MessageIDs: []string{"a", "b"}, Status: store.RECEIPT_READ}},
+		{"CHAT_MEMBERSHIP", &ChatMembership{ChatID: "1", Action: MEMBERSHIP_JOIN, UserID: "u",
+			Actor: "a", Clock: 3, Signature: []byte{1, 2}}},
+		{"CHAT_PARTICIPANTS_RESPONSE with roles", &ChatParticipantsResponse{ChatID: "1", Participants: []string{"a", "b"},
+			Owner: "a", Admins: []string{"a"}, Versions: map[string]store.MemberVersion{"b": {Clock: 2, Actor: "a", Action: "join", Signature: []byte{1, 2}}},
+			PublicKeys: map[string][]byte{"a": {3, 4}}, Creation: &store.MemberVersion{Clock: 1, Actor: "a", Action: "create", Signature: []byte{5}, UserID: "a"},
+			History: []store.MemberVersion{{Clock: 2, Actor: "a", Action: "join", Signature: []byte{1, 2}, UserID: "b"}}}},
+		{"CHAT_METADATA", &ChatMetadata{ChatID: "1", Field: chat.METADATA_NAME, Value: `"name" żółw`, Actor: "a", Clock: 3, Signature: []byte{1, 2}}},
+		{"CHAT_PARTICIPANTS_RESPONSE with metadata", &ChatParticipantsResponse{ChatID: "1", Participants: []string{"a"},
+			ChatName: "name", ChatAvatar: "/static/a.png", MetadataVersions: map[string]store.MemberVersion{"name": {Clock: 2, Actor: "a"}}}},
+		{"FRIENDSHIP", &Friendship{Action: FRIENDSHIP_REQUEST, Nick: "żółw", PublicKey: []byte{1, 2}, Address: "tcp://127.0.0.1:1"}},
+		{"PRESENCE", &Presence{Status: PRESENCE_AWAY}},
+		{"TYPING", &Typing{ChatID: "1", Typing: true}},
+	}
+	for _, codec := range []Codec{JsonCodec, ProtobufCodec} {
+		for _, tt := range tests {
+			t.Run(codec.MimeType()+" "+tt.name, func(t *testing.T) {
+				want := NewEnvelope(`tcp://"source"`, tt.body)
+				p, err := codec.Marshal(want)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := UnmarshalEnvelope(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("UnmarshalEnvelope() = %+v, want %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestUnmarshalEnvelope_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload payload.Payload
+		wantErr error
+	}{
+		{"not json", payload.NewString("1", `{"source":"a`), ErrMalformedPayload},
+		{"no metadata", payload.New([]byte("1"), nil), ErrMalformedPayload},
+		{"unversioned", payload.NewString(`{}`, `{"source":"a","type":"CHAT_ADVERT"}`), ErrUnsupportedVersion},
+		{"future version", payload.NewString(`{}`, `{"version":99,"source":"a","type":"CHAT_ADVERT"}`), ErrUnsupportedVersion},
+		{"unknown type", payload.NewString(`{}`, `{"version":1,"source":"a","type":"UNKNOWN"}`), ErrUnknownMessageType},
+		{"bad body", payload.NewString(`{"chatID":1}`, `{"version":1,"source":"a","type":"CHAT_ADVERT"}`), ErrMalformedPayload},
+		{"bad protobuf", payload.New([]byte{0xff}, []byte{0x08, 0x01, 0xff}), ErrMalformedPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnmarshalEnvelope(tt.payload); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalEnvelope() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		local   VersionRange
+		remote  VersionRange
+		want    uint32
+		wantErr bool
+	}{
+		{"same", VersionRange{1, 1}, VersionRange{1, 1}, 1, false},
+		{"remote newer", VersionRange{1, 2}, VersionRange{1, 3}, 2, false},
+		{"remote older", VersionRange{1, 3}, VersionRange{1, 2}, 2, false},
+		{"disjoint", VersionRange{3, 4}, VersionRange{1, 2}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NegotiateVersion(tt.local, tt.remote)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NegotiateVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NegotiateVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
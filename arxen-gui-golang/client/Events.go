@@ -0,0 +1,96 @@
+package client
+
+import (
+	logger "github.com/sirupsen/logrus"
+	"main/chat"
+	"main/gql"
+	"main/hub"
+)
+
+// events of chat list:
+// client publishes event when chat appears in its chat list, created by user or by other participant,
+// when the last message of chat changes, sent by user or received from other participant,
+// when users join it (see Membership.go), its name or avatar changes (see Metadata.go)
+// and when participants start or stop typing (see Typing.go),
+// subscribers choose kind of events and chat (or all chats) they are interested in
+// new and changed friends (see Friends.go) and changes of their presence (see Presence.go)
+// are published the same way, without chat
+
+// ChatEventKind is kind of change of chat list
+type ChatEventKind string
+
+const (
+	CHAT_EVENT_CREATED      ChatEventKind = "created"
+	CHAT_EVENT_LAST_MESSAGE ChatEventKind = "last_message"
+	CHAT_EVENT_TYPING       ChatEventKind = "typing"
+	CHAT_EVENT_JOINED       ChatEventKind = "joined"
+	CHAT_EVENT_METADATA     ChatEventKind = "metadata"
+	CHAT_EVENT_FRIEND       ChatEventKind = "friend"
+	CHAT_EVENT_PRESENCE     ChatEventKind = "presence"
+)
+
+// number of events buffered for single subscriber, slower subscribers miss them
+const CHAT_EVENTS_BUFFER = 32
+
+// ChatEvent is change of chat list
+type ChatEvent struct {
+	Kind        ChatEventKind
+	ChatID      string
+	LastMessage *gql.TextMessage   // set for CHAT_EVENT_LAST_MESSAGE
+	Typing      []string           // user IDs of participants typing now, set for CHAT_EVENT_TYPING
+	UserID      string             // user which joined chat, set for CHAT_EVENT_JOINED
+	Field       chat.MetadataField // changed field, set for CHAT_EVENT_METADATA
+	Friend      *gql.Friend        // set for CHAT_EVENT_FRIEND and CHAT_EVENT_PRESENCE
+}
+
+// EventBus passes events of chat list to subscribers, zero value is ready to use
+type EventBus struct {
+	hub hub.Hub
+}
+
+// Subscribe returns channel receiving events of given kind in chat and function ending subscription
+// empty chatID subscribes events of all chats
+func (b *EventBus) Subscribe(kind ChatEventKind, chatID string) (<-chan ChatEvent, func()) {
+	ch := make(chan ChatEvent, CHAT_EVENTS_BUFFER)
+	return ch, b.hub.Subscribe(ch, func(value interface{}) bool {
+		e := value.(ChatEvent)
+		return e.Kind == kind && (chatID == "" || e.ChatID == chatID)
+	})
+}
+
+// Publish passes event to its subscribers without blocking
+func (b *EventBus) Publish(e ChatEvent) {
+	if missed := b.hub.Publish(e); missed > 0 {
+		logger.WithFields(logger.Fields{
+			"chatID": e.ChatID,
+			"kind":   e.Kind,
+			"missed": missed,
+		}).Warn("EventBus: subscriber too slow, event dropped")
+	}
+}
+
+// SubscribeChatEvents returns channel with events of given kind in chat (all chats if chatID is empty)
+// and function ending subscription
+func (c *Client) SubscribeChatEvents(kind ChatEventKind, chatID string) (<-chan ChatEvent, func()) {
+	return c.events.Subscribe(kind, chatID)
+}
+
+// publishLastMessage publishes the last message of chat after new messages were added to it
+func (c *Client) publishLastMessage(chatID string) {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return
+	}
+	lastMessage, err := tmpChat.LastMessage()
+	if err != nil || lastMessage == nil {
+		logger.WithError(err).WithField("chatID", chatID).Debug("publishLastMessage: no last message")
+		return
+	}
+	c.events.Publish(ChatEvent{Kind: CHAT_EVENT_LAST_MESSAGE, ChatID: chatID, LastMessage: lastMessage})
+}
+
+// publishFriend publishes copy of new or changed friend as event of given kind
+func (c *Client) publishFriend(kind ChatEventKind, friend *gql.Friend) {
+	tmpFriend := *friend
+	c.events.Publish(ChatEvent{Kind: kind, Friend: &tmpFriend})
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"main/gql"
+	"testing"
+	"time"
+)
+
+// nextEvent returns event received from events or fails test after a second
+func nextEvent(t *testing.T, events <-chan ChatEvent) ChatEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("event not published")
+	}
+	return ChatEvent{}
+}
+
+func TestEventBus(t *testing.T) {
+	var bus EventBus
+	created, cancelCreated := bus.Subscribe(CHAT_EVENT_CREATED, "")
+	last, cancelLast := bus.Subscribe(CHAT_EVENT_LAST_MESSAGE, "1")
+	defer cancelLast()
+
+	bus.Publish(ChatEvent{Kind: CHAT_EVENT_LAST_MESSAGE, ChatID: "2"})
+	bus.Publish(ChatEvent{Kind: CHAT_EVENT_CREATED, ChatID: "2"})
+	bus.Publish(ChatEvent{Kind: CHAT_EVENT_LAST_MESSAGE, ChatID: "1", LastMessage: &gql.TextMessage{MessageID: "a"}})
+
+	if e := nextEvent(t, created); e.ChatID != "2" {
+		t.Errorf("created chat = %s, want 2", e.ChatID)
+	}
+	if e := nextEvent(t, last); e.ChatID != "1" || e.LastMessage.MessageID != "a" {
+		t.Errorf("last message event = %+v, want message a of chat 1", e)
+	}
+	select {
+	case e := <-last:
+		t.Errorf("unexpected event %+v", e)
+	default:
+	}
+
+	// ended subscription is closed and receives nothing
+	cancelCreated()
+	cancelCreated()
+	bus.Publish(ChatEvent{Kind: CHAT_EVENT_CREATED, ChatID: "3"})
+	if _, ok := <-created; ok {
+		t.Error("event received after subscription ended")
+	}
+}
+
+func TestClient_chatEvents(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+	newRolesTestChats(a, b)
+	created, cancelCreated := b.SubscribeChatEvents(CHAT_EVENT_CREATED, "")
+	defer cancelCreated()
+	last, cancelLast := b.SubscribeChatEvents(CHAT_EVENT_LAST_MESSAGE, "123")
+	defer cancelLast()
+
+	// receive is delivery of message of a with given clock
+	receive := func(messageID string, clock int) {
+		message := gql.TextMessage{MessageID: messageID, ChatID: "123", User: a.userID, TimeStamp: time.Now().UTC(), Clock: clock, Text: messageID}
+		a.signMessage(&message)
+		encrypted, err := a.encryptMessage(a.chatList["123"], message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, encrypted)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receive("b", 2)
+	if e := nextEvent(t, last); e.LastMessage == nil || e.LastMessage.MessageID != "b" {
+		t.Errorf("last message = %+v, want b", e.LastMessage)
+	}
+	// late message does not replace the last one
+	receive("a", 1)
+	if e := nextEvent(t, last); e.LastMessage == nil || e.LastMessage.MessageID != "b" {
+		t.Errorf("last message after late one = %+v, want b", e.LastMessage)
+	}
+
+	// chats created by user and by other participant
+	ch := b.CreateChat([]string{a.userID})
+	if e := nextEvent(t, created); e.ChatID != ch.ChatID {
+		t.Errorf("created chat = %s, want %s", e.ChatID, ch.ChatID)
+	}
+	b.createSlaveChat([]string{a.userID, b.userID}, "456")
+	if e := nextEvent(t, created); e.ChatID != "456" {
+		t.Errorf("created chat = %s, want 456", e.ChatID)
+	}
+	// existing chat is not created again
+	b.createSlaveChat([]string{a.userID, b.userID}, "456")
+	select {
+	case e := <-created:
+		t.Errorf("unexpected event %+v", e)
+	default:
+	}
+}
@@ -3,7 +3,7 @@ package client
 // Friend struct for friends store
 type Friend struct {
 	Name      string `json:"name"`
-	FriendIP  string `json:"friendIP"`
-	FriendID  string `json:"friendID"`
-	PublicKey string `json:"publicKey"` // to be changed
+	FriendIP  string `json:"friendIP"`  // last known address
+	FriendID  string `json:"friendID"`  // user ID derived from PublicKey
+	PublicKey string `json:"publicKey"` // hex encoded Ed25519 public key
 }
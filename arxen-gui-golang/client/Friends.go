@@ -0,0 +1,352 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/gql"
+	"main/identity"
+	"main/store"
+	"strings"
+)
+
+// friends of user:
+// user sends FRIENDSHIP {request, nick, public key, address} to other user and saves it as requested,
+// the other one saves it as pending until it accepts ({accept, nick, key, address}) or declines ({decline}) it,
+// users requesting friendship of each other become friends without answer
+// friend is removed on both sides with {remove}, friends learn new nick of user with {nick}
+// blocked users are kept with friends, their adverts, participants of chats, messages, friendship changes,
+// heartbeats and typing are dropped before dispatching (see dispatchPayload), they are not told about it,
+// messages written by them are dropped also when other participant passes them with history
+// new and changed friends are published as CHAT_EVENT_FRIEND
+
+// FriendshipAction is kind of change of friendship
+type FriendshipAction string
+
+const (
+	FRIENDSHIP_REQUEST FriendshipAction = "request"
+	FRIENDSHIP_ACCEPT  FriendshipAction = "accept"
+	FRIENDSHIP_DECLINE FriendshipAction = "decline"
+	FRIENDSHIP_REMOVE  FriendshipAction = "remove"
+	FRIENDSHIP_NICK    FriendshipAction = "nick"
+)
+
+// friendshipActions are actions accepted in FRIENDSHIP
+var friendshipActions = map[FriendshipAction]bool{
+	FRIENDSHIP_REQUEST: true,
+	FRIENDSHIP_ACCEPT:  true,
+	FRIENDSHIP_DECLINE: true,
+	FRIENDSHIP_REMOVE:  true,
+	FRIENDSHIP_NICK:    true,
+}
+
+// states of friends
+const (
+	FRIEND_ACCEPTED  = "accepted"
+	FRIEND_REQUESTED = "requested" // by user, waiting for answer
+	FRIEND_PENDING   = "pending"   // request of other user waiting for answer of user
+	FRIEND_BLOCKED   = "blocked"
+)
+
+// maximal length in bytes of nick
+const MAX_NICK_LENGTH = 64
+
+// key of own nick in store settings
+const NICK_SETTING = "nick"
+
+// blockedTypes are messages dropped when sent by blocked user
+var blockedTypes = map[string]bool{
+	CHAT_ADVERT:                true,
+	CHAT_PARTICIPANTS_RESPONSE: true,
+	CHAT_MESSAGE:               true,
+	FRIENDSHIP:                 true,
+	PRESENCE:                   true,
+	TYPING:                     true,
+}
+
+var (
+	// ErrBlocked is returned when user is blocked
+	ErrBlocked = errors.New("user is blocked")
+	// ErrNoFriendRequest is returned when answering request which was not received
+	ErrNoFriendRequest = errors.New("no friend request")
+	// ErrInvalidNick is returned when nick is blank or too long
+	ErrInvalidNick = errors.New("nick is required and cannot be longer than 64 bytes")
+)
+
+// Nick returns own nick sent to friends, empty if not set
+func (c *Client) Nick() string {
+	nick, err := c.store.Setting(NICK_SETTING)
+	if err != nil && err != store.ErrNotFound {
+		logger.WithError(err).Error("Nick: cannot read nick")
+	}
+	return nick
+}
+
+// ChangeNick saves own nick and sends it to friends
+func (c *Client) ChangeNick(nick string) error {
+	nick = strings.TrimSpace(nick)
+	if nick == "" || len(nick) > MAX_NICK_LENGTH {
+		return ErrInvalidNick
+	}
+	if err := c.store.SetSetting(NICK_SETTING, nick); err != nil {
+		return err
+	}
+
+	friends, err := c.store.Friends()
+	if err != nil {
+		return err
+	}
+	for _, friend := range friends {
+		if friendState(friend) == FRIEND_ACCEPTED {
+			c.sendFriendship(friend.UserID, FRIENDSHIP_NICK)
+		}
+	}
+	return nil
+}
+
+// AddFriend sends friend request to user, request of that user is accepted instead
+// returns state of friend
+func (c *Client) AddFriend(userID string) (string, error) {
+	if userID == "" || userID == c.userID {
+		return "", fmt.Errorf("cannot befriend %q", userID)
+	}
+	friend, err := c.friend(userID)
+	if err != nil {
+		return "", err
+	}
+
+	switch friendState(friend) {
+	case FRIEND_BLOCKED:
+		return "", fmt.Errorf("%w: %s", ErrBlocked, userID)
+	case FRIEND_ACCEPTED:
+		return FRIEND_ACCEPTED, nil
+	case FRIEND_PENDING:
+		if _, err := c.AcceptFriend(userID); err != nil {
+			return "", err
+		}
+		return FRIEND_ACCEPTED, nil
+	case "":
+		friend = &gql.Friend{UserID: userID, State: FRIEND_REQUESTED}
+		if addr, ok := c.AddressOf(userID); ok {
+			friend.UserIP = &addr
+		}
+		if err := c.store.SaveFriend(friend); err != nil {
+			return "", err
+		}
+	}
+
+	// request is sent again when it was not answered
+	c.sendFriendship(userID, FRIENDSHIP_REQUEST)
+	return FRIEND_REQUESTED, nil
+}
+
+// AcceptFriend accepts friend request of user
+func (c *Client) AcceptFriend(userID string) (*gql.Friend, error) {
+	friend, err := c.friend(userID)
+	if err != nil {
+		return nil, err
+	}
+	if friendState(friend) != FRIEND_PENDING {
+		return nil, fmt.Errorf("%w: from %s", ErrNoFriendRequest, userID)
+	}
+
+	friend.State = FRIEND_ACCEPTED
+	if err := c.store.SaveFriend(friend); err != nil {
+		return nil, err
+	}
+	c.sendFriendship(userID, FRIENDSHIP_ACCEPT)
+	c.publishFriend(CHAT_EVENT_FRIEND, friend)
+	return friend, nil
+}
+
+// DeclineFriend declines friend request of user
+func (c *Client) DeclineFriend(userID string) error {
+	friend, err := c.friend(userID)
+	if err != nil {
+		return err
+	}
+	if friendState(friend) != FRIEND_PENDING {
+		return fmt.Errorf("%w: from %s", ErrNoFriendRequest, userID)
+	}
+
+	if err := c.store.DeleteFriend(userID); err != nil {
+		return err
+	}
+	c.sendFriendship(userID, FRIENDSHIP_DECLINE)
+	return nil
+}
+
+// RemoveFriend removes friend on both sides, cancels own request or declines request of user
+func (c *Client) RemoveFriend(userID string) error {
+	friend, err := c.friend(userID)
+	if err != nil {
+		return err
+	}
+
+	switch friendState(friend) {
+	case "":
+		return fmt.Errorf("%w: friend %s", store.ErrNotFound, userID)
+	case FRIEND_BLOCKED:
+		return fmt.Errorf("%w: %s", ErrBlocked, userID)
+	case FRIEND_PENDING:
+		return c.DeclineFriend(userID)
+	}
+
+	if err := c.store.DeleteFriend(userID); err != nil {
+		return err
+	}
+	c.sendFriendship(userID, FRIENDSHIP_REMOVE)
+	return nil
+}
+
+// BlockUser drops adverts, messages and friendship changes of user, friendship with it ends
+func (c *Client) BlockUser(userID string) error {
+	if userID == "" || userID == c.userID {
+		return fmt.Errorf("cannot block %q", userID)
+	}
+	friend, err := c.friend(userID)
+	if err != nil {
+		return err
+	}
+	if friend == nil {
+		friend = &gql.Friend{UserID: userID}
+	}
+
+	friend.State = FRIEND_BLOCKED
+	logger.WithField("userID", userID).Info("BlockUser: user blocked")
+	return c.store.SaveFriend(friend)
+}
+
+// UnblockUser removes block of user, it is not a friend anymore
+func (c *Client) UnblockUser(userID string) error {
+	if !c.IsBlocked(userID) {
+		return fmt.Errorf("%s is not blocked", userID)
+	}
+	return c.store.DeleteFriend(userID)
+}
+
+// IsBlocked reports if user is blocked
+func (c *Client) IsBlocked(userID string) bool {
+	friend, err := c.friend(userID)
+	return err == nil && friendState(friend) == FRIEND_BLOCKED
+}
+
+// SubscribeFriends returns channel with events of new and changed friends and function ending subscription
+func (c *Client) SubscribeFriends() (<-chan ChatEvent, func()) {
+	return c.events.Subscribe(CHAT_EVENT_FRIEND, "")
+}
+
+// friend returns friend with given user ID or nil if there is none
+func (c *Client) friend(userID string) (*gql.Friend, error) {
+	friend, err := c.store.Friend(userID)
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	return friend, err
+}
+
+// friendState returns state of friend, empty for nil,
+// friends saved before friend requests were introduced are accepted
+func friendState(friend *gql.Friend) string {
+	switch {
+	case friend == nil:
+		return ""
+	case friend.State == "":
+		return FRIEND_ACCEPTED
+	}
+	return friend.State
+}
+
+// sendFriendship sends change of friendship to user, request and acceptance carry own key and address
+func (c *Client) sendFriendship(userID string, action FriendshipAction) {
+	m := &Friendship{Action: action, Nick: c.Nick()}
+	if action == FRIENDSHIP_REQUEST || action == FRIENDSHIP_ACCEPT {
+		m.PublicKey = c.identity.PublicKey
+		m.Address = c.userIP
+	}
+	if err := c.sendTo(userID, m); err != nil {
+		logger.WithError(err).WithField("userID", userID).Warn("sendFriendship: change not sent")
+	}
+}
+
+// handleFriendship applies change of friendship made by sender
+func (c *Client) handleFriendship(e *Envelope) error {
+	body, ok := e.Body.(*Friendship)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+	if e.Source == c.userID {
+		return fmt.Errorf("%w: friendship with oneself", ErrInvalidMessage)
+	}
+
+	// key and address are accepted only if user ID was derived from the key
+	if len(body.PublicKey) > 0 {
+		if identity.UserIDFromPublicKey(body.PublicKey) != e.Source {
+			return fmt.Errorf("%w: key of other user than %s", ErrInvalidMessage, e.Source)
+		}
+		if err := c.store.SetPublicKey(e.Source, body.PublicKey); err != nil {
+			return err
+		}
+		if body.Address != "" {
+			c.setAddress(e.Source, body.Address)
+		}
+	}
+
+	friend, err := c.friend(e.Source)
+	if err != nil {
+		return err
+	}
+	state := friendState(friend)
+	if state == FRIEND_BLOCKED {
+		logger.WithField("userID", e.Source).Debug("handleFriendship: change of blocked user ignored")
+		return nil
+	}
+	if friend == nil {
+		friend = &gql.Friend{UserID: e.Source}
+	}
+	if body.Nick != "" {
+		nick := body.Nick
+		friend.Nick = &nick
+	}
+	if addr, ok := c.AddressOf(e.Source); ok {
+		friend.UserIP = &addr
+	}
+
+	log := logger.WithFields(logger.Fields{"userID": e.Source, "action": body.Action, "state": state})
+	switch body.Action {
+	case FRIENDSHIP_REQUEST:
+		switch state {
+		case "":
+			friend.State = FRIEND_PENDING
+		case FRIEND_REQUESTED, FRIEND_ACCEPTED:
+			// both requested friendship or friend lost it
+			friend.State = FRIEND_ACCEPTED
+			c.sendFriendship(e.Source, FRIENDSHIP_ACCEPT)
+		}
+
+	case FRIENDSHIP_ACCEPT:
+		if state != FRIEND_REQUESTED && state != FRIEND_ACCEPTED {
+			return fmt.Errorf("%w: %s accepted request which was not sent", ErrInvalidMessage, e.Source)
+		}
+		friend.State = FRIEND_ACCEPTED
+
+	case FRIENDSHIP_DECLINE, FRIENDSHIP_REMOVE:
+		if state == "" {
+			return nil
+		}
+		log.Info("handleFriendship: friendship ended")
+		return c.store.DeleteFriend(e.Source)
+
+	case FRIENDSHIP_NICK:
+		if state != FRIEND_ACCEPTED {
+			return fmt.Errorf("%w: nick of %s which is not a friend", ErrInvalidMessage, e.Source)
+		}
+	}
+
+	if err := c.store.SaveFriend(friend); err != nil {
+		return err
+	}
+	log.Info("handleFriendship: friend changed")
+	c.publishFriend(CHAT_EVENT_FRIEND, friend)
+	return nil
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"errors"
+	"github.com/segmentio/ksuid"
+	"main/gql"
+	"main/store"
+	"testing"
+	"time"
+)
+
+// friendStateOf returns state of friend saved by client, empty if there is none
+func friendStateOf(t *testing.T, c *Client, userID string) string {
+	t.Helper()
+	friend, err := c.friend(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return friendState(friend)
+}
+
+func TestClient_friendship(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+	friends, cancel := b.SubscribeFriends()
+	defer cancel()
+
+	if err := a.ChangeNick("  alice "); err != nil || a.Nick() != "alice" {
+		t.Fatalf("ChangeNick() error = %v, nick = %q", err, a.Nick())
+	}
+	if err := a.ChangeNick(" "); err != ErrInvalidNick {
+		t.Errorf("ChangeNick() of blank nick error = %v, want %v", err, ErrInvalidNick)
+	}
+
+	// request waits for answer of b
+	if state, err := a.AddFriend(b.userID); err != nil || state != FRIEND_REQUESTED {
+		t.Fatalf("AddFriend() = %s, %v", state, err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-friends:
+		friend := e.Friend
+		if friend.UserID != a.userID || friend.State != FRIEND_PENDING || friend.Nick == nil || *friend.Nick != "alice" {
+			t.Errorf("published friend = %+v", friend)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("friend request not published")
+	}
+	if key, err := b.store.PublicKey(a.userID); err != nil || len(key) == 0 {
+		t.Errorf("public key of requesting user not saved: %v", err)
+	}
+
+	if _, err := b.AcceptFriend(b.userID); !errors.Is(err, ErrNoFriendRequest) {
+		t.Errorf("AcceptFriend() without request error = %v", err)
+	}
+	if _, err := b.AcceptFriend(a.userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, b, a); err != nil {
+		t.Fatal(err)
+	}
+	if state := friendStateOf(t, a, b.userID); state != FRIEND_ACCEPTED {
+		t.Errorf("state of friend after acceptance = %s", state)
+	}
+
+	// friends learn new nick
+	if err := a.ChangeNick("alicja"); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if friend, _ := b.friend(a.userID); friend.Nick == nil || *friend.Nick != "alicja" {
+		t.Errorf("nick of friend = %v, want alicja", friend.Nick)
+	}
+
+	// removal ends friendship on both sides
+	if err := b.RemoveFriend(a.userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, b, a); err != nil {
+		t.Fatal(err)
+	}
+	if state := friendStateOf(t, a, b.userID); state != "" {
+		t.Errorf("state of removed friend = %s", state)
+	}
+	if err := b.RemoveFriend(a.userID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RemoveFriend() of unknown user error = %v", err)
+	}
+}
+
+func TestClient_friendshipDeclined(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+
+	if _, err := a.AddFriend(b.userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DeclineFriend(a.userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, b, a); err != nil {
+		t.Fatal(err)
+	}
+	if state := friendStateOf(t, a, b.userID); state != "" {
+		t.Errorf("state of friend after decline = %s", state)
+	}
+	if state := friendStateOf(t, b, a.userID); state != "" {
+		t.Errorf("declined request kept as %s", state)
+	}
+
+	// users requesting each other become friends
+	if _, err := a.AddFriend(b.userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.AddFriend(a.userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if state := friendStateOf(t, b, a.userID); state != FRIEND_ACCEPTED {
+		t.Errorf("state of mutual request = %s, want accepted", state)
+	}
+}
+
+func TestClient_handleFriendship_invalid(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	other := newTestClient(t)
+
+	tests := []struct {
+		name string
+		body *Friendship
+	}{
+		{"key of other user", &Friendship{Action: FRIENDSHIP_REQUEST, PublicKey: other.identity.PublicKey}},
+		{"acceptance without request", &Friendship{Action: FRIENDSHIP_ACCEPT, PublicKey: a.identity.PublicKey}},
+		{"nick of stranger", &Friendship{Action: FRIENDSHIP_NICK, Nick: "mallory"}},
+		{"request without key", &Friendship{Action: FRIENDSHIP_REQUEST}},
+		{"unknown action", &Friendship{Action: "adopt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, tt.body)); !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidMessage)
+			}
+			if state := friendStateOf(t, b, a.userID); state != "" {
+				t.Errorf("friend saved as %s", state)
+			}
+		})
+	}
+}
+
+func TestClient_blockUser(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+
+	if err := b.BlockUser(a.userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.AddFriend(a.userID); !errors.Is(err, ErrBlocked) {
+		t.Errorf("AddFriend() of blocked user error = %v", err)
+	}
+
+	// adverts, messages and requests of blocked user are dropped before their handlers
+	for _, body := range []Message{
+		&ChatAdvert{ChatID: "123", ChatName: "chat"},
+		&Friendship{Action: FRIENDSHIP_REQUEST, PublicKey: a.identity.PublicKey},
+		&ChatParticipantsResponse{ChatID: "123", Participants: []string{a.userID, b.userID}},
+	} {
+		p, err := MarshalEnvelope(NewEnvelope(a.userID, body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.dispatchPayload(p); err == nil {
+			t.Errorf("%s of blocked user dispatched", body.MessageType())
+		}
+	}
+	if rejected := b.RejectedPayloads()[REJECT_BLOCKED]; rejected != 3 {
+		t.Errorf("rejected as blocked = %d, want 3", rejected)
+	}
+	if _, err := b.GetChat("123"); err == nil {
+		t.Error("chat of blocked user created")
+	}
+	if state := friendStateOf(t, b, a.userID); state != FRIEND_BLOCKED {
+		t.Errorf("state of blocked user = %s", state)
+	}
+
+	if err := b.UnblockUser(a.userID); err != nil {
+		t.Fatal(err)
+	}
+	if b.IsBlocked(a.userID) {
+		t.Error("user blocked after UnblockUser()")
+	}
+	if err := b.UnblockUser(a.userID); err == nil {
+		t.Error("UnblockUser() of not blocked user succeeded")
+	}
+}
+
+func TestClient_blockUserPassedMessages(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, b, c)
+	newRolesTestChats(a, b, c)
+	if err := b.BlockUser(c.userID); err != nil {
+		t.Fatal(err)
+	}
+
+	// messages written by blocked user are passed to b by a
+	written := func(text string) *ChatMessage {
+		message := gql.TextMessage{MessageID: ksuid.New().String(), ChatID: "123", User: c.userID, TimeStamp: time.Now().UTC(), Text: text}
+		c.signMessage(&message)
+		encrypted, err := c.encryptMessage(c.chatList["123"], message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encrypted
+	}
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, written("live"))); !errors.Is(err, ErrBlocked) {
+		t.Errorf("passed message of blocked user error = %v, want %v", err, ErrBlocked)
+	}
+	history := &HistorySyncResponse{ChatID: "123", Messages: []*ChatMessage{written("old")}}
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, history)); err != nil {
+		t.Fatal(err)
+	}
+
+	if messages, err := b.Messages("123"); err != nil || len(messages) != 0 {
+		t.Errorf("messages of blocked user saved: %d, %v", len(messages), err)
+	}
+	if rejected := b.RejectedPayloads()[REJECT_BLOCKED]; rejected != 1 {
+		t.Errorf("rejected as blocked = %d, want 1", rejected)
+	}
+}
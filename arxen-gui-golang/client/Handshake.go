@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	logger "github.com/sirupsen/logrus"
+	"main/identity"
+	"sync"
+	"time"
+)
+
+// handshake:
+// connecting client (A) announces its identity in setup payload, then proves possession of keys
+// by mutual challenge-response over request-response before opening the channel:
+//
+//	A -> B  AUTH_CHALLENGE {nonceA}
+//	B -> A  {identity B, nonceB, sign_B(nonceA, B, A)}
+//	A -> B  AUTH_RESPONSE  {sign_A(nonceB, A, B)}
+//	B -> A  {} or error
+//
+// B accepts channel only after A answered its challenge and closes connection
+// which fails handshake or does not finish it in AUTH_TIMEOUT
+// address announced by A is not proven, B keys link with A by it only if it is not address of other user
+// (see inboundAddress), otherwise A could take over link and outbox of that user
+// both sides accept only envelopes with Source of authenticated user
+
+// metadata of handshake request-responses
+const (
+	AUTH_CHALLENGE = "AUTH_CHALLENGE"
+	AUTH_RESPONSE  = "AUTH_RESPONSE"
+)
+
+const (
+	// time given to connecting client to finish handshake
+	AUTH_TIMEOUT = 5 * time.Second
+	// size of random challenge in bytes
+	AUTH_NONCE_SIZE = 32
+	// signed together with challenge, so signature cannot be reused in other context
+	AUTH_SIGNATURE_CONTEXT = "arxen-auth-v1"
+)
+
+var (
+	// ErrAuthenticationFailed is returned when other client did not prove possession of its key
+	ErrAuthenticationFailed = errors.New("authentication failed")
+	// ErrNotAuthenticated is returned for requests sent before finishing handshake
+	ErrNotAuthenticated = errors.New("not authenticated")
+)
+
+// authChallenge is sent by connecting client
+type authChallenge struct {
+	Nonce []byte `json:"nonce"`
+}
+
+// authProof answers challenge of the other side
+// Identity and Nonce (challenge of the other way) are set only in answer of accepting client
+type authProof struct {
+	Identity  *PeerIdentity `json:"identity,omitempty"`
+	Nonce     []byte        `json:"nonce,omitempty"`
+	Signature []byte        `json:"signature"`
+}
+
+// newNonce returns random challenge
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, AUTH_NONCE_SIZE)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// authMessage returns bytes signed by signer answering challenge of verifier
+// IDs of both sides are included, so signature cannot be reflected back or relayed to other client
+func authMessage(nonce []byte, signerID, verifierID string) []byte {
+	message := []byte(AUTH_SIGNATURE_CONTEXT + "\n" + signerID + "\n" + verifierID + "\n")
+	return append(message, nonce...)
+}
+
+// authSession is state of handshake with connecting client, kept by accepting client
+type authSession struct {
+	remote    PeerIdentity
+	publicKey ed25519.PublicKey
+	nonce     []byte // challenge sent to remote
+
+	mutex         sync.Mutex
+	authenticated bool
+	addr          string // key of link in peer registry, set when remote is authenticated
+	closed        bool
+	close         func() // closes connection
+}
+
+// newAuthSession starts handshake with client which announced remote identity in setup
+// close is called when handshake fails or times out
+func newAuthSession(remote PeerIdentity, close func()) (*authSession, error) {
+	publicKey, err := identity.ParsePublicKey(remote.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &authSession{remote: remote, publicKey: publicKey, nonce: nonce, close: close}
+	time.AfterFunc(AUTH_TIMEOUT, func() {
+		if !s.isAuthenticated() {
+			s.fail(fmt.Errorf("%w: handshake timed out", ErrAuthenticationFailed))
+		}
+	})
+	return s, nil
+}
+
+// isAuthenticated reports if remote finished handshake
+func (s *authSession) isAuthenticated() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.authenticated
+}
+
+// address returns address keying link of authenticated remote in peer registry, empty before handshake is finished
+func (s *authSession) address() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addr
+}
+
+// fail closes connection of failed handshake (once)
+func (s *authSession) fail(err error) {
+	s.mutex.Lock()
+	closed := s.closed
+	s.closed = true
+	s.mutex.Unlock()
+
+	if closed {
+		return
+	}
+	logger.WithError(err).WithField("userID", s.remote.UserID).Warn("authSession: closing connection")
+	if s.close != nil {
+		s.close()
+	}
+}
+
+// answerChallenge handles AUTH_CHALLENGE: proves own identity and challenges remote
+func (c *Client) answerChallenge(s *authSession, pl payload.Payload) mono.Mono {
+	var challenge authChallenge
+	if err := json.Unmarshal(pl.Data(), &challenge); err != nil || len(challenge.Nonce) != AUTH_NONCE_SIZE {
+		err = fmt.Errorf("%w: malformed challenge", ErrAuthenticationFailed)
+		s.fail(err)
+		return mono.Error(err)
+	}
+
+	local := c.localIdentity()
+	data, _ := json.Marshal(authProof{
+		Identity:  &local,
+		Nonce:     s.nonce,
+		Signature: c.identity.Sign(authMessage(challenge.Nonce, c.userID, s.remote.UserID)),
+	})
+	return mono.Just(payload.New(data, []byte(AUTH_CHALLENGE)))
+}
+
+// checkResponse handles AUTH_RESPONSE: verifies that remote signed challenge
+func (c *Client) checkResponse(s *authSession, pl payload.Payload) mono.Mono {
+	var proof authProof
+	if err := json.Unmarshal(pl.Data(), &proof); err != nil ||
+		!identity.Verify(s.publicKey, authMessage(s.nonce, s.remote.UserID, c.userID), proof.Signature) {
+		err := fmt.Errorf("%w: invalid signature of %s", ErrAuthenticationFailed, s.remote.UserID)
+		s.fail(err)
+		return mono.Error(err)
+	}
+
+	// key is trusted only now, claimed address only if it is not address of other user
+	addr, ok := c.inboundAddress(s.remote)
+	if !ok {
+		err := fmt.Errorf("%w: %s claims address %s of other user", ErrAuthenticationFailed, s.remote.UserID, s.remote.Address)
+		s.fail(err)
+		return mono.Error(err)
+	}
+	c.rememberPeer(s.remote, addr)
+
+	s.mutex.Lock()
+	s.authenticated = true
+	s.addr = addr
+	s.mutex.Unlock()
+	logger.WithFields(logger.Fields{"userID": s.remote.UserID, "addr": addr}).Info("checkResponse: client authenticated")
+	return mono.Just(payload.New(nil, []byte(AUTH_RESPONSE)))
+}
+
+// authenticate performs handshake with accepting client, returns its verified identity
+func (c *Client) authenticate(cli rsocket.Client) (*PeerIdentity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), AUTH_TIMEOUT)
+	defer cancel()
+
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(authChallenge{Nonce: nonce})
+	response, err := cli.RequestResponse(payload.New(data, []byte(AUTH_CHALLENGE))).Block(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthenticationFailed, err)
+	}
+
+	var proof authProof
+	if err := json.Unmarshal(response.Data(), &proof); err != nil || proof.Identity == nil {
+		return nil, fmt.Errorf("%w: malformed proof", ErrAuthenticationFailed)
+	}
+	remote := *proof.Identity
+	publicKey, err := identity.VerifyUserID(remote.UserID, remote.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.Verify(publicKey, authMessage(nonce, remote.UserID, c.userID), proof.Signature) {
+		return nil, fmt.Errorf("%w: invalid signature of %s", ErrAuthenticationFailed, remote.UserID)
+	}
+
+	data, _ = json.Marshal(authProof{Signature: c.identity.Sign(authMessage(proof.Nonce, c.userID, remote.UserID))})
+	if _, err := cli.RequestResponse(payload.New(data, []byte(AUTH_RESPONSE))).Block(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthenticationFailed, err)
+	}
+	return &remote, nil
+}
+
+// receiveFrom passes payload received from authenticated user to dispatcher
+// envelopes claiming other source are rejected
+func (c *Client) receiveFrom(userID string, p payload.Payload) {
+	if e, err := UnmarshalEnvelope(p); err == nil && e.Source != userID {
+		_ = c.dispatcher.reject(REJECT_SPOOFED, fmt.Errorf("%w: %s sent envelope with source %s", ErrInvalidMessage, userID, e.Source))
+		return
+	}
+	// payloads which cannot be decoded are rejected by dispatcher
+	c.receivedPayloadChan <- p
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"main/chat"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve starts accepting connections, returns function stopping it
+func serve(t *testing.T, c *Client) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = rsocket.Receive().Resume().Acceptor(c.acceptor).Transport(c.userIP).Serve(ctx)
+	}()
+	// wait for listener
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", c.userIP[len("tcp://"):]); err == nil {
+			conn.Close()
+			return cancel
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	t.Fatal("listener not started")
+	return nil
+}
+
+// dial connects to client at addr with setup metadata announcing identity of from
+func dial(t *testing.T, from *Client, addr string) rsocket.Client {
+	cli, err := rsocket.Connect().
+		SetupPayload(payload.New([]byte(from.userIP), from.setupMetadata())).
+		Transport(addr).
+		Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cli
+}
+
+func TestClient_authenticate(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	defer serve(t, b)()
+
+	cli := dial(t, a, b.userIP)
+	defer cli.Close()
+
+	remote, err := a.authenticate(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.UserID != b.userID || remote.Address != b.userIP {
+		t.Errorf("authenticate() = %v, want identity of %s", remote, b.userID)
+	}
+	if addr, ok := b.AddressOf(a.userID); !ok || addr != a.userIP {
+		t.Errorf("address of authenticated client = %s, %v, want %s", addr, ok, a.userIP)
+	}
+}
+
+func TestClient_authenticateImpersonator(t *testing.T) {
+	victim := newTestClient(t)
+	b := newTestClient(t)
+	defer serve(t, b)()
+
+	// claims identity of victim in setup, but owns other key
+	impersonator := newTestClient(t)
+	impersonator.userID = victim.userID
+	impersonator.userIP = victim.userIP
+
+	cli := dial(t, victim, b.userIP)
+	defer cli.Close()
+
+	if _, err := impersonator.authenticate(cli); err == nil {
+		t.Fatal("authenticate() of impersonator succeeded")
+	}
+	if addr, ok := b.AddressOf(victim.userID); ok {
+		t.Errorf("address of impersonator saved as address of victim: %s", addr)
+	}
+}
+
+func TestClient_authenticateClaimedAddress(t *testing.T) {
+	victim := newTestClient(t)
+	b := newTestClient(t)
+	defer serve(t, b)()
+	b.setAddress(victim.userID, victim.userIP)
+
+	// authenticates with own key, but claims address of victim in setup
+	a := newTestClient(t)
+	a.userIP = victim.userIP
+	cli := dial(t, a, b.userIP)
+	defer cli.Close()
+
+	if _, err := a.authenticate(cli); err == nil {
+		t.Fatal("authenticate() of client claiming address of other user succeeded")
+	}
+	if userID, _ := b.userIDAt(victim.userIP); userID != victim.userID {
+		t.Errorf("address of victim bound to %s", userID)
+	}
+	if addr, ok := b.AddressOf(a.userID); ok {
+		t.Errorf("address of victim saved as address of other user: %s", addr)
+	}
+}
+
+func TestClient_inboundAddress(t *testing.T) {
+	c := newTestClient(t)
+	c.setAddress("victim", "tcp://10.5.0.2:7878")
+	c.setAddress("moved", "tcp://10.5.0.3:7878")
+
+	tests := []struct {
+		name   string
+		remote PeerIdentity
+		want   string
+		wantOK bool
+	}{
+		{"new user", PeerIdentity{UserID: "new", Address: "tcp://10.5.0.4:7878"}, "tcp://10.5.0.4:7878", true},
+		{"own address", PeerIdentity{UserID: "victim", Address: "tcp://10.5.0.2:7878"}, "tcp://10.5.0.2:7878", true},
+		{"address of other user", PeerIdentity{UserID: "other", Address: "tcp://10.5.0.2:7878"}, "", false},
+		{"known user claiming address of other user", PeerIdentity{UserID: "moved", Address: "tcp://10.5.0.2:7878"}, "tcp://10.5.0.3:7878", true},
+		{"address of client", PeerIdentity{UserID: "other", Address: c.userIP}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := c.inboundAddress(tt.remote); got != tt.want || ok != tt.wantOK {
+				t.Errorf("inboundAddress() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+	if userID, _ := c.userIDAt("tcp://10.5.0.2:7878"); userID != "victim" {
+		t.Errorf("address of victim bound to %s", userID)
+	}
+}
+
+func TestClient_channelBeforeHandshake(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	defer serve(t, b)()
+
+	cli := dial(t, a, b.userIP)
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := cli.RequestChannel(flux.Just(payload.NewString("", ""))).BlockLast(ctx)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RequestChannel() before handshake error = %v, want rejection", err)
+	}
+	if b.peers.IsConnected(a.userIP) {
+		t.Error("not authenticated client marked as connected")
+	}
+}
+
+func TestClient_participantsStream(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	defer serve(t, b)()
+	b.chatList["123"] = chat.NewChat("123", []string{b.userID, "other"}, b.store)
+
+	cli := dial(t, a, b.userIP)
+	defer cli.Close()
+	if _, err := a.authenticate(cli); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	request := func() (payload.Payload, error) {
+		return cli.RequestStream(payload.NewString("123", "CHAT_PARTICIPANTS_REQ")).BlockLast(ctx)
+	}
+	// participants are told only to other participants
+	if _, err := request(); err == nil {
+		t.Error("participants sent to user outside of chat")
+	}
+	b.chatList["123"].SetParticipants([]string{b.userID, a.userID})
+	if pl, err := request(); err != nil || pl == nil {
+		t.Errorf("participants request of participant = %v, %v", pl, err)
+	}
+}
+
+func TestClient_receiveFrom(t *testing.T) {
+	c := newTestClient(t)
+
+	spoofed, err := MarshalEnvelope(NewEnvelope("other", &ChatAdvert{ChatID: "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.receiveFrom("sender", spoofed)
+	if got := c.RejectedPayloads()[REJECT_SPOOFED]; got != 1 {
+		t.Errorf("rejected spoofed payloads = %d, want 1", got)
+	}
+
+	genuine, err := MarshalEnvelope(NewEnvelope("sender", &ChatAdvert{ChatID: "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.receiveFrom("sender", genuine)
+	if len(c.receivedPayloadChan) != 1 {
+		t.Error("payload of authenticated sender not passed to dispatcher")
+	}
+}
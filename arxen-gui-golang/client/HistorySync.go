@@ -0,0 +1,190 @@
+package client
+
+import (
+	"fmt"
+	"github.com/segmentio/ksuid"
+	logger "github.com/sirupsen/logrus"
+	"main/chat"
+	"main/store"
+	"time"
+)
+
+// history synchronisation:
+// after connecting with other client, for each chat both participate in
+// HISTORY_SYNC_REQUEST {chatID, sinceMessageID} is sent and answered by
+// HISTORY_SYNC_RESPONSE {chatID, messages} in batches of HISTORY_SYNC_BATCH_SIZE,
+// full batch makes requester ask for the next one
+// messages already stored are ignored (deduplicated by MessageID)
+
+const (
+	// max number of messages in single HISTORY_SYNC_RESPONSE
+	HISTORY_SYNC_BATCH_SIZE = 100
+	// history is requested from this long before the newest known message,
+	// so messages of other participants delivered out of order are not skipped
+	HISTORY_SYNC_OVERLAP = 10 * time.Minute
+)
+
+// requestHistorySync asks user with given ID for messages of all shared chats
+func (c *Client) requestHistorySync(userID string) {
+	c.mutex.Lock()
+	var chats []*chat.Chat
+	for _, tmpChat := range c.chatList {
+		for _, participant := range tmpChat.ClientsIPsList() {
+			if participant == userID {
+				chats = append(chats, tmpChat)
+				break
+			}
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, tmpChat := range chats {
+		since, err := historySyncSince(tmpChat)
+		if err != nil {
+			logger.WithError(err).WithField("chatID", tmpChat.ChatID).Error("requestHistorySync: cannot read last message")
+			continue
+		}
+		if err := c.sendTo(userID, &HistorySyncRequest{ChatID: tmpChat.ChatID, SinceMessageID: since}); err != nil {
+			logger.WithError(err).WithField("userID", userID).Warn("requestHistorySync: request not sent")
+			return
+		}
+	}
+}
+
+// historySyncSince returns MessageID history of chat should be requested from
+func historySyncSince(tmpChat *chat.Chat) (string, error) {
+	last, err := tmpChat.LastMessage()
+	if err != nil || last == nil {
+		return "", err
+	}
+
+	lastID, err := ksuid.Parse(last.MessageID)
+	if err != nil {
+		// not a ksuid, ask for whole history
+		return "", nil
+	}
+
+	// the smallest ksuid of given time
+	since, err := ksuid.FromParts(lastID.Time().Add(-HISTORY_SYNC_OVERLAP), make([]byte, 16))
+	if err != nil {
+		return "", nil
+	}
+	return since.String(), nil
+}
+
+// handleHistorySyncRequest sends requester batch of messages it missed
+func (c *Client) handleHistorySyncRequest(e *Envelope) error {
+	body, ok := e.Body.(*HistorySyncRequest)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+
+	messages, err := tmpChat.MessagesSince(body.SinceMessageID, HISTORY_SYNC_BATCH_SIZE)
+	if err != nil {
+		return err
+	}
+
+	// history is sent encrypted with current key
+	response := &HistorySyncResponse{ChatID: body.ChatID}
+	for _, message := range messages {
+		encrypted, err := c.encryptMessage(tmpChat, *message)
+		if err != nil {
+			return err
+		}
+		response.Messages = append(response.Messages, encrypted)
+	}
+
+	logger.WithFields(logger.Fields{
+		"chatID":   body.ChatID,
+		"userID":   e.Source,
+		"messages": len(response.Messages),
+	}).Debug("handleHistorySyncRequest: sending history")
+
+	return c.sendTo(e.Source, response)
+}
+
+// handleHistorySyncResponse saves missed messages and asks for the next batch if needed
+func (c *Client) handleHistorySyncResponse(e *Envelope) error {
+	body, ok := e.Body.(*HistorySyncResponse)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+
+	backfilled, rejected, blocked := 0, 0, 0
+	delivered := make(map[string][]string) // author : messageIDs
+	for _, message := range body.Messages {
+		// single bad message does not stop the rest of history
+		tmpTextMessage, err := c.decryptMessage(tmpChat, e.Source, message)
+		if err != nil {
+			rejected++
+			logger.WithError(err).WithFields(logger.Fields{
+				"chatID":    body.ChatID,
+				"messageID": message.MessageID,
+				"userID":    e.Source,
+			}).Warn("handleHistorySyncResponse: message skipped")
+			continue
+		}
+		// sender is checked by dispatcher, author of passed message is not
+		if c.IsBlocked(tmpTextMessage.User) {
+			blocked++
+			continue
+		}
+		added, err := tmpChat.AddMessage(&tmpTextMessage)
+		if err != nil {
+			return err
+		}
+		if !added {
+			continue
+		}
+		backfilled++
+		delivered[tmpTextMessage.User] = append(delivered[tmpTextMessage.User], tmpTextMessage.MessageID)
+		tmpChat.PublishMessage(&tmpTextMessage)
+	}
+
+	logger.WithFields(logger.Fields{
+		"chatID":     body.ChatID,
+		"userID":     e.Source,
+		"backfilled": backfilled,
+		"rejected":   rejected,
+		"blocked":    blocked,
+	}).Debug("handleHistorySyncResponse: history received")
+
+	for author, messageIDs := range delivered {
+		c.sendReceipt(author, body.ChatID, messageIDs, store.RECEIPT_DELIVERED)
+	}
+	if backfilled > 0 {
+		c.publishLastMessage(body.ChatID)
+	}
+
+	// full batch, there may be more
+	if len(body.Messages) == HISTORY_SYNC_BATCH_SIZE {
+		last := body.Messages[len(body.Messages)-1].MessageID
+		go func() {
+			if err := c.sendTo(e.Source, &HistorySyncRequest{ChatID: body.ChatID, SinceMessageID: last}); err != nil {
+				logger.WithError(err).Warn("handleHistorySyncResponse: next request not sent")
+			}
+		}()
+	}
+	return nil
+}
+
+// isParticipant reports if user with given ID participates in chat
+func isParticipant(tmpChat *chat.Chat, userID string) bool {
+	return tmpChat.HasParticipant(userID)
+}
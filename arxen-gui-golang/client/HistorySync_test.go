@@ -0,0 +1,144 @@
+package client
+
+import (
+	"github.com/segmentio/ksuid"
+	"main/chat"
+	"main/gql"
+	"main/store"
+	"testing"
+	"time"
+)
+
+// key of chat shared by test clients
+var testChatKey = make([]byte, chat.KEY_SIZE)
+
+// newHistoryTestClients returns connected clients participating in chat "123",
+// the first one stays online, the other one was offline
+func newHistoryTestClients(t *testing.T) (*Client, *Client) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+	newRolesTestChats(a, b)
+	return a, b
+}
+
+func TestClient_historySync(t *testing.T) {
+	a, b := newHistoryTestClients(t)
+
+	start := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := 0; i < HISTORY_SYNC_BATCH_SIZE+5; i++ {
+		id, err := ksuid.NewRandomWithTime(start.Add(time.Duration(i) * time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := &gql.TextMessage{MessageID: id.String(), ChatID: "123", User: a.userID, TimeStamp: start, Text: "missed"}
+		a.signMessage(message)
+		if _, err := a.store.AddMessage(message); err != nil {
+			t.Fatal(err)
+		}
+		// offline client knows only the first message
+		if i == 0 {
+			if _, err := b.store.AddMessage(message); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ids = append(ids, id.String())
+	}
+
+	go b.requestHistorySync(a.userID)
+
+	// pass envelopes between clients until offline one stops asking
+	for requests := 0; ; {
+		var request *Envelope
+		select {
+		case request = <-outboxOf(t, b, a.userIP):
+		case <-time.After(100 * time.Millisecond):
+			if requests != 2 {
+				t.Errorf("history requested %d times, want 2", requests)
+			}
+			messages, err := b.Messages("123")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(messages) != len(ids) || messages[len(messages)-1].MessageID != ids[len(ids)-1] {
+				t.Errorf("offline client has %d messages, want %d", len(messages), len(ids))
+			}
+			// author learns that backfilled messages were delivered
+			for _, id := range []string{ids[1], ids[len(ids)-1]} {
+				if receipts, _ := a.store.Receipts("123", id); receipts[b.userID] != store.RECEIPT_DELIVERED {
+					t.Errorf("receipts of backfilled message = %v, want delivered to %s", receipts, b.userID)
+				}
+			}
+			return
+		}
+
+		if err := a.dispatcher.DispatchEnvelope(request); err != nil {
+			t.Fatal(err)
+		}
+		// receipts are not answered
+		if request.Type != HISTORY_SYNC_REQUEST {
+			continue
+		}
+		requests++
+		if err := b.dispatcher.DispatchEnvelope(<-outboxOf(t, a, b.userIP)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestClient_historySyncRejectsStranger(t *testing.T) {
+	c, _ := newHistoryTestClients(t)
+
+	bodies := []Message{
+		&HistorySyncRequest{ChatID: "123"},
+		&HistorySyncResponse{ChatID: "123"},
+		&HistorySyncRequest{ChatID: "unknown"},
+	}
+	for _, body := range bodies {
+		if err := c.dispatcher.DispatchEnvelope(NewEnvelope("tcp://10.5.0.3:7878", body)); err == nil {
+			t.Errorf("DispatchEnvelope(%T) from stranger accepted", body)
+		}
+	}
+}
+
+func TestClient_historySyncSkipsBadMessage(t *testing.T) {
+	a, b := newHistoryTestClients(t)
+
+	// full batch with single corrupted message in the middle
+	response := &HistorySyncResponse{ChatID: "123"}
+	for i := 0; i < HISTORY_SYNC_BATCH_SIZE; i++ {
+		message := gql.TextMessage{MessageID: ksuid.New().String(), ChatID: "123", User: a.userID, TimeStamp: time.Now().UTC(), Clock: i + 1, Text: "missed"}
+		a.signMessage(&message)
+		encrypted, err := a.encryptMessage(a.chatList["123"], message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == HISTORY_SYNC_BATCH_SIZE/2 {
+			encrypted.Ciphertext[len(encrypted.Ciphertext)-1] ^= 0xff
+		}
+		response.Messages = append(response.Messages, encrypted)
+	}
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, response)); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := b.Messages("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != HISTORY_SYNC_BATCH_SIZE-1 {
+		t.Errorf("offline client has %d messages, want %d", len(messages), HISTORY_SYNC_BATCH_SIZE-1)
+	}
+	// receipt and request of the next batch are sent despite bad message
+	var receipt, request bool
+	for !receipt || !request {
+		select {
+		case e := <-outboxOf(t, b, a.userIP):
+			receipt = receipt || e.Type == MESSAGE_RECEIPT
+			request = request || e.Type == HISTORY_SYNC_REQUEST
+		case <-time.After(time.Second):
+			t.Fatalf("receipt sent = %v, next batch requested = %v, want both", receipt, request)
+		}
+	}
+}
@@ -0,0 +1,525 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/chat"
+	"main/identity"
+	"main/store"
+	"sort"
+	"sync"
+	"time"
+)
+
+// membership of chats:
+// admin adds user with CHAT_MEMBERSHIP {chatID, join, userID} sent to other participants
+// and CHAT_ADVERT sent to the new one, which asks for participants, roles, current key and history as when chat was created
+// participant leaves with CHAT_MEMBERSHIP {leave, own user ID}, admin removes other participant with {kick, userID},
+// removed user is told too, so it stops posting, chat stays in its store with history
+// admin makes other participant admin with {promote, userID} and member again with {demote, userID},
+// owner of chat cannot be removed nor demoted (see chat/Roles.go)
+//
+// every change is stamped with clock of chat and signed by its actor, participants check the signature
+// and that actor was allowed to make it, changes of user older than already applied one are ignored
+// after user leaves or is removed chat is rekeyed (see Encryption.go) by the one who removed it,
+// or by remaining participant with the lowest user ID when user left, so the removed one cannot read new messages
+// joined users are published as CHAT_EVENT_JOINED
+//
+// joining user accepts participants only from participant it asked (see participantsRequests),
+// it trusts it with participants and key of chat, but not with roles:
+// creator of chat signs its creation {chatID, owner, clock}, owners of chats created before sign it with clock 0
+// when answering first CHAT_PARTICIPANTS_REQUEST, every participant keeps it with all applied signed changes of roles
+// and passes them with participants, joining user accepts owner only as signer of creation
+// and replays changes in order they were made, checking each against roles at that time, starting with owner as the only admin
+// response without owner cannot carry any changes, chats without owner have no roles
+
+// MembershipAction is kind of change of chat participants
+type MembershipAction string
+
+const (
+	MEMBERSHIP_JOIN    MembershipAction = "join"
+	MEMBERSHIP_LEAVE   MembershipAction = "leave"
+	MEMBERSHIP_KICK    MembershipAction = "kick"
+	MEMBERSHIP_PROMOTE MembershipAction = "promote"
+	MEMBERSHIP_DEMOTE  MembershipAction = "demote"
+	// signed by owner when creating chat, it is never sent as CHAT_MEMBERSHIP
+	MEMBERSHIP_CREATE MembershipAction = "create"
+)
+
+// membershipRoles maps action to role user has after it
+var membershipRoles = map[MembershipAction]chat.Role{
+	MEMBERSHIP_JOIN:    chat.ROLE_MEMBER,
+	MEMBERSHIP_LEAVE:   chat.ROLE_NONE,
+	MEMBERSHIP_KICK:    chat.ROLE_NONE,
+	MEMBERSHIP_PROMOTE: chat.ROLE_ADMIN,
+	MEMBERSHIP_DEMOTE:  chat.ROLE_MEMBER,
+}
+
+// signed together with change, so signature cannot be reused in other context
+const MEMBERSHIP_SIGNATURE_CONTEXT = "arxen-membership-v1"
+
+// time in which participant has to answer CHAT_PARTICIPANTS_REQUEST, later responses are rejected
+const PARTICIPANTS_REQUEST_TIMEOUT = 10 * time.Minute
+
+var (
+	// ErrNotParticipant is returned when user does not participate in chat
+	ErrNotParticipant = errors.New("not a participant")
+	// ErrNotPermitted is returned when user is not allowed to change chat
+	ErrNotPermitted = errors.New("not permitted")
+)
+
+// signedMembership returns bytes signed by actor of change
+func signedMembership(m *ChatMembership) []byte {
+	content := []byte(MEMBERSHIP_SIGNATURE_CONTEXT)
+	for _, field := range []string{m.ChatID, string(m.Action), m.UserID, m.Actor} {
+		content = appendField(content, []byte(field))
+	}
+	var clock [8]byte
+	binary.BigEndian.PutUint64(clock[:], uint64(m.Clock))
+	return appendField(content, clock[:])
+}
+
+// checkMembership reports error if change is not allowed by current roles in chat
+func checkMembership(tmpChat *chat.Chat, m *ChatMembership) error {
+	if !tmpChat.HasParticipant(m.Actor) {
+		return fmt.Errorf("%w: %s in chat %s", ErrNotParticipant, m.Actor, m.ChatID)
+	}
+	if m.Action == MEMBERSHIP_LEAVE {
+		if m.UserID != m.Actor {
+			return fmt.Errorf("%w: %s cannot leave chat for %s", ErrNotPermitted, m.Actor, m.UserID)
+		}
+		return nil
+	}
+
+	if !tmpChat.IsAdmin(m.Actor) {
+		return fmt.Errorf("%w: %s is not admin of chat %s", ErrNotPermitted, m.Actor, m.ChatID)
+	}
+	switch m.Action {
+	case MEMBERSHIP_KICK, MEMBERSHIP_DEMOTE:
+		if m.UserID == m.Actor {
+			return fmt.Errorf("%w: %s cannot %s itself", ErrNotPermitted, m.Actor, m.Action)
+		}
+		if m.UserID == tmpChat.Owner() {
+			return fmt.Errorf("%w: owner of chat cannot be %sd", ErrNotPermitted, m.Action)
+		}
+	case MEMBERSHIP_PROMOTE:
+		if !tmpChat.HasParticipant(m.UserID) {
+			return fmt.Errorf("%w: %s in chat %s", ErrNotParticipant, m.UserID, m.ChatID)
+		}
+	}
+	return nil
+}
+
+// verifyMembership reports error if change is not signed by its actor or actor was not allowed to make it
+func (c *Client) verifyMembership(tmpChat *chat.Chat, m *ChatMembership) error {
+	publicKey, err := c.publicKeyOf(m.Actor)
+	if err != nil {
+		return err
+	}
+	if !identity.Verify(publicKey, signedMembership(m), m.Signature) {
+		return fmt.Errorf("invalid signature of membership change by %s", m.Actor)
+	}
+	return checkMembership(tmpChat, m)
+}
+
+// applyMembership applies verified change, returns previous role of user and false if change was not applied
+func applyMembership(tmpChat *chat.Chat, m *ChatMembership) (chat.Role, bool) {
+	version := store.MemberVersion{Clock: m.Clock, Actor: m.Actor, Action: string(m.Action), Signature: m.Signature}
+	if m.Action == MEMBERSHIP_JOIN {
+		return tmpChat.ApplyJoin(m.UserID, version)
+	}
+	return tmpChat.ApplyRole(m.UserID, membershipRoles[m.Action], version)
+}
+
+// changeMembership signs change of role of user made by this client and applies it
+// returns participants which should be informed
+func (c *Client) changeMembership(tmpChat *chat.Chat, action MembershipAction, userID string) (*ChatMembership, []string, error) {
+	m := &ChatMembership{ChatID: tmpChat.ChatID, Action: action, UserID: userID, Actor: c.userID}
+	if err := checkMembership(tmpChat, m); err != nil {
+		return nil, nil, err
+	}
+	if action == MEMBERSHIP_JOIN {
+		if tmpChat.HasParticipant(userID) {
+			return nil, nil, fmt.Errorf("%s already participates in chat %s", userID, tmpChat.ChatID)
+		}
+	}
+
+	// the removed one is informed too
+	var recipients []string
+	for _, participant := range tmpChat.ClientsIPsList() {
+		if participant != c.userID {
+			recipients = append(recipients, participant)
+		}
+	}
+
+	m.Clock = tmpChat.Tick()
+	m.Signature = c.identity.Sign(signedMembership(m))
+	applyMembership(tmpChat, m)
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		return nil, nil, err
+	}
+
+	logger.WithFields(logger.Fields{
+		"chatID": tmpChat.ChatID,
+		"userID": userID,
+		"action": action,
+	}).Info("changeMembership: membership of chat changed")
+	return m, recipients, nil
+}
+
+// sendMembership sends change to given users
+func (c *Client) sendMembership(m *ChatMembership, recipients []string) {
+	for _, userID := range recipients {
+		if err := c.sendTo(userID, m); err != nil {
+			logger.WithError(err).WithField("userID", userID).Warn("sendMembership: change not sent")
+		}
+	}
+}
+
+// AddMember adds user to chat, informs other participants and adverts chat to the new one
+// only admins can add users
+func (c *Client) AddMember(chatID, userID string) error {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return err
+	}
+	m, recipients, err := c.changeMembership(tmpChat, MEMBERSHIP_JOIN, userID)
+	if err != nil {
+		return err
+	}
+	c.sendMembership(m, recipients)
+	c.connectParticipant(userID)
+	c.events.Publish(ChatEvent{Kind: CHAT_EVENT_JOINED, ChatID: chatID, UserID: userID})
+
+	// the new one asks for participants and key
+	return c.sendTo(userID, &ChatAdvert{ChatID: chatID, ChatName: tmpChat.ChatName()})
+}
+
+// LeaveChat removes user from chat and informs other participants, history of chat is kept
+func (c *Client) LeaveChat(chatID string) error {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return err
+	}
+	m, recipients, err := c.changeMembership(tmpChat, MEMBERSHIP_LEAVE, c.userID)
+	if err != nil {
+		return err
+	}
+	c.sendMembership(m, recipients)
+	return nil
+}
+
+// RemoveMember removes other user from chat, informs all participants including the removed one and rekeys chat
+// only admins can remove users
+func (c *Client) RemoveMember(chatID, userID string) error {
+	if userID == c.userID {
+		return c.LeaveChat(chatID)
+	}
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return err
+	}
+	if !tmpChat.HasParticipant(userID) {
+		return fmt.Errorf("%w: %s in chat %s", ErrNotParticipant, userID, chatID)
+	}
+	m, recipients, err := c.changeMembership(tmpChat, MEMBERSHIP_KICK, userID)
+	if err != nil {
+		return err
+	}
+	c.sendMembership(m, recipients)
+	return c.rekeyChat(tmpChat)
+}
+
+// SetAdmin makes participant of chat admin or member again, only admins can change roles
+func (c *Client) SetAdmin(chatID, userID string, admin bool) error {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return err
+	}
+	action := MEMBERSHIP_DEMOTE
+	if admin {
+		action = MEMBERSHIP_PROMOTE
+	}
+	m, recipients, err := c.changeMembership(tmpChat, action, userID)
+	if err != nil {
+		return err
+	}
+	c.sendMembership(m, recipients)
+	return nil
+}
+
+// SubscribeJoined returns channel with events of users joining chat and function ending subscription
+func (c *Client) SubscribeJoined(chatID string) (<-chan ChatEvent, func()) {
+	return c.events.Subscribe(CHAT_EVENT_JOINED, chatID)
+}
+
+// handleChatMembership applies change of participants signed by its actor
+func (c *Client) handleChatMembership(e *Envelope) error {
+	body, ok := e.Body.(*ChatMembership)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+	if err := c.verifyMembership(tmpChat, body); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	log := logger.WithFields(logger.Fields{"chatID": body.ChatID, "userID": body.UserID, "actor": body.Actor, "action": body.Action})
+	tmpChat.Witness(body.Clock)
+	// user which already participates does not join again
+	previous, applied := applyMembership(tmpChat, body)
+	if !applied {
+		log.Debug("handleChatMembership: outdated change ignored")
+		return nil
+	}
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		return err
+	}
+	log.Info("handleChatMembership: membership of chat changed")
+
+	switch body.Action {
+	case MEMBERSHIP_JOIN:
+		// address of the new user is looked up if unknown
+		c.connectParticipant(body.UserID)
+		c.events.Publish(ChatEvent{Kind: CHAT_EVENT_JOINED, ChatID: body.ChatID, UserID: body.UserID})
+
+	case MEMBERSHIP_LEAVE:
+		if previous == chat.ROLE_NONE {
+			break
+		}
+		rekeyer := lowestParticipant(tmpChat)
+		if rekeyer == c.userID {
+			return c.rekeyChat(tmpChat)
+		}
+		c.allowRekey(tmpChat, rekeyer)
+
+	case MEMBERSHIP_KICK:
+		if body.UserID == c.userID {
+			log.Warn("handleChatMembership: removed from chat")
+		} else if previous != chat.ROLE_NONE {
+			c.allowRekey(tmpChat, body.Actor)
+		}
+	}
+	return nil
+}
+
+// connectParticipant makes client connect to participant, its address is looked up if unknown
+func (c *Client) connectParticipant(userID string) {
+	if userID == c.userID {
+		return
+	}
+	addr, ok := c.AddressOf(userID)
+	if !ok {
+		logger.WithField("userID", userID).Info("connectParticipant: address of participant unknown, looking up")
+		c.lookUpParticipant(userID)
+		return
+	}
+	c.peers.Register(addr)
+}
+
+// lowestParticipant returns remaining participant with the lowest user ID,
+// which generates new key after other one left, empty without participants
+func lowestParticipant(tmpChat *chat.Chat) string {
+	participants := tmpChat.ClientsIPsList()
+	if len(participants) == 0 {
+		return ""
+	}
+	sort.Strings(participants)
+	return participants[0]
+}
+
+// allowRekey lets participant send key of the next epoch of chat after participants changed
+func (c *Client) allowRekey(tmpChat *chat.Chat, userID string) {
+	if userID == "" || userID == c.userID {
+		return
+	}
+	epoch, _, _ := tmpChat.CurrentKey()
+	c.rekeyers.allow(tmpChat.ChatID, userID, epoch+1)
+}
+
+// signCreation signs creation of chat owned by this client at given clock and keeps it with chat
+func (c *Client) signCreation(tmpChat *chat.Chat, clock int) store.MemberVersion {
+	m := &ChatMembership{ChatID: tmpChat.ChatID, Action: MEMBERSHIP_CREATE, UserID: c.userID, Actor: c.userID, Clock: clock}
+	creation := store.MemberVersion{Clock: clock, Actor: c.userID, Action: string(MEMBERSHIP_CREATE),
+		Signature: c.identity.Sign(signedMembership(m)), UserID: c.userID}
+	tmpChat.SetHistory(&creation, tmpChat.History())
+	return creation
+}
+
+// creationOf returns signed creation of chat, chats created before creation was signed
+// get it with clock 0 from their owner, nil if this client is not the owner
+func (c *Client) creationOf(tmpChat *chat.Chat) *store.MemberVersion {
+	if creation, ok := tmpChat.Creation(); ok {
+		return &creation
+	}
+	if tmpChat.Owner() != c.userID {
+		return nil
+	}
+	creation := c.signCreation(tmpChat, 0)
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		logger.WithError(err).WithField("chatID", tmpChat.ChatID).Error("creationOf: cannot save chat")
+	}
+	return &creation
+}
+
+// verifyCreation reports error if owner in response is not the one who signed creation of chat,
+// response without owner cannot carry changes of roles, otherwise anybody would be admin of chat
+// public keys from response have to be remembered before
+func (c *Client) verifyCreation(body *ChatParticipantsResponse) error {
+	if body.Owner == "" {
+		if body.Creation != nil || len(body.History) > 0 || len(body.Versions) > 0 || len(body.Admins) > 0 {
+			return fmt.Errorf("%w: changes of roles in chat %s without owner", ErrInvalidMessage, body.ChatID)
+		}
+		return nil
+	}
+	creation := body.Creation
+	if creation == nil || creation.Actor != body.Owner || creation.Action != string(MEMBERSHIP_CREATE) || creation.Clock < 0 {
+		return fmt.Errorf("%w: owner %s of chat %s did not sign its creation", ErrInvalidMessage, body.Owner, body.ChatID)
+	}
+	publicKey, err := c.publicKeyOf(body.Owner)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	m := &ChatMembership{ChatID: body.ChatID, Action: MEMBERSHIP_CREATE, UserID: body.Owner, Actor: body.Owner, Clock: creation.Clock}
+	if !identity.Verify(publicKey, signedMembership(m), creation.Signature) {
+		return fmt.Errorf("%w: invalid signature of creation of chat %s by %s", ErrInvalidMessage, body.ChatID, body.Owner)
+	}
+	return nil
+}
+
+// adoptRoles sets roles of chat joined by this client from changes received with its participants,
+// creation has to be verified by verifyCreation before
+// changes are replayed in order they were made on chat, which starts with owner as the only admin,
+// so each is checked against roles at the time it was made,
+// users whose first change is joining participate only after it, the others are initial participants,
+// changes which cannot be verified are skipped, so participant which answered cannot make anyone admin
+func (c *Client) adoptRoles(tmpChat *chat.Chat, body *ChatParticipantsResponse) {
+	if body.Owner == "" {
+		tmpChat.SetRoles("", nil, nil)
+		tmpChat.SetHistory(nil, nil)
+		return
+	}
+
+	changes := make([]*ChatMembership, 0, len(body.History))
+	for _, version := range body.History {
+		if version.Clock <= body.Creation.Clock {
+			logger.WithFields(logger.Fields{"chatID": body.ChatID, "userID": version.UserID}).Warn("adoptRoles: change before creation of chat skipped")
+			continue
+		}
+		changes = append(changes, &ChatMembership{ChatID: body.ChatID, Action: MembershipAction(version.Action), UserID: version.UserID,
+			Actor: version.Actor, Clock: version.Clock, Signature: version.Signature})
+	}
+	// the same order as of MemberVersion.Newer
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Clock != changes[j].Clock {
+			return changes[i].Clock < changes[j].Clock
+		}
+		return changes[i].Actor < changes[j].Actor
+	})
+
+	initial := []string{body.Owner}
+	seen := map[string]bool{body.Owner: true}
+	for _, m := range changes {
+		if !seen[m.UserID] && m.Action != MEMBERSHIP_JOIN {
+			initial = append(initial, m.UserID)
+		}
+		seen[m.UserID] = true
+	}
+	for _, userID := range body.Participants {
+		if !seen[userID] {
+			initial = append(initial, userID)
+			seen[userID] = true
+		}
+	}
+	replayed := chat.NewChat(body.ChatID, initial, nil)
+	replayed.SetRoles(body.Owner, []string{body.Owner}, nil)
+
+	for _, m := range changes {
+		err := m.Validate()
+		if err == nil {
+			err = c.verifyMembership(replayed, m)
+		}
+		if err != nil {
+			logger.WithError(err).WithFields(logger.Fields{"chatID": m.ChatID, "userID": m.UserID}).Warn("adoptRoles: change of role skipped")
+			continue
+		}
+		tmpChat.Witness(m.Clock)
+		applyMembership(replayed, m)
+	}
+	tmpChat.SetRoles(body.Owner, replayed.Admins(), replayed.Versions())
+	tmpChat.SetHistory(body.Creation, replayed.History())
+}
+
+// rememberKeys saves public keys of actors of changes received with participants of chat
+// key is saved only if user ID is derived from it, so nobody passes its own key as key of other user
+func (c *Client) rememberKeys(publicKeys map[string][]byte) {
+	for userID, publicKey := range publicKeys {
+		if userID == c.userID || len(publicKey) != ed25519.PublicKeySize || identity.UserIDFromPublicKey(publicKey) != userID {
+			logger.WithField("userID", userID).Warn("rememberKeys: public key of other user ignored")
+			continue
+		}
+		if err := c.store.SetPublicKey(userID, publicKey); err != nil {
+			logger.WithError(err).WithField("userID", userID).Error("rememberKeys: cannot save public key")
+		}
+	}
+}
+
+// actorKeys returns known public keys of actors of changes, nil if there are none
+func (c *Client) actorKeys(changes []store.MemberVersion) map[string][]byte {
+	var publicKeys map[string][]byte
+	for _, version := range changes {
+		publicKey, err := c.publicKeyOf(version.Actor)
+		if err != nil {
+			continue
+		}
+		if publicKeys == nil {
+			publicKeys = make(map[string][]byte)
+		}
+		publicKeys[version.Actor] = publicKey
+	}
+	return publicKeys
+}
+
+// participantsRequests tracks CHAT_PARTICIPANTS_REQUEST sent by client, zero value is ready to use
+// only answers to them are accepted, so nobody pushes chat, its participants or key on client
+type participantsRequests struct {
+	mutex   sync.Mutex
+	pending map[string]time.Time // chatID/userID : when request was sent
+}
+
+// add records request sent to user, requests without answer for PARTICIPANTS_REQUEST_TIMEOUT are forgotten
+func (r *participantsRequests) add(chatID, userID string, now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.pending == nil {
+		r.pending = make(map[string]time.Time)
+	}
+	for key, sent := range r.pending {
+		if now.Sub(sent) >= PARTICIPANTS_REQUEST_TIMEOUT {
+			delete(r.pending, key)
+		}
+	}
+	r.pending[chatID+"/"+userID] = now
+}
+
+// take reports if answer of user is expected and forgets request
+func (r *participantsRequests) take(chatID, userID string, now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := chatID + "/" + userID
+	sent, ok := r.pending[key]
+	delete(r.pending, key)
+	return ok && now.Sub(sent) < PARTICIPANTS_REQUEST_TIMEOUT
+}
@@ -0,0 +1,398 @@
+package client
+
+import (
+	"errors"
+	"main/chat"
+	"main/gql"
+	"main/store"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// participantsOf returns sorted participants of chat 123 of client
+func participantsOf(c *Client) []string {
+	participants := c.chatList["123"].ClientsIPsList()
+	sort.Strings(participants)
+	return participants
+}
+
+func sortedIDs(ids ...string) []string {
+	sort.Strings(ids)
+	return ids
+}
+
+// newRolesTestChats gives clients chat 123 owned by owner with all of them participating
+func newRolesTestChats(owner *Client, clients ...*Client) {
+	participants := []string{owner.userID}
+	for _, c := range clients {
+		participants = append(participants, c.userID)
+	}
+	var creation store.MemberVersion
+	for _, c := range append([]*Client{owner}, clients...) {
+		tmpChat := chat.NewChat("123", participants, c.store)
+		tmpChat.AddKey(1, testChatKey)
+		tmpChat.SetRoles(owner.userID, []string{owner.userID}, nil)
+		if c == owner {
+			creation = owner.signCreation(tmpChat, tmpChat.Tick())
+		}
+		tmpChat.SetHistory(&creation, nil)
+		c.chatList["123"] = tmpChat
+	}
+}
+
+func TestClient_membership(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, a, c)
+	link(t, b, c)
+	newRolesTestChats(a, b)
+	joined, cancel := b.SubscribeJoined("123")
+	defer cancel()
+
+	// only admins add users
+	if err := b.AddMember("123", c.userID); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("AddMember() by member error = %v, want %v", err, ErrNotPermitted)
+	}
+
+	// a adds c, b learns about it, c gets chat with its key and roles
+	if err := a.AddMember("123", c.userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddMember("123", c.userID); err == nil {
+		t.Error("user added twice")
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-joined:
+		if e.UserID != c.userID {
+			t.Errorf("joined user = %s, want %s", e.UserID, c.userID)
+		}
+	case <-time.After(time.Second):
+		t.Error("joined user not published")
+	}
+	for _, step := range [][2]*Client{{a, c}, {c, a}, {a, c}} {
+		if err := deliver(t, step[0], step[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := sortedIDs(a.userID, b.userID, c.userID)
+	for _, client := range []*Client{a, b, c} {
+		if got := participantsOf(client); !reflect.DeepEqual(got, want) {
+			t.Errorf("participants of %s = %v, want %v", client.userID, got, want)
+		}
+	}
+	if _, key, err := c.chatList["123"].CurrentKey(); err != nil || string(key) != string(testChatKey) {
+		t.Errorf("key of joined user = %x, %v, want current key", key, err)
+	}
+	if cChat := c.chatList["123"]; cChat.Owner() != a.userID || !cChat.IsAdmin(a.userID) || cChat.IsAdmin(b.userID) {
+		t.Errorf("roles of joined user: owner %s, admins %v, want owner %s", cChat.Owner(), cChat.Admins(), a.userID)
+	}
+	if record, err := b.store.Chat("123"); err != nil || len(record.Participants) != 3 {
+		t.Errorf("saved participants = %v, %v, want 3", record.Participants, err)
+	}
+
+	// a makes b admin, b removes c, a gets new key, c cannot post anymore
+	if err := a.SetAdmin("123", b.userID, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range [][2]*Client{{a, b}, {a, c}} {
+		if err := deliver(t, step[0], step[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.RemoveMember("123", a.userID); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("RemoveMember() of owner error = %v, want %v", err, ErrNotPermitted)
+	}
+	if err := b.RemoveMember("123", c.userID); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range [][2]*Client{{b, a}, {b, a}, {b, c}} {
+		if err := deliver(t, step[0], step[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want = sortedIDs(a.userID, b.userID)
+	for _, client := range []*Client{a, b, c} {
+		if got := participantsOf(client); !reflect.DeepEqual(got, want) {
+			t.Errorf("participants of %s after removal = %v, want %v", client.userID, got, want)
+		}
+	}
+	aEpoch, aKey, _ := a.chatList["123"].CurrentKey()
+	bEpoch, bKey, _ := b.chatList["123"].CurrentKey()
+	if aEpoch != 2 || bEpoch != 2 || string(aKey) != string(bKey) {
+		t.Errorf("keys after removal: %d and %d, want the same key of epoch 2", aEpoch, bEpoch)
+	}
+	if err := c.LeaveChat("123"); !errors.Is(err, ErrNotParticipant) {
+		t.Errorf("LeaveChat() of removed user error = %v, want %v", err, ErrNotParticipant)
+	}
+	message := gql.TextMessage{MessageID: "1", ChatID: "123", User: c.userID, TimeStamp: time.Now().UTC(), Text: "still here"}
+	c.signMessage(&message)
+	encrypted, err := c.encryptMessage(c.chatList["123"], message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.dispatcher.DispatchEnvelope(NewEnvelope(c.userID, encrypted)); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("message of removed user error = %v, want %v", err, ErrInvalidMessage)
+	}
+
+	// a leaves, b rekeys as the last one
+	if err := a.LeaveChat("123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if got := participantsOf(b); !reflect.DeepEqual(got, []string{b.userID}) {
+		t.Errorf("participants after leave = %v, want [%s]", got, b.userID)
+	}
+	if epoch, _, _ := b.chatList["123"].CurrentKey(); epoch != 3 {
+		t.Errorf("key epoch after leave = %d, want 3", epoch)
+	}
+	if a.chatList["123"].HasParticipant(a.userID) {
+		t.Error("left chat still participated")
+	}
+}
+
+func TestClient_handleChatMembership_invalid(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, a, c)
+	newRolesTestChats(a, b, c)
+
+	// signed is signed change of participant, as if made by actor
+	signed := func(actor *Client, action MembershipAction, userID string, clock int) *ChatMembership {
+		m := &ChatMembership{ChatID: "123", Action: action, UserID: userID, Actor: actor.userID, Clock: clock}
+		m.Signature = actor.identity.Sign(signedMembership(m))
+		return m
+	}
+	forged := signed(b, MEMBERSHIP_KICK, c.userID, 5)
+	forged.Actor = a.userID
+	replayed := signed(a, MEMBERSHIP_JOIN, "d", 1)
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, signed(a, MEMBERSHIP_KICK, "d", 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source *Client
+		body   *ChatMembership
+	}{
+		{"member adds user", c, signed(c, MEMBERSHIP_JOIN, "d", 3)},
+		{"member removes user", c, signed(c, MEMBERSHIP_KICK, a.userID, 3)},
+		{"leave for other user", c, signed(c, MEMBERSHIP_LEAVE, a.userID, 3)},
+		{"signature of other actor", c, forged},
+		{"unknown chat", a, &ChatMembership{ChatID: "456", Action: MEMBERSHIP_JOIN, UserID: "d", Actor: a.userID, Signature: []byte{1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := b.dispatcher.DispatchEnvelope(NewEnvelope(tt.source.userID, tt.body)); !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidMessage)
+			}
+		})
+	}
+
+	// changes older than applied one are ignored
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(c.userID, replayed)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := participantsOf(b), sortedIDs(a.userID, b.userID, c.userID); !reflect.DeepEqual(got, want) {
+		t.Errorf("participants changed by invalid messages: %v, want %v", got, want)
+	}
+}
+
+func TestClient_joinParticipant(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, a, c)
+	newRolesTestChats(a, b, c)
+
+	if err := a.SetAdmin("123", b.userID, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, to := range []*Client{b, c} {
+		if err := deliver(t, a, to); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// admin joining again stays admin
+	join := &ChatMembership{ChatID: "123", Action: MEMBERSHIP_JOIN, UserID: b.userID, Actor: a.userID, Clock: 10}
+	join.Signature = a.identity.Sign(signedMembership(join))
+	if err := c.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, join)); err != nil {
+		t.Fatal(err)
+	}
+	if !c.chatList["123"].IsAdmin(b.userID) {
+		t.Error("admin demoted by joining chat again")
+	}
+}
+
+func TestClient_participantsResponse(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, b, c)
+	newRolesTestChats(a, b)
+	if err := a.ChangeChatName("123", "team"); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// member makes itself admin and owner of chat
+	promote := &ChatMembership{ChatID: "123", Action: MEMBERSHIP_PROMOTE, UserID: b.userID, Actor: b.userID, Clock: 10}
+	promote.Signature = b.identity.Sign(signedMembership(promote))
+	forged := &ChatParticipantsResponse{
+		ChatID:       "123",
+		Participants: []string{a.userID, b.userID, c.userID},
+		Owner:        a.userID,
+		Admins:       []string{a.userID, b.userID},
+		Creation:     b.creationOf(b.chatList["123"]),
+		History: []store.MemberVersion{
+			{Clock: promote.Clock, Actor: b.userID, Action: string(MEMBERSHIP_PROMOTE), Signature: promote.Signature, UserID: b.userID},
+			{Clock: 11, Actor: a.userID, Action: string(MEMBERSHIP_PROMOTE), UserID: c.userID},
+		},
+		ChatName:         "team",
+		MetadataVersions: b.chatList["123"].MetadataVersions(),
+		PublicKeys:       map[string][]byte{a.userID: a.identity.PublicKey, "other": b.identity.PublicKey},
+	}
+
+	// participants are accepted only from participant which was asked
+	if err := c.dispatcher.DispatchEnvelope(NewEnvelope(b.userID, forged)); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("unsolicited response error = %v, want %v", err, ErrInvalidMessage)
+	}
+	if _, err := c.GetChat("123"); err == nil {
+		t.Error("chat created by unsolicited response")
+	}
+
+	if err := c.dispatcher.DispatchEnvelope(NewEnvelope(b.userID, &ChatAdvert{ChatID: "123"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.dispatcher.DispatchEnvelope(NewEnvelope(b.userID, forged)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.dispatcher.DispatchEnvelope(NewEnvelope(b.userID, forged)); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("repeated response error = %v, want %v", err, ErrInvalidMessage)
+	}
+
+	// only signed changes of admins are adopted
+	cChat := c.chatList["123"]
+	if cChat.Owner() != a.userID || !reflect.DeepEqual(cChat.Admins(), []string{a.userID}) {
+		t.Errorf("roles of joined user: owner %s, admins %v, want only %s", cChat.Owner(), cChat.Admins(), a.userID)
+	}
+	if cChat.ChatName() != "team" {
+		t.Errorf("name of joined chat = %q, want name signed by admin", cChat.ChatName())
+	}
+	if _, err := c.store.PublicKey("other"); err == nil {
+		t.Error("key of other user saved")
+	}
+}
+
+func TestClient_participantsResponseOwner(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, b, c)
+	newRolesTestChats(a, b)
+	creation := b.creationOf(b.chatList["123"])
+
+	// responder names itself owner, creation signed by real owner or by nobody
+	selfPromote := &ChatMembership{ChatID: "123", Action: MEMBERSHIP_PROMOTE, UserID: c.userID, Actor: b.userID, Clock: 10}
+	selfPromote.Signature = b.identity.Sign(signedMembership(selfPromote))
+	history := []store.MemberVersion{{Clock: 10, Actor: b.userID, Action: string(MEMBERSHIP_PROMOTE), Signature: selfPromote.Signature, UserID: c.userID}}
+	forged := []*ChatParticipantsResponse{
+		{ChatID: "123", Participants: []string{a.userID, b.userID, c.userID}, Owner: b.userID, Creation: creation, History: history},
+		{ChatID: "123", Participants: []string{a.userID, b.userID, c.userID}, Owner: b.userID, History: history},
+		// without owner every participant would be admin
+		{ChatID: "123", Participants: []string{a.userID, b.userID, c.userID}, History: history},
+	}
+	for i, response := range forged {
+		if err := c.dispatcher.DispatchEnvelope(NewEnvelope(b.userID, &ChatAdvert{ChatID: "123"})); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.dispatcher.DispatchEnvelope(NewEnvelope(b.userID, response)); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("forged response %d error = %v, want %v", i, err, ErrInvalidMessage)
+		}
+	}
+	if _, err := c.GetChat("123"); err == nil {
+		t.Error("chat created by forged response")
+	}
+}
+
+func TestClient_adoptRoles(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	d := newTestClient(t)
+	c := newTestClient(t)
+	for _, pair := range [][2]*Client{{a, b}, {a, d}, {b, d}, {d, c}} {
+		link(t, pair[0], pair[1])
+	}
+	newRolesTestChats(a, b, d)
+
+	// admin promotes other participant and leaves
+	if err := a.SetAdmin("123", b.userID, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, to := range []*Client{b, d} {
+		if err := deliver(t, a, to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.SetAdmin("123", d.userID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, b, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, b, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.LeaveChat("123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, b, d); err != nil {
+		t.Fatal(err)
+	}
+
+	// the new user replays promotion made by admin which is gone
+	if err := d.AddMember("123", c.userID); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		e := <-outboxOf(t, d, c.userIP)
+		if err := c.dispatcher.DispatchEnvelope(e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Type == CHAT_ADVERT {
+			break
+		}
+	}
+	for _, step := range [][2]*Client{{c, d}, {d, c}} {
+		if err := deliver(t, step[0], step[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cChat, err := c.GetChat("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cChat.Owner() != a.userID || !reflect.DeepEqual(cChat.Admins(), sortedIDs(a.userID, d.userID)) {
+		t.Errorf("roles of joined user: owner %s, admins %v, want %s and %s", cChat.Owner(), cChat.Admins(), a.userID, d.userID)
+	}
+	if !cChat.IsAdmin(d.userID) || cChat.IsAdmin(b.userID) {
+		t.Errorf("roles of joined user: d admin %v, b admin %v, want only d", cChat.IsAdmin(d.userID), cChat.IsAdmin(b.userID))
+	}
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/chat"
+	"main/identity"
+	"main/store"
+	"strings"
+)
+
+// metadata of chats:
+// admin renames chat or changes its avatar with CHAT_METADATA {chatID, field, value} sent to all other participants,
+// change is stamped with clock of chat and signed by its actor like changes of membership (see Membership.go),
+// participants check the signature and that actor is admin, changes older than already applied one are ignored
+// users joining chat later get current metadata with participants of chat together with signed changes
+// which set it, they verify them like changes of roles (see adoptRoles)
+// changed fields are published as CHAT_EVENT_METADATA
+
+// signed together with change, so signature cannot be reused in other context
+const METADATA_SIGNATURE_CONTEXT = "arxen-metadata-v1"
+
+// ErrEmptyChatName is returned when chat is renamed to blank name
+var ErrEmptyChatName = errors.New("chat name is required")
+
+// signedMetadata returns bytes signed by actor of change
+func signedMetadata(m *ChatMetadata) []byte {
+	content := []byte(METADATA_SIGNATURE_CONTEXT)
+	for _, field := range []string{m.ChatID, string(m.Field), m.Value, m.Actor} {
+		content = appendField(content, []byte(field))
+	}
+	var clock [8]byte
+	binary.BigEndian.PutUint64(clock[:], uint64(m.Clock))
+	return appendField(content, clock[:])
+}
+
+// checkMetadata reports error if actor is not allowed to change metadata of chat
+func checkMetadata(tmpChat *chat.Chat, actor string) error {
+	if !tmpChat.HasParticipant(actor) {
+		return fmt.Errorf("%w: %s in chat %s", ErrNotParticipant, actor, tmpChat.ChatID)
+	}
+	if !tmpChat.IsAdmin(actor) {
+		return fmt.Errorf("%w: %s is not admin of chat %s", ErrNotPermitted, actor, tmpChat.ChatID)
+	}
+	return nil
+}
+
+// verifyMetadata reports error if change is not signed by its actor or actor is not admin
+func (c *Client) verifyMetadata(tmpChat *chat.Chat, m *ChatMetadata) error {
+	publicKey, err := c.publicKeyOf(m.Actor)
+	if err != nil {
+		return err
+	}
+	if !identity.Verify(publicKey, signedMetadata(m), m.Signature) {
+		return fmt.Errorf("invalid signature of metadata change by %s", m.Actor)
+	}
+	return checkMetadata(tmpChat, m.Actor)
+}
+
+// applyMetadata applies verified change, returns false if it was outdated
+func applyMetadata(tmpChat *chat.Chat, m *ChatMetadata) bool {
+	return tmpChat.ApplyMetadata(m.Field, m.Value, store.MemberVersion{Clock: m.Clock, Actor: m.Actor, Signature: m.Signature})
+}
+
+// adoptMetadata sets name and avatar of chat joined by this client from changes received with its participants,
+// fields set by changes which cannot be verified stay empty
+func (c *Client) adoptMetadata(tmpChat *chat.Chat, body *ChatParticipantsResponse) {
+	tmpChat.SetMetadata("", "", nil)
+	values := map[chat.MetadataField]string{chat.METADATA_NAME: body.ChatName, chat.METADATA_AVATAR: body.ChatAvatar}
+	for field, value := range values {
+		version, ok := body.MetadataVersions[string(field)]
+		if !ok {
+			continue
+		}
+		m := &ChatMetadata{ChatID: body.ChatID, Field: field, Value: value, Actor: version.Actor, Clock: version.Clock, Signature: version.Signature}
+		err := m.Validate()
+		if err == nil {
+			err = c.verifyMetadata(tmpChat, m)
+		}
+		if err != nil {
+			logger.WithError(err).WithFields(logger.Fields{"chatID": m.ChatID, "field": field}).Warn("adoptMetadata: change skipped")
+			continue
+		}
+		tmpChat.Witness(m.Clock)
+		applyMetadata(tmpChat, m)
+	}
+}
+
+// ChangeChatName renames chat and informs other participants, only admins can rename chat
+func (c *Client) ChangeChatName(chatID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrEmptyChatName
+	}
+	return c.changeMetadata(chatID, chat.METADATA_NAME, name)
+}
+
+// ChangeChatAvatar sets address of avatar of chat and informs other participants, only admins can change it
+// empty address removes avatar
+func (c *Client) ChangeChatAvatar(chatID, avatarAddr string) error {
+	return c.changeMetadata(chatID, chat.METADATA_AVATAR, strings.TrimSpace(avatarAddr))
+}
+
+// changeMetadata signs change of field made by this client, applies it and sends it to other participants
+func (c *Client) changeMetadata(chatID string, field chat.MetadataField, value string) error {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return err
+	}
+	if err := checkMetadata(tmpChat, c.userID); err != nil {
+		return err
+	}
+
+	m := &ChatMetadata{ChatID: chatID, Field: field, Value: value, Actor: c.userID, Clock: tmpChat.Tick()}
+	m.Signature = c.identity.Sign(signedMetadata(m))
+	// the same checks as participants do
+	if err := m.Validate(); err != nil {
+		return fmt.Errorf("%s of chat: %v", field, err)
+	}
+	applyMetadata(tmpChat, m)
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		return err
+	}
+	c.events.Publish(ChatEvent{Kind: CHAT_EVENT_METADATA, ChatID: chatID, Field: field})
+
+	logger.WithFields(logger.Fields{
+		"chatID": chatID,
+		"field":  field,
+	}).Info("changeMetadata: metadata of chat changed")
+
+	for _, userID := range tmpChat.ClientsIPsList() {
+		if userID == c.userID {
+			continue
+		}
+		if err := c.sendTo(userID, m); err != nil {
+			logger.WithError(err).WithField("userID", userID).Warn("changeMetadata: change not sent")
+		}
+	}
+	return nil
+}
+
+// SubscribeChatChanged returns channel with events of metadata changed in chat and function ending subscription
+func (c *Client) SubscribeChatChanged(chatID string) (<-chan ChatEvent, func()) {
+	return c.events.Subscribe(CHAT_EVENT_METADATA, chatID)
+}
+
+// handleChatMetadata applies change of name or avatar of chat signed by its actor
+func (c *Client) handleChatMetadata(e *Envelope) error {
+	body, ok := e.Body.(*ChatMetadata)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+	// dispatcher validates too, handler does not rely on it
+	if err := body.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+	if err := c.verifyMetadata(tmpChat, body); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	log := logger.WithFields(logger.Fields{"chatID": body.ChatID, "actor": body.Actor, "field": body.Field})
+	tmpChat.Witness(body.Clock)
+	if !applyMetadata(tmpChat, body) {
+		log.Debug("handleChatMetadata: outdated change ignored")
+		return nil
+	}
+	if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+		return err
+	}
+	c.events.Publish(ChatEvent{Kind: CHAT_EVENT_METADATA, ChatID: body.ChatID, Field: body.Field})
+	log.Info("handleChatMetadata: metadata of chat changed")
+	return nil
+}
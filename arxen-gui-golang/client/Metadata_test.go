@@ -0,0 +1,125 @@
+package client
+
+import (
+	"errors"
+	"main/chat"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_changeMetadata(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, a, c)
+	newRolesTestChats(a, b)
+	changed, cancel := b.SubscribeChatChanged("123")
+	defer cancel()
+
+	// only admins rename chat
+	if err := b.ChangeChatName("123", "mine"); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("ChangeChatName() by member error = %v, want %v", err, ErrNotPermitted)
+	}
+	if err := a.ChangeChatName("123", "  "); !errors.Is(err, ErrEmptyChatName) {
+		t.Errorf("ChangeChatName() to blank name error = %v, want %v", err, ErrEmptyChatName)
+	}
+
+	if err := a.ChangeChatName("123", " team "); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ChangeChatAvatar("123", "/static/team.png"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := deliver(t, a, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []chat.MetadataField{chat.METADATA_NAME, chat.METADATA_AVATAR} {
+		select {
+		case e := <-changed:
+			if e.Field != want {
+				t.Errorf("changed field = %s, want %s", e.Field, want)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("change of %s not published", want)
+		}
+	}
+	if bChat := b.chatList["123"]; bChat.ChatName() != "team" || bChat.ChatAvatar() != "/static/team.png" {
+		t.Errorf("metadata of participant = %q, %q, want team and its avatar", bChat.ChatName(), bChat.ChatAvatar())
+	}
+	if record, err := b.store.Chat("123"); err != nil || record.ChatName != "team" {
+		t.Errorf("saved name = %q, %v, want team", record.ChatName, err)
+	}
+
+	// user added later gets current metadata with participants
+	if err := a.AddMember("123", c.userID); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range [][2]*Client{{a, b}, {a, c}, {c, a}, {a, c}} {
+		if err := deliver(t, step[0], step[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cChat := c.chatList["123"]; cChat.ChatName() != "team" || cChat.ChatAvatar() != "/static/team.png" {
+		t.Errorf("metadata of joined user = %q, %q, want team and its avatar", cChat.ChatName(), cChat.ChatAvatar())
+	}
+}
+
+func TestClient_handleChatMetadata_invalid(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	c := newTestClient(t)
+	link(t, a, b)
+	link(t, a, c)
+	newRolesTestChats(a, b, c)
+
+	// signed is signed rename, as if made by actor
+	signed := func(actor *Client, name string, clock int) *ChatMetadata {
+		m := &ChatMetadata{ChatID: "123", Field: chat.METADATA_NAME, Value: name, Actor: actor.userID, Clock: clock}
+		m.Signature = actor.identity.Sign(signedMetadata(m))
+		return m
+	}
+	forged := signed(a, "forged", 5)
+	forged.Value = "changed"
+	replayed := signed(a, "old", 1)
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, signed(a, "team", 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source *Client
+		body   *ChatMetadata
+	}{
+		{"member renames chat", c, signed(c, "mine", 3)},
+		{"changed value", c, forged},
+		{"unknown chat", a, &ChatMetadata{ChatID: "456", Field: chat.METADATA_NAME, Value: "x", Actor: a.userID, Signature: []byte{1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := b.dispatcher.DispatchEnvelope(NewEnvelope(tt.source.userID, tt.body)); !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidMessage)
+			}
+		})
+	}
+
+	// handler checks length of value without dispatcher
+	long := signed(a, strings.Repeat("x", chat.MAX_METADATA_LENGTH+1), 4)
+	if err := b.handleChatMetadata(NewEnvelope(a.userID, long)); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("too long name error = %v, want %v", err, ErrInvalidMessage)
+	}
+	if err := a.ChangeChatAvatar("123", strings.Repeat("x", chat.MAX_METADATA_LENGTH+1)); err == nil {
+		t.Error("ChangeChatAvatar() accepted too long address")
+	}
+
+	// changes older than applied one are ignored
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(c.userID, replayed)); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.chatList["123"].ChatName(); name != "team" {
+		t.Errorf("name changed by invalid messages: %q, want team", name)
+	}
+}
@@ -0,0 +1,235 @@
+package client
+
+import (
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"log"
+	"main/store"
+	"time"
+)
+
+// newDispatcher returns Dispatcher with handlers of all message types supported by client
+func (c *Client) newDispatcher() *Dispatcher {
+	d := NewDispatcher()
+	d.Register(CHAT_MESSAGE, c.handleChatMessage)
+	d.Register(CHAT_PARTICIPANTS_REQUEST, c.handleChatParticipantsRequest)
+	d.Register(CHAT_PARTICIPANTS_RESPONSE, c.handleChatParticipantsResponse)
+	d.Register(CHAT_ADVERT_REQUEST, c.handleChatAdvertRequest)
+	d.Register(CHAT_ADVERT, c.handleChatAdvert)
+	d.Register(HISTORY_SYNC_REQUEST, c.handleHistorySyncRequest)
+	d.Register(HISTORY_SYNC_RESPONSE, c.handleHistorySyncResponse)
+	d.Register(CHAT_KEY, c.handleChatKey)
+	d.Register(CHAT_KEY_REQUEST, c.handleChatKeyRequest)
+	d.Register(MESSAGE_RECEIPT, c.handleMessageReceipt)
+	d.Register(CHAT_MEMBERSHIP, c.handleChatMembership)
+	d.Register(CHAT_METADATA, c.handleChatMetadata)
+	d.Register(FRIENDSHIP, c.handleFriendship)
+	d.Register(PRESENCE, c.handlePresence)
+	d.Register(TYPING, c.handleTyping)
+	return d
+}
+
+// handleChatMessage appends incoming message to its chat and acknowledges it to author
+func (c *Client) handleChatMessage(e *Envelope) error {
+	body, ok := e.Body.(*ChatMessage)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	// removed users cannot post, they still have old keys
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+
+	tmpTextMessage, err := c.decryptMessage(tmpChat, e.Source, body)
+	if err != nil {
+		return err
+	}
+	// sender is checked before dispatching, author of passed message is not
+	if c.IsBlocked(tmpTextMessage.User) {
+		return fmt.Errorf("%w: message of %s", ErrBlocked, tmpTextMessage.User)
+	}
+
+	// save and send to appropriate chat, messages already known are ignored
+	added, err := tmpChat.AddMessage(&tmpTextMessage)
+	if err != nil {
+		return err
+	}
+	// duplicates too, author sends message again when receipt does not come
+	c.sendReceipt(tmpTextMessage.User, tmpChat.ChatID, []string{tmpTextMessage.MessageID}, store.RECEIPT_DELIVERED)
+	if !added {
+		logger.WithField("messageID", tmpTextMessage.MessageID).Debug("handleChatMessage: duplicated message ignored")
+		return nil
+	}
+	c.stopTyping(tmpChat.ChatID, e.Source)
+	c.publishLastMessage(tmpChat.ChatID)
+	tmpChat.PublishMessage(&tmpTextMessage)
+	logger.Trace("handleChatMessage: After CHAN")
+
+	return nil
+}
+
+// handleChatParticipantsRequest sends user IDs and known addresses of all participants to requester
+func (c *Client) handleChatParticipantsRequest(e *Envelope) error {
+	body, ok := e.Body.(*ChatParticipantsRequest)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	log.Println("handleChatParticipantsRequest: got new CHAT_PARTICIPANTS_REQUEST")
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	// response carries chat key
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+
+	sealedKey, err := c.sealChatKey(tmpChat, e.Source)
+	if err != nil {
+		logger.WithError(err).WithField("userID", e.Source).Warn("handleChatParticipantsRequest: chat key not sent")
+	}
+
+	addresses := make(map[string]string)
+	for _, userID := range tmpChat.ClientsIPsList() {
+		if addr, ok := c.AddressOf(userID); ok {
+			addresses[userID] = addr
+		}
+	}
+
+	log.Println("handleChatParticipantsRequest: sending chat CHAT_PARTICIPANTS_RESPONSE")
+	// requester verifies signed changes of roles and metadata with keys of their actors
+	creation, history, metadataVersions := c.creationOf(tmpChat), tmpChat.History(), tmpChat.MetadataVersions()
+	changes := append([]store.MemberVersion(nil), history...)
+	if creation != nil {
+		changes = append(changes, *creation)
+	}
+	for _, version := range metadataVersions {
+		changes = append(changes, version)
+	}
+	return c.sendTo(e.Source, &ChatParticipantsResponse{
+		ChatID:       body.ChatID,
+		Participants: tmpChat.ClientsIPsList(),
+		Addresses:    addresses,
+		Key:          sealedKey,
+		Owner:        tmpChat.Owner(),
+		Admins:       tmpChat.Admins(),
+		Versions:     tmpChat.Versions(),
+		ChatName:     tmpChat.ChatName(),
+		ChatAvatar:   tmpChat.ChatAvatar(),
+		Creation:     creation,
+		History:      history,
+
+		MetadataVersions: metadataVersions,
+		PublicKeys:       c.actorKeys(changes),
+	})
+}
+
+// handleChatParticipantsResponse creates chat client was adverted, only participant which was asked is answered
+func (c *Client) handleChatParticipantsResponse(e *Envelope) error {
+	body, ok := e.Body.(*ChatParticipantsResponse)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+	if !c.participantsAsked.take(body.ChatID, e.Source, time.Now()) {
+		return fmt.Errorf("%w: %s sent participants of chat %s without request", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+
+	// addresses are not signed, they are only hints for users without known address
+	for userID, addr := range body.Addresses {
+		c.addressHint(userID, addr)
+	}
+
+	// owner is accepted only with its signature
+	c.rememberKeys(body.PublicKeys)
+	if err := c.verifyCreation(body); err != nil {
+		return err
+	}
+
+	log.Println("handleChatParticipantsResponse: beginning creation of new chat")
+	created := c.createSlaveChat(body.Participants, body.ChatID)
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return err
+	}
+	// chat left before, client was added again by one of its participants
+	rejoined := !tmpChat.HasParticipant(c.userID) && tmpChat.HasParticipant(e.Source) && contains(body.Participants, c.userID)
+	if rejoined {
+		logger.WithField("chatID", body.ChatID).Info("handleChatParticipantsResponse: joined chat again")
+		tmpChat.SetParticipants(body.Participants)
+	}
+	// roles and metadata are taken only from signed changes
+	if created || rejoined {
+		c.adoptRoles(tmpChat, body)
+		c.adoptMetadata(tmpChat, body)
+		if err := c.store.SaveChat(tmpChat.Record()); err != nil {
+			return err
+		}
+	}
+
+	if body.Key == nil {
+		logger.WithField("chatID", body.ChatID).Warn("handleChatParticipantsResponse: no chat key received")
+		return nil
+	}
+	if err := c.openChatKey(tmpChat, e.Source, body.Key, true); err != nil {
+		return err
+	}
+	// history of chat joined later is sent encrypted with current key
+	return c.sendTo(e.Source, &HistorySyncRequest{ChatID: body.ChatID})
+}
+
+// contains reports if list contains userID
+func contains(list []string, userID string) bool {
+	for _, item := range list {
+		if item == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleChatAdvertRequest adverts own chat to all its participants
+func (c *Client) handleChatAdvertRequest(e *Envelope) error {
+	body, ok := e.Body.(*ChatAdvertRequest)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	// phantom request, accepted only from oneself
+	if e.Source != c.userID {
+		return fmt.Errorf("%w: %s accepted only from oneself", ErrInvalidMessage, CHAT_ADVERT_REQUEST)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	for _, userID := range tmpChat.ClientsIPsList() {
+		if userID != c.userID {
+			// advert waits until participant is connected
+			if err := c.sendTo(userID, &ChatAdvert{ChatID: tmpChat.ChatID, ChatName: tmpChat.ChatName()}); err != nil {
+				logger.WithError(err).Warn("handleChatAdvertRequest: advert not sent")
+			}
+		}
+	}
+	return nil
+}
+
+// handleChatAdvert asks adverting client for all participants of chat
+func (c *Client) handleChatAdvert(e *Envelope) error {
+	body, ok := e.Body.(*ChatAdvert)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	log.Println("handleChatAdvert: asking by CHAT_PARTICIPANTS_REQUEST")
+	c.participantsAsked.add(body.ChatID, e.Source, time.Now())
+	return c.sendTo(e.Source, &ChatParticipantsRequest{ChatID: body.ChatID})
+}
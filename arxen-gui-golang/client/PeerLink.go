@@ -0,0 +1,89 @@
+package client
+
+import (
+	"github.com/rsocket/rsocket-go/payload"
+	logger "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// links:
+// link is single rsocket connection with peer, both directions of communication go over its channel
+// two clients can dial each other at the same time, so there can be two connections per peer pair,
+// registry attaches only one of them, other one is closed
+// both sides have to keep the same connection, so the decision depends only on who dialed it:
+//	connection dialed by client with lower user ID wins
+//	connection dialed by the same client replaces older one, older one is most likely dead
+// only attached link reads outbox of peer, so envelopes are never split between connections
+//
+// channel of link is opened by dialer at once with LINK_OPEN payload, responder attaches link and answers with LINK_OPEN,
+// dialer starts sending envelopes only after that, so nothing is sent over connection rejected by responder
+
+// metadata of the first payload sent in each direction of channel
+const LINK_OPEN = "LINK_OPEN"
+
+// peerLink is connection with peer
+type peerLink struct {
+	dialer string        // user ID of client which opened connection
+	done   chan struct{} // closed when link is closed
+	once   sync.Once
+}
+
+// newPeerLink returns open link dialed by user with given ID
+func newPeerLink(dialer string) *peerLink {
+	return &peerLink{dialer: dialer, done: make(chan struct{})}
+}
+
+// Close closes link, owner of connection closes it when done is closed
+func (l *peerLink) Close() {
+	l.once.Do(func() { close(l.done) })
+}
+
+// Done returns channel closed when link is closed
+func (l *peerLink) Done() <-chan struct{} {
+	return l.done
+}
+
+// preferredLink reports whether candidate replaces attached link
+func preferredLink(attached, candidate *peerLink) bool {
+	if attached == nil {
+		return true
+	}
+	if attached.dialer == candidate.dialer {
+		return true
+	}
+	return candidate.dialer < attached.dialer
+}
+
+// forward passes envelopes from outbox to next until link is closed
+// envelope taken by link which is just being closed is put back for the next link
+func (l *peerLink) forward(outbox chan *Envelope, next func(e *Envelope)) {
+	for {
+		select {
+		case <-l.done:
+			return
+		case e := <-outbox:
+			select {
+			case <-l.done:
+				requeue(outbox, e)
+				return
+			default:
+			}
+			next(e)
+		}
+	}
+}
+
+// isLinkOpen reports if payload opens channel of link
+func isLinkOpen(p payload.Payload) bool {
+	meta, _ := p.MetadataUTF8()
+	return meta == LINK_OPEN
+}
+
+// requeue puts envelope back into outbox without blocking
+func requeue(outbox chan *Envelope, e *Envelope) {
+	select {
+	case outbox <- e:
+	default:
+		logger.WithField("type", e.Type).Warn("requeue: outbox full, envelope dropped")
+	}
+}
@@ -0,0 +1,78 @@
+//go:build !race
+// +build !race
+
+// resumable connections of rsocket-go race when server closes them, so this test is not run with race detector
+
+package client
+
+import (
+	"fmt"
+	"github.com/rsocket/rsocket-go/payload"
+	"testing"
+	"time"
+)
+
+// clients dialing each other at the same time end up with single connection and lose no envelope
+func TestClient_simultaneousDial(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	a.codec, b.codec = DefaultCodec, DefaultCodec
+	// envelopes are read after all are sent
+	for _, c := range []*Client{a, b} {
+		c.peers = NewPeerRegistry(OUTBOX_SIZE)
+		c.receivedPayloadChan = make(chan payload.Payload, OUTBOX_SIZE)
+	}
+	defer serve(t, a)()
+	defer serve(t, b)()
+	a.setAddress(b.userID, b.userIP)
+	b.setAddress(a.userID, a.userIP)
+
+	for _, pair := range [][2]*Client{{a, b}, {b, a}} {
+		from, to := pair[0], pair[1]
+		from.peers.MarkConnecting(to.userIP)
+		go from.connectToClient(outboxOf(t, from, to.userIP), to.userIP)
+	}
+
+	// both sides keep connection dialed by client with lower user ID
+	dialer := a.userID
+	if b.userID < dialer {
+		dialer = b.userID
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		la, lb := attachedLink(a.peers, b.userIP), attachedLink(b.peers, a.userIP)
+		if la != nil && lb != nil && la.dialer == dialer && lb.dialer == dialer {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("links not settled: %v, %v, want dialed by %s", la, lb, dialer)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	const count = 20
+	for i := 0; i < count; i++ {
+		if err := a.sendTo(b.userID, &ChatAdvert{ChatID: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.sendTo(a.userID, &ChatAdvert{ChatID: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []*Client{a, b} {
+		for i := 0; i < count; i++ {
+			select {
+			case p := <-c.receivedPayloadChan:
+				e, err := UnmarshalEnvelope(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if advert, ok := e.Body.(*ChatAdvert); !ok || advert.ChatID != fmt.Sprint(i) {
+					t.Fatalf("received %v, want advert of chat %d", e.Body, i)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("received %d envelopes, want %d", i, count)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+// attachedLink returns link attached to peer at addr
+func attachedLink(r *PeerRegistry, addr string) *peerLink {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if p, ok := r.peers[addr]; ok {
+		return p.link
+	}
+	return nil
+}
+
+func TestPreferredLink(t *testing.T) {
+	low, high := newPeerLink("a"), newPeerLink("b")
+
+	if !preferredLink(nil, high) {
+		t.Error("link not attached to peer without connection")
+	}
+	if !preferredLink(high, low) || preferredLink(low, high) {
+		t.Error("link dialed by lower user ID not preferred")
+	}
+	if !preferredLink(low, newPeerLink("a")) {
+		t.Error("newer link of the same dialer not preferred")
+	}
+}
+
+func TestPeerRegistry_duplicateLink(t *testing.T) {
+	r := NewPeerRegistry(1)
+	low, high := newPeerLink("a"), newPeerLink("b")
+
+	if !r.MarkConnected("x", "b", high) {
+		t.Fatal("first link not attached")
+	}
+	// preferred link replaces attached one, which is closed
+	if !r.MarkConnected("x", "b", low) || attachedLink(r, "x") != low {
+		t.Fatal("preferred link not attached")
+	}
+	select {
+	case <-high.Done():
+	default:
+		t.Error("replaced link not closed")
+	}
+	// closing of replaced link does not disconnect peer
+	r.MarkDown("x", high)
+	if !r.IsConnected("x") {
+		t.Error("replaced link disconnected peer")
+	}
+
+	if r.MarkConnected("x", "b", newPeerLink("b")) || attachedLink(r, "x") != low {
+		t.Error("link which is not preferred attached")
+	}
+	// link of other user does not replace connected one, even if it would be preferred
+	other := newPeerLink("0")
+	if r.MarkConnected("x", "c", other) || attachedLink(r, "x") != low {
+		t.Error("link of other user attached")
+	}
+	select {
+	case <-low.Done():
+		t.Error("link closed by link of other user")
+	default:
+	}
+}
+
+func TestPeerLink_forward(t *testing.T) {
+	outbox := make(chan *Envelope, 2)
+	l := newPeerLink("a")
+
+	var sent []*Envelope
+	done := make(chan struct{})
+	go func() {
+		l.forward(outbox, func(e *Envelope) { sent = append(sent, e) })
+		close(done)
+	}()
+
+	outbox <- NewEnvelope("a", &ChatAdvert{ChatID: "1"})
+	for len(outbox) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("forward() not stopped by closed link")
+	}
+	if len(sent) != 1 {
+		t.Errorf("forwarded %d envelopes, want 1", len(sent))
+	}
+
+	// closed link leaves envelopes for the next one
+	outbox <- NewEnvelope("a", &ChatAdvert{ChatID: "2"})
+	l.forward(outbox, func(e *Envelope) { t.Error("closed link forwarded envelope") })
+	if len(outbox) != 1 {
+		t.Error("envelope taken by closed link")
+	}
+}
@@ -0,0 +1,366 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/hub"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// peer registry:
+// every other client daemon user talks to is peer identified by its address,
+// registry owns state of all connections, so connectionsHandler, responder,
+// payload handlers and OnClose callbacks never touch shared maps directly
+// registered peer has outbox - bounded channel with envelopes waiting to be sent by its connection,
+// outbox lives as long as registry, so envelopes wait there while peer is down
+// Send never blocks, envelopes not accepted by outbox are spilled to store (see Spillover.go)
+//
+// connection state machine:
+//	disconnected --MarkConnecting--> connecting --MarkConnected--> connected
+//	connecting   --MarkFailed------> backing_off (failed after MAX_CONNECT_ATTEMPTS)
+//	backing_off, failed --after backoff--> due for next MarkConnecting
+//	connected    --MarkDown--------> disconnected (due at once)
+// peer which connects to client goes straight to connected
+// connected peer has exactly one attached link, see PeerLink.go
+// every change of state is published to subscribers as PeerEvent
+
+// number of envelopes waiting in outbox of peer
+const OUTBOX_SIZE = 64
+
+// reconnection backoff, delay doubles after every failed attempt, it is randomized by half
+const (
+	BACKOFF_MIN = time.Second
+	BACKOFF_MAX = 5 * time.Minute
+	// consecutive failed attempts after which peer is considered failed, it is still retried every BACKOFF_MAX
+	MAX_CONNECT_ATTEMPTS = 10
+	// number of events buffered for single subscriber, slower subscribers miss events
+	PEER_EVENTS_BUFFER = 32
+)
+
+// PeerState is state of connection with peer
+type PeerState string
+
+const (
+	PEER_DISCONNECTED PeerState = "disconnected"
+	PEER_CONNECTING   PeerState = "connecting"
+	PEER_CONNECTED    PeerState = "connected"
+	PEER_BACKING_OFF  PeerState = "backing_off"
+	PEER_FAILED       PeerState = "failed"
+)
+
+var (
+	// ErrPeerDown is returned when envelope is sent to peer which is not connected
+	ErrPeerDown = errors.New("peer not connected")
+	// ErrOutboxFull is returned when connection of peer does not keep up with sent envelopes
+	ErrOutboxFull = errors.New("outbox full")
+	// ErrOtherUser is returned when envelope is sent to address at which other user than recipient is connected
+	ErrOtherUser = errors.New("other user connected at address")
+)
+
+// PeerEvent is change of connection state
+type PeerEvent struct {
+	Address string
+	UserID  string // empty until peer proved its identity
+	State   PeerState
+	Time    time.Time
+}
+
+// peer is state of connection with single client
+type peer struct {
+	state       PeerState
+	userID      string    // user last authenticated at address
+	attempts    int       // consecutive failed connection attempts
+	nextAttempt time.Time // when connection can be tried again
+	link        *peerLink // connection reading outbox, nil if not connected
+	outbox      chan *Envelope
+}
+
+// PeerRegistry keeps connection state of peers, safe for concurrent use
+type PeerRegistry struct {
+	mutex       sync.RWMutex
+	peers       map[string]*peer // address : peer
+	outboxSize  int
+	subscribers hub.Hub
+	wake        chan struct{} // signals that some peer is due for connection
+}
+
+// NewPeerRegistry returns empty registry, outboxes of its peers buffer outboxSize envelopes
+func NewPeerRegistry(outboxSize int) *PeerRegistry {
+	return &PeerRegistry{
+		peers:      make(map[string]*peer),
+		outboxSize: outboxSize,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Register adds peer to be connected, returns false if it is already known
+func (r *PeerRegistry) Register(addr string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.peers[addr]; ok {
+		return false
+	}
+	r.add(addr)
+	r.signalWake()
+	return true
+}
+
+// add registers peer, mutex has to be locked
+func (r *PeerRegistry) add(addr string) *peer {
+	p := &peer{state: PEER_DISCONNECTED, outbox: make(chan *Envelope, r.outboxSize)}
+	r.peers[addr] = p
+	return p
+}
+
+// Outbox returns outbox of registered peer
+func (r *PeerRegistry) Outbox(addr string) (chan *Envelope, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, ok := r.peers[addr]
+	if !ok {
+		return nil, false
+	}
+	return p.outbox, true
+}
+
+// Send puts envelope for user into outbox of peer connected at address without blocking,
+// addresses are not trusted, so it is queued only if the user authenticated at address is the recipient
+// returns ErrUnknownPeer, ErrPeerDown, ErrOtherUser or ErrOutboxFull if envelope was not queued
+func (r *PeerRegistry) Send(addr, userID string, e *Envelope) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, ok := r.peers[addr]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPeer, addr)
+	}
+	if p.state != PEER_CONNECTED {
+		return fmt.Errorf("%w: %s is %s", ErrPeerDown, addr, p.state)
+	}
+	if p.userID != userID {
+		return fmt.Errorf("%w: %s is connected at %s instead of %s", ErrOtherUser, p.userID, addr, userID)
+	}
+	select {
+	case p.outbox <- e:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrOutboxFull, addr)
+	}
+}
+
+// MarkConnecting starts connection attempt, registering peer if needed
+// returns false if peer is already connecting or connected, so only one connection is started
+func (r *PeerRegistry) MarkConnecting(addr string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.peers[addr]
+	if !ok {
+		p = r.add(addr)
+	}
+	if p.state == PEER_CONNECTING || p.state == PEER_CONNECTED {
+		return false
+	}
+	r.setState(addr, p, PEER_CONNECTING)
+	return true
+}
+
+// MarkConnected attaches link with peer authenticated as user with given ID, registering peer if needed
+// link already attached to peer is closed if l is preferred over it, links of other user than the connected one
+// are never preferred, so nobody takes over connection of other user at its address,
+// returns false if l is not preferred, caller has to close it then
+func (r *PeerRegistry) MarkConnected(addr, userID string, l *peerLink) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.peers[addr]
+	if !ok {
+		p = r.add(addr)
+	}
+	if p.state == PEER_CONNECTED {
+		if p.userID != userID {
+			logger.WithFields(logger.Fields{
+				"addr":   addr,
+				"userID": userID,
+				"peer":   p.userID,
+			}).Warn("PeerRegistry: link of other user than the connected one rejected")
+			return false
+		}
+		if !preferredLink(p.link, l) {
+			return false
+		}
+	}
+	if p.link != nil && p.link != l {
+		p.link.Close()
+	}
+	p.link = l
+	p.attempts = 0
+	if p.state == PEER_CONNECTED {
+		return true
+	}
+	p.userID = userID
+	r.setState(addr, p, PEER_CONNECTED)
+	return true
+}
+
+// MarkFailed ends failed connection attempt, next one is due after backoff
+func (r *PeerRegistry) MarkFailed(addr string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.peers[addr]
+	if !ok || p.state != PEER_CONNECTING {
+		return
+	}
+	p.attempts++
+	p.nextAttempt = time.Now().Add(backoff(p.attempts))
+	if p.attempts >= MAX_CONNECT_ATTEMPTS {
+		r.setState(addr, p, PEER_FAILED)
+	} else {
+		r.setState(addr, p, PEER_BACKING_OFF)
+	}
+}
+
+// MarkDown detaches closed link, peer is disconnected and connectionsHandler connects to it again at once
+// links which are not attached (duplicated or still connecting) do not affect peer
+func (r *PeerRegistry) MarkDown(addr string, l *peerLink) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.peers[addr]
+	if !ok || p.state != PEER_CONNECTED || p.link != l {
+		return
+	}
+	p.link = nil
+	p.nextAttempt = time.Time{}
+	r.setState(addr, p, PEER_DISCONNECTED)
+	r.signalWake()
+}
+
+// setState changes state of peer and publishes event, mutex has to be locked
+func (r *PeerRegistry) setState(addr string, p *peer, state PeerState) {
+	p.state = state
+	event := PeerEvent{Address: addr, UserID: p.userID, State: state, Time: time.Now()}
+	if missed := r.subscribers.Publish(event); missed > 0 {
+		logger.WithFields(logger.Fields{
+			"addr":   addr,
+			"missed": missed,
+		}).Warn("PeerRegistry: subscriber too slow, event dropped")
+	}
+}
+
+// signalWake wakes up connectionsHandler, mutex has to be locked
+func (r *PeerRegistry) signalWake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wake returns channel signalled when peer becomes due for connection
+func (r *PeerRegistry) Wake() <-chan struct{} {
+	return r.wake
+}
+
+// Status returns state of peer and whether it is registered at all
+func (r *PeerRegistry) Status(addr string) (PeerState, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, ok := r.peers[addr]
+	if !ok {
+		return PEER_DISCONNECTED, false
+	}
+	return p.state, true
+}
+
+// IsConnected reports whether peer is connected
+func (r *PeerRegistry) IsConnected(addr string) bool {
+	state, _ := r.Status(addr)
+	return state == PEER_CONNECTED
+}
+
+// Due returns sorted addresses of peers which are not connected and can be connected at given time
+func (r *PeerRegistry) Due(now time.Time) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var addrs []string
+	for addr, p := range r.peers {
+		if p.state != PEER_CONNECTING && p.state != PEER_CONNECTED && !now.Before(p.nextAttempt) {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// NextAttempt returns time of the earliest connection attempt, false if no peer waits for one
+func (r *PeerRegistry) NextAttempt() (time.Time, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var next time.Time
+	found := false
+	for _, p := range r.peers {
+		if p.state == PEER_CONNECTING || p.state == PEER_CONNECTED {
+			continue
+		}
+		if !found || p.nextAttempt.Before(next) {
+			next = p.nextAttempt
+			found = true
+		}
+	}
+	return next, found
+}
+
+// Each calls f for every registered peer,
+// f gets snapshot of state and can use registry, changes are not visible in current iteration
+func (r *PeerRegistry) Each(f func(addr string, state PeerState)) {
+	r.mutex.RLock()
+	snapshot := make(map[string]PeerState, len(r.peers))
+	for addr, p := range r.peers {
+		snapshot[addr] = p.state
+	}
+	r.mutex.RUnlock()
+
+	for addr, state := range snapshot {
+		f(addr, state)
+	}
+}
+
+// Peers returns snapshot of state of every registered peer, sorted by address
+func (r *PeerRegistry) Peers() []PeerEvent {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	peers := make([]PeerEvent, 0, len(r.peers))
+	for addr, p := range r.peers {
+		peers = append(peers, PeerEvent{Address: addr, UserID: p.userID, State: p.state})
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Address < peers[j].Address })
+	return peers
+}
+
+// Subscribe returns channel receiving every change of connection state and function ending subscription
+func (r *PeerRegistry) Subscribe() (<-chan PeerEvent, func()) {
+	ch := make(chan PeerEvent, PEER_EVENTS_BUFFER)
+	return ch, r.subscribers.Subscribe(ch, nil)
+}
+
+// backoff returns delay before next connection attempt, from half to full of exponential delay
+func backoff(attempts int) time.Duration {
+	delay := BACKOFF_MAX
+	if attempts < 32 {
+		if d := BACKOFF_MIN << uint(attempts-1); d > 0 && d < BACKOFF_MAX {
+			delay = d
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
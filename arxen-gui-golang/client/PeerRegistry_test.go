@@ -0,0 +1,305 @@
+package client
+
+import (
+	"fmt"
+	"main/gql"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// outboxOf returns outbox of registered peer
+func outboxOf(t *testing.T, c *Client, addr string) chan *Envelope {
+	outbox, ok := c.peers.Outbox(addr)
+	if !ok {
+		t.Fatalf("peer %s not registered", addr)
+	}
+	return outbox
+}
+
+func TestPeerRegistry(t *testing.T) {
+	r := NewPeerRegistry(1)
+
+	if !r.Register("a") || r.Register("a") {
+		t.Error("Register() does not report if peer was known")
+	}
+	if state, known := r.Status("a"); state != PEER_DISCONNECTED || !known {
+		t.Errorf("Status() of registered peer = %v, %v, want disconnected", state, known)
+	}
+	if _, known := r.Status("b"); known {
+		t.Error("Status() of unknown peer reports it known")
+	}
+	if _, ok := r.Outbox("b"); ok {
+		t.Error("Outbox() of unknown peer returned")
+	}
+
+	// only one connection is started for peer
+	if !r.MarkConnecting("a") || r.MarkConnecting("a") {
+		t.Error("MarkConnecting() does not report if peer was connecting")
+	}
+	linkA := newPeerLink("1")
+	if !r.MarkConnected("a", "1", linkA) || r.MarkConnecting("a") {
+		t.Error("connection started to connected peer")
+	}
+	// accepted connection registers peer
+	if !r.MarkConnected("b", "2", newPeerLink("2")) || !r.IsConnected("b") {
+		t.Error("MarkConnected() of unknown peer failed")
+	}
+	r.Register("c")
+	if got := r.Due(time.Now()); !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("Due() = %v, want [c]", got)
+	}
+	r.MarkDown("a", newPeerLink("1"))
+	if !r.IsConnected("a") {
+		t.Error("closed link which is not attached disconnected peer")
+	}
+	r.MarkDown("a", linkA)
+	r.MarkDown("unknown", linkA)
+	if got := r.Due(time.Now()); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("Due() after MarkDown = %v, want [a c]", got)
+	}
+
+	// outbox survives reconnection
+	outbox, _ := r.Outbox("a")
+	outbox <- &Envelope{Type: CHAT_MESSAGE}
+	r.MarkConnected("a", "1", newPeerLink("1"))
+	if again, _ := r.Outbox("a"); len(again) != 1 {
+		t.Error("envelope waiting in outbox lost after reconnection")
+	}
+
+	// registry can be used during iteration
+	seen := 0
+	r.Each(func(addr string, state PeerState) {
+		seen++
+		r.Register(addr + "-next")
+	})
+	if seen != 3 {
+		t.Errorf("Each() visited %d peers, want 3", seen)
+	}
+}
+
+func TestPeerRegistry_backoff(t *testing.T) {
+	r := NewPeerRegistry(1)
+
+	r.MarkConnecting("a")
+	r.MarkFailed("a")
+	if state, _ := r.Status("a"); state != PEER_BACKING_OFF {
+		t.Errorf("state after failed attempt = %v, want %v", state, PEER_BACKING_OFF)
+	}
+	if due := r.Due(time.Now()); len(due) != 0 {
+		t.Errorf("Due() during backoff = %v, want none", due)
+	}
+	next, ok := r.NextAttempt()
+	if !ok || time.Until(next) < BACKOFF_MIN/2-time.Millisecond || time.Until(next) > BACKOFF_MIN {
+		t.Errorf("NextAttempt() = %v, %v, want within %v", time.Until(next), ok, BACKOFF_MIN)
+	}
+	if due := r.Due(next); !reflect.DeepEqual(due, []string{"a"}) {
+		t.Errorf("Due() after backoff = %v, want [a]", due)
+	}
+
+	// closed connection of peer which is not connected does not reset backoff
+	r.MarkDown("a", newPeerLink("0"))
+	if due := r.Due(time.Now()); len(due) != 0 {
+		t.Errorf("Due() after MarkDown of connecting peer = %v, want none", due)
+	}
+
+	for i := 1; i < MAX_CONNECT_ATTEMPTS; i++ {
+		r.MarkConnecting("a")
+		r.MarkFailed("a")
+	}
+	if state, _ := r.Status("a"); state != PEER_FAILED {
+		t.Errorf("state after %d failed attempts = %v, want %v", MAX_CONNECT_ATTEMPTS, state, PEER_FAILED)
+	}
+	// failed peer is still retried
+	if due := r.Due(time.Now().Add(BACKOFF_MAX)); !reflect.DeepEqual(due, []string{"a"}) {
+		t.Errorf("Due() of failed peer = %v, want [a]", due)
+	}
+
+	// success resets backoff
+	l := newPeerLink("0")
+	r.MarkConnecting("a")
+	r.MarkConnected("a", "1", l)
+	r.MarkDown("a", l)
+	if due := r.Due(time.Now()); !reflect.DeepEqual(due, []string{"a"}) {
+		t.Errorf("Due() after disconnection = %v, want [a]", due)
+	}
+	select {
+	case <-r.Wake():
+	default:
+		t.Error("connectionsHandler not woken up after disconnection")
+	}
+	r.MarkConnecting("a")
+	r.MarkFailed("a")
+	if next, _ := r.NextAttempt(); time.Until(next) > BACKOFF_MIN {
+		t.Errorf("backoff after successful connection = %v, want at most %v", time.Until(next), BACKOFF_MIN)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{1: BACKOFF_MIN, 3: 4 * BACKOFF_MIN, 20: BACKOFF_MAX, 100: BACKOFF_MAX} {
+		for i := 0; i < 10; i++ {
+			if got := backoff(attempts); got < want/2 || got > want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", attempts, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestPeerRegistry_Subscribe(t *testing.T) {
+	r := NewPeerRegistry(1)
+	events, cancel := r.Subscribe()
+
+	r.MarkConnecting("a")
+	r.MarkFailed("a")
+	l := newPeerLink("0")
+	r.MarkConnecting("a")
+	r.MarkConnected("a", "1", l)
+	r.MarkDown("a", l)
+
+	want := []PeerState{PEER_CONNECTING, PEER_BACKING_OFF, PEER_CONNECTING, PEER_CONNECTED, PEER_DISCONNECTED}
+	for _, state := range want {
+		select {
+		case e := <-events:
+			if e.State != state || e.Address != "a" {
+				t.Errorf("event = %v, want %v of a", e, state)
+			}
+			if state == PEER_CONNECTED && e.UserID != "1" {
+				t.Errorf("event of connected peer carries user %q, want 1", e.UserID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %v not received", state)
+		}
+	}
+
+	cancel()
+	cancel()
+	r.MarkConnected("a", "1", newPeerLink("1"))
+	if _, ok := <-events; ok {
+		t.Error("event received after subscription ended")
+	}
+	if peers := r.Peers(); len(peers) != 1 || peers[0].State != PEER_CONNECTED || peers[0].UserID != "1" {
+		t.Errorf("Peers() = %v, want connected a", peers)
+	}
+}
+
+func TestPeerRegistry_concurrent(t *testing.T) {
+	r := NewPeerRegistry(0)
+	var links []*peerLink
+	for i := 0; i < 10; i++ {
+		links = append(links, newPeerLink(fmt.Sprint(i)))
+	}
+	events, cancel := r.Subscribe()
+	defer cancel()
+	go func() {
+		for range events {
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				addr := fmt.Sprint(j % 10)
+				switch (i + j) % 6 {
+				case 0:
+					r.Register(addr)
+				case 1:
+					r.MarkConnecting(addr)
+				case 2:
+					r.MarkConnected(addr, addr, links[j%10])
+				case 3:
+					r.MarkDown(addr, links[j%10])
+					r.MarkFailed(addr)
+				case 4:
+					r.Due(time.Now())
+					r.NextAttempt()
+					r.Status(addr)
+				case 5:
+					r.Each(func(addr string, state PeerState) { r.Outbox(addr) })
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// goroutines of client use connection state at once, run with -race
+func TestClient_concurrentConnectionState(t *testing.T) {
+	c := newTestClient(t)
+	participants := []string{"1", "2", "3"}
+	for _, userID := range participants {
+		c.setAddress(userID, userID)
+		c.peers.Register(userID)
+	}
+
+	var wg sync.WaitGroup
+	run := func(f func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				f(i)
+			}
+		}()
+	}
+
+	// chats started by responses of different participants
+	run(func(i int) { c.createSlaveChat(participants, fmt.Sprint(i%5)) })
+	run(func(i int) { c.createSlaveChat(participants, fmt.Sprint(i%5)) })
+	// connections opened and closed
+	links := []*peerLink{newPeerLink("1"), newPeerLink("2"), newPeerLink("3")}
+	run(func(i int) { c.peers.MarkConnected(participants[i%3], participants[i%3], links[i%3]) })
+	run(func(i int) { c.peers.MarkDown(participants[i%3], links[i%3]) })
+	// readers
+	run(func(i int) {
+		for chatID := range c.GetChatList() {
+			if _, err := c.GetChat(chatID); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	run(func(i int) {
+		if err := c.sendTo(participants[i%3], &ChatAdvert{ChatID: "1"}); err != nil {
+			t.Error(err)
+		}
+	})
+	run(func(i int) { c.requestHistorySync(participants[i%3]) })
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client blocked")
+	}
+
+	if chats := c.GetChatList(); len(chats) != 5 {
+		t.Errorf("%d chats started, want 5", len(chats))
+	}
+}
+
+func TestClient_FriendsStatus(t *testing.T) {
+	c := newTestClient(t)
+	c.setAddress("1", "tcp://10.5.0.2:7878")
+	for _, userID := range []string{"1", "2"} {
+		c.saveFriend(&gql.Friend{UserID: userID})
+	}
+	c.peers.MarkConnected("tcp://10.5.0.2:7878", "1", newPeerLink("1"))
+
+	friends, err := c.Friends()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, friend := range friends {
+		want := friend.UserID == "1"
+		if friend.Status == nil || *friend.Status != want {
+			t.Errorf("status of friend %s = %v, want %v", friend.UserID, friend.Status, want)
+		}
+	}
+}
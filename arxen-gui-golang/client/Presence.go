@@ -0,0 +1,253 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/gql"
+	"main/store"
+	"sync"
+	"time"
+)
+
+// presence of users:
+// client sends PRESENCE {status} to every connected peer every PRESENCE_INTERVAL,
+// at once when peer connects and when user changes own status, blocked users get nothing
+// user is online or away as it announced until PRESENCE_TIMEOUT passes without heartbeat
+// or its connection goes down, then it is offline, announcing offline makes user invisible
+// time of the last heartbeat is last-seen, it is saved with friend when friend goes offline
+// changes of presence of friends are published as CHAT_EVENT_PRESENCE
+
+// PresenceStatus is status of user announced to other clients or derived from heartbeats
+type PresenceStatus string
+
+const (
+	PRESENCE_ONLINE  PresenceStatus = "online"
+	PRESENCE_AWAY    PresenceStatus = "away"
+	PRESENCE_OFFLINE PresenceStatus = "offline"
+)
+
+// presenceStatuses are statuses accepted in PRESENCE
+var presenceStatuses = map[PresenceStatus]bool{
+	PRESENCE_ONLINE:  true,
+	PRESENCE_AWAY:    true,
+	PRESENCE_OFFLINE: true,
+}
+
+const (
+	// time between heartbeats sent to every connected peer
+	PRESENCE_INTERVAL = 30 * time.Second
+	// user without heartbeat for that long is offline, few heartbeats can be lost
+	PRESENCE_TIMEOUT = 3 * PRESENCE_INTERVAL
+)
+
+// key of own status in store settings
+const STATUS_SETTING = "status"
+
+// ErrInvalidStatus is returned when user sets unknown status
+var ErrInvalidStatus = errors.New("status has to be online, away or offline")
+
+// userPresence is presence of single user
+type userPresence struct {
+	status   PresenceStatus // announced in the last heartbeat
+	lastSeen time.Time      // time of the last heartbeat
+	derived  PresenceStatus // presence last reported, offline after timeout
+}
+
+// presenceTracker derives presence of users from their heartbeats, zero value is ready to use
+type presenceTracker struct {
+	mutex sync.Mutex
+	users map[string]*userPresence // userID : presence
+}
+
+// heartbeat records status announced by user, reports if its presence changed
+func (t *presenceTracker) heartbeat(userID string, status PresenceStatus, now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.users == nil {
+		t.users = make(map[string]*userPresence)
+	}
+	p, ok := t.users[userID]
+	if !ok {
+		p = &userPresence{derived: PRESENCE_OFFLINE}
+		t.users[userID] = p
+	}
+	p.status = status
+	// invisible user is not seen
+	if status != PRESENCE_OFFLINE {
+		p.lastSeen = now
+	}
+
+	changed := p.derived != status
+	p.derived = status
+	return changed
+}
+
+// disconnect makes user offline at once, reports if its presence changed
+func (t *presenceTracker) disconnect(userID string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	p, ok := t.users[userID]
+	if !ok || p.derived == PRESENCE_OFFLINE {
+		return false
+	}
+	p.derived = PRESENCE_OFFLINE
+	return true
+}
+
+// expire makes users without heartbeat for PRESENCE_TIMEOUT offline, returns their user IDs
+func (t *presenceTracker) expire(now time.Time) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var expired []string
+	for userID, p := range t.users {
+		if p.derived != PRESENCE_OFFLINE && now.Sub(p.lastSeen) >= PRESENCE_TIMEOUT {
+			p.derived = PRESENCE_OFFLINE
+			expired = append(expired, userID)
+		}
+	}
+	return expired
+}
+
+// presence returns presence of user and time of its last heartbeat, zero if none was received
+func (t *presenceTracker) presence(userID string) (PresenceStatus, time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	p, ok := t.users[userID]
+	if !ok {
+		return PRESENCE_OFFLINE, time.Time{}
+	}
+	return p.derived, p.lastSeen
+}
+
+// Status returns own status announced to other clients
+func (c *Client) Status() PresenceStatus {
+	status, err := c.store.Setting(STATUS_SETTING)
+	if err != nil {
+		if err != store.ErrNotFound {
+			logger.WithError(err).Error("Status: cannot read status")
+		}
+		return PRESENCE_ONLINE
+	}
+	return PresenceStatus(status)
+}
+
+// SetStatus saves own status and announces it to connected peers
+func (c *Client) SetStatus(status PresenceStatus) error {
+	if !presenceStatuses[status] {
+		return ErrInvalidStatus
+	}
+	if err := c.store.SetSetting(STATUS_SETTING, string(status)); err != nil {
+		return err
+	}
+	c.broadcastPresence()
+	return nil
+}
+
+// SubscribePresence returns channel with events of friends whose presence changed and function ending subscription
+func (c *Client) SubscribePresence() (<-chan ChatEvent, func()) {
+	return c.events.Subscribe(CHAT_EVENT_PRESENCE, "")
+}
+
+// presenceHeartbeats sends heartbeats to connected peers and notices users which went offline
+func (c *Client) presenceHeartbeats() {
+	events, cancel := c.peers.Subscribe()
+	defer cancel()
+	ticker := time.NewTicker(PRESENCE_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			c.handlePeerPresence(e)
+		case now := <-ticker.C:
+			c.broadcastPresence()
+			for _, userID := range c.presence.expire(now) {
+				c.publishPresence(userID)
+			}
+		}
+	}
+}
+
+// handlePeerPresence greets newly connected peer with heartbeat, user of lost connection is offline
+func (c *Client) handlePeerPresence(e PeerEvent) {
+	if e.UserID == "" {
+		return
+	}
+	switch e.State {
+	case PEER_CONNECTED:
+		c.sendPresence(e.UserID)
+	case PEER_DISCONNECTED:
+		if c.presence.disconnect(e.UserID) {
+			c.publishPresence(e.UserID)
+		}
+	}
+}
+
+// broadcastPresence sends heartbeat to every connected peer
+func (c *Client) broadcastPresence() {
+	for _, p := range c.peers.Peers() {
+		if p.State == PEER_CONNECTED && p.UserID != "" {
+			c.sendPresence(p.UserID)
+		}
+	}
+}
+
+// sendPresence sends heartbeat to user, it is ephemeral as it would be outdated after reconnection
+func (c *Client) sendPresence(userID string) {
+	if c.IsBlocked(userID) {
+		return
+	}
+	if err := c.sendTo(userID, &Presence{Status: c.Status()}); err != nil {
+		logger.WithError(err).WithField("userID", userID).Debug("sendPresence: heartbeat not sent")
+	}
+}
+
+// handlePresence records heartbeat of sender
+func (c *Client) handlePresence(e *Envelope) error {
+	body, ok := e.Body.(*Presence)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+	// unknown status would be published as presence
+	if !presenceStatuses[body.Status] {
+		return fmt.Errorf("%w: unknown presence status %q of %s", ErrInvalidMessage, body.Status, e.Source)
+	}
+	if c.presence.heartbeat(e.Source, body.Status, time.Now().UTC()) {
+		c.publishPresence(e.Source)
+	}
+	return nil
+}
+
+// publishPresence passes friend with changed presence to subscribers,
+// last-seen of friend going offline is saved
+func (c *Client) publishPresence(userID string) {
+	friend, err := c.friend(userID)
+	if err != nil || friendState(friend) != FRIEND_ACCEPTED {
+		return
+	}
+	c.fillPresence(friend)
+	if friend.Presence == string(PRESENCE_OFFLINE) && friend.LastSeen != nil {
+		if err := c.store.SaveFriend(friend); err != nil {
+			logger.WithError(err).WithField("userID", userID).Warn("publishPresence: last-seen not saved")
+		}
+	}
+	logger.WithFields(logger.Fields{"userID": userID, "presence": friend.Presence}).Debug("publishPresence: presence changed")
+	c.publishFriend(CHAT_EVENT_PRESENCE, friend)
+}
+
+// fillPresence sets presence of friend and its last-seen, unless the saved one is newer
+func (c *Client) fillPresence(friend *gql.Friend) {
+	status, lastSeen := c.presence.presence(friend.UserID)
+	friend.Presence = string(status)
+	if !lastSeen.IsZero() && (friend.LastSeen == nil || lastSeen.After(*friend.LastSeen)) {
+		friend.LastSeen = &lastSeen
+	}
+}
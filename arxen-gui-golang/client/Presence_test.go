@@ -0,0 +1,116 @@
+package client
+
+import (
+	"errors"
+	"main/gql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	var tracker presenceTracker
+	start := time.Now()
+
+	if status, lastSeen := tracker.presence("a"); status != PRESENCE_OFFLINE || !lastSeen.IsZero() {
+		t.Errorf("presence of unknown user = %s, %v", status, lastSeen)
+	}
+	if !tracker.heartbeat("a", PRESENCE_ONLINE, start) {
+		t.Error("first heartbeat did not change presence")
+	}
+	if tracker.heartbeat("a", PRESENCE_ONLINE, start.Add(PRESENCE_INTERVAL)) {
+		t.Error("repeated heartbeat changed presence")
+	}
+	if !tracker.heartbeat("b", PRESENCE_AWAY, start) {
+		t.Error("heartbeat of away user did not change presence")
+	}
+
+	// a sent heartbeat later than b
+	if expired := tracker.expire(start.Add(PRESENCE_TIMEOUT)); !reflect.DeepEqual(expired, []string{"b"}) {
+		t.Errorf("expired = %v, want [b]", expired)
+	}
+	if status, lastSeen := tracker.presence("b"); status != PRESENCE_OFFLINE || !lastSeen.Equal(start) {
+		t.Errorf("presence of expired user = %s, %v", status, lastSeen)
+	}
+
+	if !tracker.disconnect("a") || tracker.disconnect("a") {
+		t.Error("disconnect did not make user offline once")
+	}
+
+	// invisible user is offline and last-seen does not move
+	tracker.heartbeat("b", PRESENCE_OFFLINE, start.Add(time.Hour))
+	if status, lastSeen := tracker.presence("b"); status != PRESENCE_OFFLINE || !lastSeen.Equal(start) {
+		t.Errorf("presence of invisible user = %s, %v", status, lastSeen)
+	}
+}
+
+func TestClient_presence(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+	b.saveFriend(&gql.Friend{UserID: a.userID, State: FRIEND_ACCEPTED})
+	changed, cancel := b.SubscribePresence()
+	defer cancel()
+
+	if a.Status() != PRESENCE_ONLINE {
+		t.Errorf("default status = %s, want online", a.Status())
+	}
+	if err := a.SetStatus("busy"); err != ErrInvalidStatus {
+		t.Errorf("SetStatus() of unknown status error = %v", err)
+	}
+	if err := a.SetStatus(PRESENCE_AWAY); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-changed:
+		friend := e.Friend
+		if friend.UserID != a.userID || friend.Presence != string(PRESENCE_AWAY) || friend.LastSeen == nil {
+			t.Errorf("published friend = %+v", friend)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("presence not published")
+	}
+	friends, err := b.Friends()
+	if err != nil || len(friends) != 1 || friends[0].Presence != string(PRESENCE_AWAY) {
+		t.Fatalf("Friends() = %+v, %v", friends, err)
+	}
+
+	// unknown status is not recorded
+	for _, status := range []PresenceStatus{"", "busy"} {
+		if err := b.handlePresence(NewEnvelope(a.userID, &Presence{Status: status})); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("presence %q error = %v, want %v", status, err, ErrInvalidMessage)
+		}
+	}
+	if status, _ := b.presence.presence(a.userID); status != PRESENCE_AWAY {
+		t.Errorf("presence after invalid heartbeats = %s, want away", status)
+	}
+
+	// lost connection makes friend offline, its last-seen is kept
+	b.handlePeerPresence(PeerEvent{Address: a.userIP, UserID: a.userID, State: PEER_DISCONNECTED})
+	select {
+	case e := <-changed:
+		friend := e.Friend
+		if friend.Presence != string(PRESENCE_OFFLINE) {
+			t.Errorf("presence after disconnection = %s", friend.Presence)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("presence not published")
+	}
+	if friend, err := b.store.Friend(a.userID); err != nil || friend.LastSeen == nil {
+		t.Errorf("last-seen not saved: %+v, %v", friend, err)
+	}
+
+	// blocked user gets no heartbeats
+	if err := a.BlockUser(b.userID); err != nil {
+		t.Fatal(err)
+	}
+	a.broadcastPresence()
+	select {
+	case e := <-outboxOf(t, a, b.userIP):
+		t.Errorf("%s sent to blocked user", e.Type)
+	default:
+	}
+}
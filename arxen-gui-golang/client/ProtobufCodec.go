@@ -0,0 +1,414 @@
+package client
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/rsocket/rsocket-go/payload"
+	"main/chat"
+	arxen "main/genproto"
+	"main/store"
+)
+
+// protoMessage is Message which can be mapped to its counterpart defined in pb/arxen.proto
+type protoMessage interface {
+	Message
+	// toProto returns generated counterpart of message
+	toProto() (proto.Message, error)
+	// fromProto decodes generated counterpart of message from bytes
+	fromProto(data []byte) error
+}
+
+// protobufCodec encodes header and body as protobuf messages (see pb/arxen.proto)
+type protobufCodec struct{}
+
+func (protobufCodec) MimeType() string { return MIME_TYPE_PROTOBUF }
+
+func (protobufCodec) Marshal(e *Envelope) (payload.Payload, error) {
+	if err := checkBody(e); err != nil {
+		return nil, err
+	}
+
+	body, ok := e.Body.(protoMessage)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has no protobuf mapping", ErrUnknownMessageType, e.Type)
+	}
+
+	metadata, err := proto.Marshal(&arxen.Header{
+		Version: e.Version,
+		Type:    e.Type,
+		Source:  e.Source,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pb, err := body.toProto()
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload.New(data, metadata), nil
+}
+
+func (protobufCodec) Unmarshal(p payload.Payload) (*Envelope, error) {
+	metadata, _ := p.Metadata()
+
+	var header arxen.Header
+	if err := proto.Unmarshal(metadata, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+
+	e := Envelope{
+		Version: header.GetVersion(),
+		Type:    header.GetType(),
+		Source:  header.GetSource(),
+	}
+
+	body, err := checkHeader(&e)
+	if err != nil {
+		return nil, err
+	}
+
+	pbBody, ok := body.(protoMessage)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has no protobuf mapping", ErrUnknownMessageType, e.Type)
+	}
+	if err := pbBody.fromProto(p.Data()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+	e.Body = body
+
+	return &e, nil
+}
+
+func (m *ChatMessage) toProto() (proto.Message, error) {
+	return m.toChatMessageProto()
+}
+
+func (m *ChatMessage) fromProto(data []byte) error {
+	var pb arxen.ChatMessage
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	return m.fromChatMessageProto(&pb)
+}
+
+// toChatMessageProto is toProto returning concrete type, used also for messages nested in other ones
+func (m *ChatMessage) toChatMessageProto() (*arxen.ChatMessage, error) {
+	pb := &arxen.ChatMessage{
+		ChatID:     m.ChatID,
+		MessageID:  m.MessageID,
+		User:       m.User,
+		Text:       m.Text,
+		Epoch:      m.Epoch,
+		Ciphertext: m.Ciphertext,
+	}
+	// encrypted messages carry time stamp in ciphertext
+	if !m.TimeStamp.IsZero() {
+		timeStamp, err := ptypes.TimestampProto(m.TimeStamp)
+		if err != nil {
+			return nil, err
+		}
+		pb.TimeStamp = timeStamp
+	}
+	return pb, nil
+}
+
+// fromChatMessageProto is fromProto for already decoded message
+func (m *ChatMessage) fromChatMessageProto(pb *arxen.ChatMessage) error {
+	*m = ChatMessage{
+		ChatID:     pb.GetChatID(),
+		MessageID:  pb.GetMessageID(),
+		User:       pb.GetUser(),
+		Text:       pb.GetText(),
+		Epoch:      pb.GetEpoch(),
+		Ciphertext: pb.GetCiphertext(),
+	}
+	if pb.GetTimeStamp() != nil {
+		timeStamp, err := ptypes.Timestamp(pb.GetTimeStamp())
+		if err != nil {
+			return err
+		}
+		m.TimeStamp = timeStamp
+	}
+	return nil
+}
+
+func (m *ChatAdvertRequest) toProto() (proto.Message, error) {
+	return &arxen.ChatAdvertRequest{ChatID: m.ChatID}, nil
+}
+
+func (m *ChatAdvertRequest) fromProto(data []byte) error {
+	var pb arxen.ChatAdvertRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = ChatAdvertRequest{ChatID: pb.GetChatID()}
+	return nil
+}
+
+func (m *ChatAdvert) toProto() (proto.Message, error) {
+	return &arxen.ChatAdvert{ChatID: m.ChatID, ChatName: m.ChatName}, nil
+}
+
+func (m *ChatAdvert) fromProto(data []byte) error {
+	var pb arxen.ChatAdvert
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = ChatAdvert{ChatID: pb.GetChatID(), ChatName: pb.GetChatName()}
+	return nil
+}
+
+func (m *ChatParticipantsRequest) toProto() (proto.Message, error) {
+	return &arxen.ChatParticipantsRequest{ChatID: m.ChatID}, nil
+}
+
+func (m *ChatParticipantsRequest) fromProto(data []byte) error {
+	var pb arxen.ChatParticipantsRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = ChatParticipantsRequest{ChatID: pb.GetChatID()}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) toProto() (proto.Message, error) {
+	pb := &arxen.ChatParticipantsResponse{ChatID: m.ChatID, Participants: m.Participants, Addresses: m.Addresses,
+		Owner: m.Owner, Admins: m.Admins, ChatName: m.ChatName, ChatAvatar: m.ChatAvatar, PublicKeys: m.PublicKeys}
+	if m.Key != nil {
+		pb.Key = m.Key.toChatKeyProto()
+	}
+	pb.Versions = versionsToProto(m.Versions)
+	pb.MetadataVersions = versionsToProto(m.MetadataVersions)
+	if m.Creation != nil {
+		pb.Creation = versionToProto(*m.Creation)
+	}
+	for _, version := range m.History {
+		pb.History = append(pb.History, versionToProto(version))
+	}
+	return pb, nil
+}
+
+func (m *ChatParticipantsResponse) fromProto(data []byte) error {
+	var pb arxen.ChatParticipantsResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = ChatParticipantsResponse{ChatID: pb.GetChatID(), Participants: pb.GetParticipants(), Addresses: pb.GetAddresses(),
+		Owner: pb.GetOwner(), Admins: pb.GetAdmins(), ChatName: pb.GetChatName(), ChatAvatar: pb.GetChatAvatar(),
+		PublicKeys: pb.GetPublicKeys()}
+	if pb.GetKey() != nil {
+		m.Key = fromChatKeyProto(pb.GetKey())
+	}
+	m.Versions = versionsFromProto(pb.GetVersions())
+	m.MetadataVersions = versionsFromProto(pb.GetMetadataVersions())
+	if pb.GetCreation() != nil {
+		creation := versionFromProto(pb.GetCreation())
+		m.Creation = &creation
+	}
+	for _, version := range pb.GetHistory() {
+		m.History = append(m.History, versionFromProto(version))
+	}
+	return nil
+}
+
+// versionsToProto converts versions of changes to their generated counterparts, nil if there are none
+func versionsToProto(versions map[string]store.MemberVersion) map[string]*arxen.MemberVersion {
+	if len(versions) == 0 {
+		return nil
+	}
+	pb := make(map[string]*arxen.MemberVersion, len(versions))
+	for key, version := range versions {
+		pb[key] = versionToProto(version)
+	}
+	return pb
+}
+
+// versionToProto converts version of single change
+func versionToProto(version store.MemberVersion) *arxen.MemberVersion {
+	return &arxen.MemberVersion{Clock: int64(version.Clock), Actor: version.Actor, Action: version.Action,
+		Signature: version.Signature, UserID: version.UserID}
+}
+
+// versionsFromProto converts generated versions of changes, nil if there are none
+func versionsFromProto(pb map[string]*arxen.MemberVersion) map[string]store.MemberVersion {
+	if len(pb) == 0 {
+		return nil
+	}
+	versions := make(map[string]store.MemberVersion, len(pb))
+	for key, version := range pb {
+		versions[key] = versionFromProto(version)
+	}
+	return versions
+}
+
+// versionFromProto converts generated version of single change
+func versionFromProto(pb *arxen.MemberVersion) store.MemberVersion {
+	return store.MemberVersion{Clock: int(pb.GetClock()), Actor: pb.GetActor(), Action: pb.GetAction(),
+		Signature: pb.GetSignature(), UserID: pb.GetUserID()}
+}
+
+func (m *HistorySyncRequest) toProto() (proto.Message, error) {
+	return &arxen.HistorySyncRequest{ChatID: m.ChatID, SinceMessageID: m.SinceMessageID}, nil
+}
+
+func (m *HistorySyncRequest) fromProto(data []byte) error {
+	var pb arxen.HistorySyncRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = HistorySyncRequest{ChatID: pb.GetChatID(), SinceMessageID: pb.GetSinceMessageID()}
+	return nil
+}
+
+func (m *HistorySyncResponse) toProto() (proto.Message, error) {
+	pb := &arxen.HistorySyncResponse{ChatID: m.ChatID}
+	for _, message := range m.Messages {
+		pbMessage, err := message.toChatMessageProto()
+		if err != nil {
+			return nil, err
+		}
+		pb.Messages = append(pb.Messages, pbMessage)
+	}
+	return pb, nil
+}
+
+func (m *HistorySyncResponse) fromProto(data []byte) error {
+	var pb arxen.HistorySyncResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = HistorySyncResponse{ChatID: pb.GetChatID()}
+	for _, pbMessage := range pb.GetMessages() {
+		message := &ChatMessage{}
+		if err := message.fromChatMessageProto(pbMessage); err != nil {
+			return err
+		}
+		m.Messages = append(m.Messages, message)
+	}
+	return nil
+}
+
+func (m *ChatKey) toProto() (proto.Message, error) {
+	return m.toChatKeyProto(), nil
+}
+
+func (m *ChatKey) fromProto(data []byte) error {
+	var pb arxen.ChatKey
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = *fromChatKeyProto(&pb)
+	return nil
+}
+
+// toChatKeyProto is toProto returning concrete type, used also for keys nested in other messages
+func (m *ChatKey) toChatKeyProto() *arxen.ChatKey {
+	return &arxen.ChatKey{ChatID: m.ChatID, Epoch: m.Epoch, SealedKey: m.SealedKey}
+}
+
+// fromChatKeyProto converts already decoded key
+func fromChatKeyProto(pb *arxen.ChatKey) *ChatKey {
+	return &ChatKey{ChatID: pb.GetChatID(), Epoch: pb.GetEpoch(), SealedKey: pb.GetSealedKey()}
+}
+
+func (m *ChatKeyRequest) toProto() (proto.Message, error) {
+	return &arxen.ChatKeyRequest{ChatID: m.ChatID, Epoch: m.Epoch}, nil
+}
+
+func (m *ChatKeyRequest) fromProto(data []byte) error {
+	var pb arxen.ChatKeyRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = ChatKeyRequest{ChatID: pb.GetChatID(), Epoch: pb.GetEpoch()}
+	return nil
+}
+
+func (m *MessageReceipt) toProto() (proto.Message, error) {
+	return &arxen.MessageReceipt{ChatID: m.ChatID, MessageIDs: m.MessageIDs, Status: string(m.Status)}, nil
+}
+
+func (m *MessageReceipt) fromProto(data []byte) error {
+	var pb arxen.MessageReceipt
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = MessageReceipt{ChatID: pb.GetChatID(), MessageIDs: pb.GetMessageIDs(), Status: store.ReceiptStatus(pb.GetStatus())}
+	return nil
+}
+
+func (m *ChatMembership) toProto() (proto.Message, error) {
+	return &arxen.ChatMembership{ChatID: m.ChatID, Action: string(m.Action), UserID: m.UserID,
+		Actor: m.Actor, Clock: int64(m.Clock), Signature: m.Signature}, nil
+}
+
+func (m *ChatMembership) fromProto(data []byte) error {
+	var pb arxen.ChatMembership
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = ChatMembership{ChatID: pb.GetChatID(), Action: MembershipAction(pb.GetAction()), UserID: pb.GetUserID(),
+		Actor: pb.GetActor(), Clock: int(pb.GetClock()), Signature: pb.GetSignature()}
+	return nil
+}
+
+func (m *ChatMetadata) toProto() (proto.Message, error) {
+	return &arxen.ChatMetadata{ChatID: m.ChatID, Field: string(m.Field), Value: m.Value,
+		Actor: m.Actor, Clock: int64(m.Clock), Signature: m.Signature}, nil
+}
+
+func (m *ChatMetadata) fromProto(data []byte) error {
+	var pb arxen.ChatMetadata
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = ChatMetadata{ChatID: pb.GetChatID(), Field: chat.MetadataField(pb.GetField()), Value: pb.GetValue(),
+		Actor: pb.GetActor(), Clock: int(pb.GetClock()), Signature: pb.GetSignature()}
+	return nil
+}
+
+func (m *Friendship) toProto() (proto.Message, error) {
+	return &arxen.Friendship{Action: string(m.Action), Nick: m.Nick, PublicKey: m.PublicKey, Address: m.Address}, nil
+}
+
+func (m *Friendship) fromProto(data []byte) error {
+	var pb arxen.Friendship
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = Friendship{Action: FriendshipAction(pb.GetAction()), Nick: pb.GetNick(), PublicKey: pb.GetPublicKey(), Address: pb.GetAddress()}
+	return nil
+}
+
+func (m *Presence) toProto() (proto.Message, error) {
+	return &arxen.Presence{Status: string(m.Status)}, nil
+}
+
+func (m *Presence) fromProto(data []byte) error {
+	var pb arxen.Presence
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = Presence{Status: PresenceStatus(pb.GetStatus())}
+	return nil
+}
+
+func (m *Typing) toProto() (proto.Message, error) {
+	return &arxen.Typing{ChatID: m.ChatID, Typing: m.Typing}, nil
+}
+
+func (m *Typing) fromProto(data []byte) error {
+	var pb arxen.Typing
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*m = Typing{ChatID: pb.GetChatID(), Typing: pb.GetTyping()}
+	return nil
+}
@@ -0,0 +1,272 @@
+package client
+
+import (
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/gql"
+	"main/hub"
+	"main/store"
+	"sort"
+	"sync"
+	"time"
+)
+
+// receipts:
+// recipient of CHAT_MESSAGE answers its author with MESSAGE_RECEIPT {chatID, messageIDs, delivered},
+// also when message is already known, so receipt lost on the way is sent again for retried message
+// messages backfilled by history sync are acknowledged the same way
+// user marks messages read with MarkRead, their authors get MESSAGE_RECEIPT {read}
+// receipts are saved in store per message and recipient and published to subscribers
+//
+// author keeps sent messages until recipients acknowledge them, connectionsHandler sends
+// unacknowledged ones again to connected recipients after DELIVERY_RETRY_TIMEOUT, doubling it after each attempt
+// pending deliveries are kept in memory only, envelopes for offline users survive restart in spillover anyway
+
+const (
+	// time to wait for receipt before message is sent again
+	DELIVERY_RETRY_TIMEOUT = 30 * time.Second
+	// number of attempts after which unacknowledged message is given up
+	DELIVERY_MAX_ATTEMPTS = 5
+	// number of receipts buffered for single subscriber, slower subscribers miss receipts
+	RECEIPT_EVENTS_BUFFER = 32
+)
+
+// deliveryKey identifies message sent to single recipient
+type deliveryKey struct {
+	messageID string
+	userID    string
+}
+
+// pendingDelivery is message waiting for receipt of recipient
+type pendingDelivery struct {
+	userID   string
+	message  *ChatMessage // encrypted as it was sent
+	attempts int
+	next     time.Time // when message is sent again
+}
+
+// DeliveryTracker keeps messages waiting for receipts and subscribers of receipts, safe for concurrent use
+type DeliveryTracker struct {
+	mutex       sync.Mutex
+	pending     map[deliveryKey]*pendingDelivery
+	subscribers hub.Hub
+}
+
+// NewDeliveryTracker returns tracker without pending messages
+func NewDeliveryTracker() *DeliveryTracker {
+	return &DeliveryTracker{
+		pending: make(map[deliveryKey]*pendingDelivery),
+	}
+}
+
+// Track starts waiting for receipt of message sent to user with given ID
+func (d *DeliveryTracker) Track(userID string, message *ChatMessage) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.pending[deliveryKey{message.MessageID, userID}] = &pendingDelivery{
+		userID:  userID,
+		message: message,
+		next:    time.Now().Add(DELIVERY_RETRY_TIMEOUT),
+	}
+}
+
+// Ack stops waiting for receipt of message, returns false if it was not pending
+func (d *DeliveryTracker) Ack(messageID, userID string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := deliveryKey{messageID, userID}
+	if _, ok := d.pending[key]; !ok {
+		return false
+	}
+	delete(d.pending, key)
+	return true
+}
+
+// Pending returns number of messages waiting for receipts
+func (d *DeliveryTracker) Pending() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return len(d.pending)
+}
+
+// Due returns messages for online recipients which should be sent again at given time and schedules their next attempt
+// only returned messages count as attempts, messages of offline recipients wait until they connect
+// messages sent DELIVERY_MAX_ATTEMPTS times are given up, online is called with tracker locked
+func (d *DeliveryTracker) Due(now time.Time, online func(userID string) bool) []pendingDelivery {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var due []pendingDelivery
+	for key, p := range d.pending {
+		if now.Before(p.next) || !online(p.userID) {
+			continue
+		}
+		if p.attempts >= DELIVERY_MAX_ATTEMPTS {
+			logger.WithFields(logger.Fields{
+				"messageID": key.messageID,
+				"userID":    key.userID,
+			}).Warn("DeliveryTracker: message not acknowledged, giving up")
+			delete(d.pending, key)
+			continue
+		}
+		p.attempts++
+		p.next = now.Add(DELIVERY_RETRY_TIMEOUT << uint(p.attempts))
+		due = append(due, *p)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].message.MessageID < due[j].message.MessageID })
+	return due
+}
+
+// Subscribe returns channel receiving receipts of messages of chat and function ending subscription
+func (d *DeliveryTracker) Subscribe(chatID string) (<-chan *gql.MessageReceipt, func()) {
+	ch := make(chan *gql.MessageReceipt, RECEIPT_EVENTS_BUFFER)
+	return ch, d.subscribers.Subscribe(ch, func(value interface{}) bool {
+		return value.(*gql.MessageReceipt).ChatID == chatID
+	})
+}
+
+// publish passes receipt to subscribers of its chat without blocking
+func (d *DeliveryTracker) publish(receipt *gql.MessageReceipt) {
+	if missed := d.subscribers.Publish(receipt); missed > 0 {
+		logger.WithFields(logger.Fields{
+			"chatID": receipt.ChatID,
+			"missed": missed,
+		}).Warn("DeliveryTracker: subscriber too slow, receipt dropped")
+	}
+}
+
+// sendReceipt informs author that its messages reached given status
+func (c *Client) sendReceipt(author, chatID string, messageIDs []string, status store.ReceiptStatus) {
+	if author == c.userID || len(messageIDs) == 0 {
+		return
+	}
+	if err := c.sendTo(author, &MessageReceipt{ChatID: chatID, MessageIDs: messageIDs, Status: status}); err != nil {
+		logger.WithError(err).WithField("userID", author).Warn("sendReceipt: receipt not sent")
+	}
+}
+
+// handleMessageReceipt saves receipt of participant and stops retrying acknowledged messages
+func (c *Client) handleMessageReceipt(e *Envelope) error {
+	body, ok := e.Body.(*MessageReceipt)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+
+	for _, messageID := range body.MessageIDs {
+		// read message was delivered too
+		c.deliveries.Ack(messageID, e.Source)
+
+		changed, err := c.store.SetReceipt(body.ChatID, messageID, e.Source, body.Status)
+		if err != nil {
+			return err
+		}
+		if changed {
+			c.deliveries.publish(&gql.MessageReceipt{
+				ChatID:    body.ChatID,
+				MessageID: messageID,
+				UserID:    e.Source,
+				Status:    string(body.Status),
+			})
+		}
+	}
+	return nil
+}
+
+// retryDeliveries sends unacknowledged messages again to connected recipients
+// messages for offline recipients wait in spillover
+func (c *Client) retryDeliveries() {
+	for _, p := range c.deliveries.Due(time.Now(), c.IsOnline) {
+		logger.WithFields(logger.Fields{
+			"messageID": p.message.MessageID,
+			"userID":    p.userID,
+			"attempt":   p.attempts,
+		}).Info("retryDeliveries: message not acknowledged, sending again")
+		if err := c.sendTo(p.userID, p.message); err != nil {
+			logger.WithError(err).WithField("userID", p.userID).Warn("retryDeliveries: message not sent")
+		}
+	}
+}
+
+// MarkRead marks messages of other participants up to given message (in order of chat) as read by user
+// and informs their authors, returns number of messages which were not read before
+func (c *Client) MarkRead(chatID, upToMessageID string) (int, error) {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return 0, err
+	}
+	messages, err := tmpChat.Messages()
+	if err != nil {
+		return 0, err
+	}
+	last := -1
+	for i, message := range messages {
+		if message.MessageID == upToMessageID {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		return 0, fmt.Errorf("%w: message %s", store.ErrNotFound, upToMessageID)
+	}
+
+	read := make(map[string][]string) // author : messageIDs
+	count := 0
+	for _, message := range messages[:last+1] {
+		if message.User == c.userID {
+			continue
+		}
+		changed, err := c.store.SetReceipt(chatID, message.MessageID, c.userID, store.RECEIPT_READ)
+		if err != nil {
+			return count, err
+		}
+		if changed {
+			read[message.User] = append(read[message.User], message.MessageID)
+			count++
+		}
+	}
+
+	for author, messageIDs := range read {
+		c.sendReceipt(author, chatID, messageIDs, store.RECEIPT_READ)
+	}
+	return count, nil
+}
+
+// SubscribeReceipts returns channel with receipts of messages of chat and function ending subscription
+func (c *Client) SubscribeReceipts(chatID string) (<-chan *gql.MessageReceipt, func()) {
+	return c.deliveries.Subscribe(chatID)
+}
+
+// withReceipts sets receipts of other participants on own messages
+func (c *Client) withReceipts(messages []*gql.TextMessage) ([]*gql.TextMessage, error) {
+	for _, message := range messages {
+		if message.User != c.userID {
+			continue
+		}
+		receipts, err := c.store.Receipts(message.ChatID, message.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		message.Receipts = []*gql.MessageReceipt{}
+		for userID, status := range receipts {
+			message.Receipts = append(message.Receipts, &gql.MessageReceipt{
+				ChatID:    message.ChatID,
+				MessageID: message.MessageID,
+				UserID:    userID,
+				Status:    string(status),
+			})
+		}
+		sort.Slice(message.Receipts, func(i, j int) bool { return message.Receipts[i].UserID < message.Receipts[j].UserID })
+	}
+	return messages, nil
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"main/chat"
+	"main/gql"
+	"main/store"
+	"testing"
+	"time"
+)
+
+func TestDeliveryTracker(t *testing.T) {
+	d := NewDeliveryTracker()
+	d.Track("u", &ChatMessage{ChatID: "1", MessageID: "a"})
+	d.Track("v", &ChatMessage{ChatID: "1", MessageID: "a"})
+	now := time.Now()
+	online := func(string) bool { return true }
+	if due := d.Due(now, online); len(due) != 0 {
+		t.Errorf("Due() before timeout = %v, want none", due)
+	}
+
+	if !d.Ack("a", "v") || d.Ack("a", "v") {
+		t.Error("Ack() does not report if message was pending")
+	}
+	due := d.Due(now.Add(DELIVERY_RETRY_TIMEOUT), online)
+	if len(due) != 1 || due[0].userID != "u" || due[0].attempts != 1 {
+		t.Fatalf("Due() after timeout = %v, want message for u", due)
+	}
+	// timeout doubles
+	if due := d.Due(now.Add(2*DELIVERY_RETRY_TIMEOUT), online); len(due) != 0 {
+		t.Errorf("Due() right after retry = %v, want none", due)
+	}
+
+	// attempts are not counted while recipient is offline
+	later := now
+	offline := func(string) bool { return false }
+	for i := 0; i < 2*DELIVERY_MAX_ATTEMPTS; i++ {
+		later = later.Add(DELIVERY_RETRY_TIMEOUT << uint(DELIVERY_MAX_ATTEMPTS))
+		if due := d.Due(later, offline); len(due) != 0 || d.Pending() != 1 {
+			t.Fatalf("Due() for offline recipient = %v, %d pending", due, d.Pending())
+		}
+	}
+
+	// given up after DELIVERY_MAX_ATTEMPTS
+	for i := 1; i < DELIVERY_MAX_ATTEMPTS; i++ {
+		later = later.Add(DELIVERY_RETRY_TIMEOUT << uint(DELIVERY_MAX_ATTEMPTS))
+		if due := d.Due(later, online); len(due) != 1 {
+			t.Fatalf("attempt %d: Due() = %v, want message for u", i+1, due)
+		}
+	}
+	if due := d.Due(later.Add(DELIVERY_RETRY_TIMEOUT<<uint(DELIVERY_MAX_ATTEMPTS)), online); len(due) != 0 || d.Pending() != 0 {
+		t.Errorf("Due() after %d attempts = %v, want message given up", DELIVERY_MAX_ATTEMPTS, due)
+	}
+}
+
+func TestClient_receipts(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	link(t, a, b)
+	for _, c := range []*Client{a, b} {
+		tmpChat := chat.NewChat("123", []string{a.userID, b.userID}, c.store)
+		tmpChat.AddKey(1, testChatKey)
+		c.chatList["123"] = tmpChat
+	}
+	receipts, cancel := a.SubscribeReceipts("123")
+	defer cancel()
+
+	// message of a sent to b
+	aChat, _ := a.GetChat("123")
+	message := gql.TextMessage{MessageID: "1", ChatID: "123", User: a.userID, TimeStamp: time.Now().UTC(), Text: "hello"}
+	a.signMessage(&message)
+	encrypted, err := a.encryptMessage(aChat, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aChat.AddMessage(&message); err != nil {
+		t.Fatal(err)
+	}
+	a.deliveries.Track(b.userID, encrypted)
+	if err := a.sendTo(b.userID, encrypted); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// b acknowledges it
+	if err := deliver(t, b, a); err != nil {
+		t.Fatal(err)
+	}
+	if a.deliveries.Pending() != 0 {
+		t.Error("acknowledged message still pending")
+	}
+	expectReceipt := func(status store.ReceiptStatus) {
+		select {
+		case r := <-receipts:
+			if r.MessageID != "1" || r.UserID != b.userID || r.Status != string(status) {
+				t.Errorf("receipt = %+v, want %s by %s", r, status, b.userID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s receipt not published", status)
+		}
+	}
+	expectReceipt(store.RECEIPT_DELIVERED)
+
+	// b reads it, own messages of b are not affected
+	if n, err := b.MarkRead("123", "1"); err != nil || n != 1 {
+		t.Errorf("MarkRead() = %d, %v, want 1", n, err)
+	}
+	if n, err := b.MarkRead("123", "1"); err != nil || n != 0 {
+		t.Errorf("MarkRead() of read messages = %d, %v, want 0", n, err)
+	}
+	if err := deliver(t, b, a); err != nil {
+		t.Fatal(err)
+	}
+	expectReceipt(store.RECEIPT_READ)
+
+	messages, err := a.Messages("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || len(messages[0].Receipts) != 1 || messages[0].Receipts[0].Status != string(store.RECEIPT_READ) {
+		t.Errorf("Messages() = %+v, want message read by %s", messages, b.userID)
+	}
+
+	// receipt of stranger is rejected
+	stranger := newTestClient(t)
+	if err := a.dispatcher.DispatchEnvelope(NewEnvelope(stranger.userID, &MessageReceipt{
+		ChatID: "123", MessageIDs: []string{"1"}, Status: store.RECEIPT_READ,
+	})); err == nil {
+		t.Error("receipt of stranger accepted")
+	}
+}
+
+func TestClient_retryDeliveries(t *testing.T) {
+	c := newTestClient(t)
+	const addr = "tcp://10.5.0.2:7878"
+	c.setAddress("1", addr)
+	message := &ChatMessage{ChatID: "1", MessageID: "a", Epoch: 1, Ciphertext: []byte{1}}
+	c.deliveries.Track("1", message)
+	c.deliveries.Track("2", message)
+
+	// recipient offline, message waits in spillover
+	c.deliveries.pending[deliveryKey{"a", "1"}].next = time.Now()
+	c.retryDeliveries()
+	if n, _ := c.store.OutboxLen("1"); n != 0 {
+		t.Errorf("message for offline recipient spilled again %d times", n)
+	}
+
+	if attempts := c.deliveries.pending[deliveryKey{"a", "1"}].attempts; attempts != 0 {
+		t.Errorf("attempts while recipient offline = %d, want 0", attempts)
+	}
+
+	c.peers.MarkConnected(addr, "1", newPeerLink("1"))
+	c.deliveries.pending[deliveryKey{"a", "1"}].next = time.Now()
+	c.retryDeliveries()
+	select {
+	case e := <-outboxOf(t, c, addr):
+		if m, ok := e.Body.(*ChatMessage); !ok || m.MessageID != "a" {
+			t.Errorf("sent again %v, want message a", e.Body)
+		}
+	default:
+		t.Error("unacknowledged message not sent again")
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"main/gql"
+	"main/identity"
+)
+
+// signed messages:
+// author signs chat ID, message ID, user, time stamp, clock and text of every message,
+// signature and public key of author travel encrypted together with message (see Encryption.go)
+// message is stored only if signature is valid and public key belongs to its user,
+// public key is taken from store (authenticated users) or from message, as user ID is derived from it
+
+// signed together with message, so signature cannot be reused in other context
+const MESSAGE_SIGNATURE_CONTEXT = "arxen-message-v1"
+
+// signedContent returns bytes signed by author of message
+// every field is prefixed with its length, so fields cannot be shifted between each other
+func signedContent(m gql.TextMessage) []byte {
+	content := []byte(MESSAGE_SIGNATURE_CONTEXT)
+	for _, field := range []string{m.ChatID, m.MessageID, m.User} {
+		content = appendField(content, []byte(field))
+	}
+	var timeStamp [8]byte
+	binary.BigEndian.PutUint64(timeStamp[:], uint64(m.TimeStamp.UnixNano()))
+	content = appendField(content, timeStamp[:])
+	// messages written before clocks were introduced have none, their signatures stay valid
+	if m.Clock != 0 {
+		var clock [8]byte
+		binary.BigEndian.PutUint64(clock[:], uint64(m.Clock))
+		content = appendField(content, clock[:])
+	}
+	return appendField(content, []byte(m.Text))
+}
+
+func appendField(content []byte, field []byte) []byte {
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(len(field)))
+	content = append(content, length[:n]...)
+	return append(content, field...)
+}
+
+// signMessage signs message written by this client
+func (c *Client) signMessage(m *gql.TextMessage) {
+	signature := base64.StdEncoding.EncodeToString(c.identity.Sign(signedContent(*m)))
+	m.Signature = &signature
+	m.Verified = true
+}
+
+// messageSignature returns decoded signature of message and public key of its author
+// both are nil for messages stored before messages were signed
+func (c *Client) messageSignature(m gql.TextMessage) ([]byte, []byte) {
+	if m.Signature == nil {
+		return nil, nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(*m.Signature)
+	if err != nil {
+		return nil, nil
+	}
+	publicKey, err := c.publicKeyOf(m.User)
+	if err != nil {
+		return signature, nil
+	}
+	return signature, publicKey
+}
+
+// verifyMessage checks signature of received message and marks it verified
+// authorKey is public key of author sent together with message
+func (c *Client) verifyMessage(m *gql.TextMessage, signature []byte, authorKey []byte) error {
+	if len(signature) == 0 {
+		return fmt.Errorf("%w: message %s is not signed", ErrInvalidMessage, m.MessageID)
+	}
+
+	publicKey, err := c.publicKeyOf(m.User)
+	if err != nil {
+		// not known yet, accept key sent with message if user ID was derived from it
+		if identity.UserIDFromPublicKey(authorKey) != m.User {
+			return fmt.Errorf("%w: unknown key of author %s", ErrInvalidMessage, m.User)
+		}
+		publicKey = ed25519.PublicKey(authorKey)
+		if err := c.store.SetPublicKey(m.User, publicKey); err != nil {
+			logger.WithError(err).WithField("userID", m.User).Error("verifyMessage: cannot save public key")
+		}
+	}
+
+	if !identity.Verify(publicKey, signedContent(*m), signature) {
+		return fmt.Errorf("%w: invalid signature of message %s by %s", ErrInvalidMessage, m.MessageID, m.User)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(signature)
+	m.Signature = &encoded
+	m.Verified = true
+	return nil
+}
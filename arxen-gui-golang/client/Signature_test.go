@@ -0,0 +1,85 @@
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"main/gql"
+	"testing"
+	"time"
+)
+
+func TestClient_verifyMessage(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	stranger := newTestClient(t)
+	link(t, a, b)
+
+	newMessage := func(author *Client) gql.TextMessage {
+		m := gql.TextMessage{MessageID: "1", ChatID: "123", User: author.userID, TimeStamp: time.Now().UTC(), Text: "signed"}
+		author.signMessage(&m)
+		return m
+	}
+	signatureOf := func(m gql.TextMessage) []byte {
+		signature, err := base64.StdEncoding.DecodeString(*m.Signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+
+	m := newMessage(a)
+	m.Verified = false
+	if err := b.verifyMessage(&m, signatureOf(m), nil); err != nil || !m.Verified {
+		t.Errorf("verifyMessage() = %v, verified %v, want valid message", err, m.Verified)
+	}
+
+	// author not known yet, key sent with message is accepted only if user ID is derived from it
+	m = newMessage(stranger)
+	if err := b.verifyMessage(&m, signatureOf(m), a.identity.PublicKey); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("verifyMessage() with key of other user error = %v, want %v", err, ErrInvalidMessage)
+	}
+	if err := b.verifyMessage(&m, signatureOf(m), stranger.identity.PublicKey); err != nil {
+		t.Errorf("verifyMessage() with key of author error = %v", err)
+	}
+	if _, err := b.store.PublicKey(stranger.userID); err != nil {
+		t.Errorf("key of author not saved: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(m *gql.TextMessage) []byte
+	}{
+		{"unsigned", func(m *gql.TextMessage) []byte { return nil }},
+		{"tampered text", func(m *gql.TextMessage) []byte {
+			m.Text = "changed"
+			return signatureOf(*m)
+		}},
+		{"tampered time stamp", func(m *gql.TextMessage) []byte {
+			m.TimeStamp = m.TimeStamp.Add(time.Second)
+			return signatureOf(*m)
+		}},
+		{"tampered clock", func(m *gql.TextMessage) []byte {
+			m.Clock++
+			return signatureOf(*m)
+		}},
+		{"moved to other chat", func(m *gql.TextMessage) []byte {
+			m.ChatID = "456"
+			return signatureOf(*m)
+		}},
+		{"signed by other user", func(m *gql.TextMessage) []byte {
+			forged := *m
+			stranger.signMessage(&forged)
+			return signatureOf(forged)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMessage(a)
+			m.Verified = false
+			signature := tt.modify(&m)
+			if err := b.verifyMessage(&m, signature, a.identity.PublicKey); !errors.Is(err, ErrInvalidMessage) || m.Verified {
+				t.Errorf("verifyMessage() error = %v, verified %v, want %v", err, m.Verified, ErrInvalidMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rsocket/rsocket-go/payload"
+	logger "github.com/sirupsen/logrus"
+	"main/store"
+	"time"
+)
+
+// spillover:
+// envelopes which cannot be put into outbox of peer (peer is offline, its address is unknown
+// or its connection does not keep up) are kept in store per user, so they survive restart of daemon,
+// when user is connected again they are moved to outbox in order they were sent,
+// while user has spilled envelopes new ones are spilled as well, so order is kept
+// drop policy: only SPILLOVER_LIMIT newest envelopes are kept per user
+// and envelopes older than SPILLOVER_TTL are not delivered at all
+
+const (
+	// maximal number of envelopes kept for single user
+	SPILLOVER_LIMIT = 1000
+	// spilled envelopes older than this are dropped
+	SPILLOVER_TTL = 7 * 24 * time.Hour
+	// number of envelopes read from store at once
+	SPILLOVER_BATCH_SIZE = 50
+	// how long flush waits before trying full outbox again
+	SPILLOVER_RETRY_INTERVAL = 100 * time.Millisecond
+)
+
+// spilledEnvelope is envelope encoded by DefaultCodec, kept in store.OutboxEntry
+type spilledEnvelope struct {
+	Metadata []byte `json:"metadata"`
+	Data     []byte `json:"data"`
+}
+
+// spill keeps envelope for user in store until user is connected
+func (c *Client) spill(userID string, e *Envelope) error {
+	payl, err := MarshalEnvelope(e)
+	if err != nil {
+		return err
+	}
+	metadata, _ := payl.Metadata()
+	data, err := json.Marshal(spilledEnvelope{Metadata: metadata, Data: payl.Data()})
+	if err != nil {
+		return err
+	}
+
+	dropped, err := c.store.AppendOutbox(userID, store.OutboxEntry{Data: data, Queued: time.Now().UTC()}, SPILLOVER_LIMIT)
+	if err != nil {
+		return fmt.Errorf("envelope for %s not spilled: %w", userID, err)
+	}
+	if dropped > 0 {
+		logger.WithFields(logger.Fields{"userID": userID, "dropped": dropped}).Warn("spill: too many envelopes for user, the oldest dropped")
+	}
+	return nil
+}
+
+// hasSpillover reports whether there are envelopes spilled for user
+func (c *Client) hasSpillover(userID string) bool {
+	n, err := c.store.OutboxLen(userID)
+	if err != nil {
+		logger.WithError(err).WithField("userID", userID).Error("hasSpillover: cannot read outbox")
+		return false
+	}
+	return n > 0
+}
+
+// flushSpillover moves envelopes spilled for user to outbox of its connection,
+// it stops when user is disconnected and is run again when user connects
+func (c *Client) flushSpillover(userID string) {
+	// single flush per user keeps order of envelopes
+	c.mutex.Lock()
+	if c.flushing == nil {
+		c.flushing = make(map[string]bool)
+	}
+	if c.flushing[userID] {
+		c.mutex.Unlock()
+		return
+	}
+	c.flushing[userID] = true
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.flushing, userID)
+		c.mutex.Unlock()
+	}()
+
+	for {
+		entries, err := c.store.Outbox(userID, SPILLOVER_BATCH_SIZE)
+		if err != nil {
+			logger.WithError(err).WithField("userID", userID).Error("flushSpillover: cannot read outbox")
+			return
+		}
+		if len(entries) == 0 {
+			return
+		}
+
+		var done []uint64
+		for _, entry := range entries {
+			e, err := decodeSpilled(entry)
+			if err != nil {
+				logger.WithError(err).WithField("userID", userID).Warn("flushSpillover: envelope dropped")
+			} else if !c.deliverSpilled(userID, e) {
+				break
+			}
+			done = append(done, entry.ID)
+		}
+
+		if err := c.store.DeleteOutbox(userID, done); err != nil {
+			logger.WithError(err).WithField("userID", userID).Error("flushSpillover: cannot remove delivered envelopes")
+			return
+		}
+		// user disconnected
+		if len(done) < len(entries) {
+			return
+		}
+	}
+}
+
+// decodeSpilled decodes spilled envelope, expired ones are returned with error
+func decodeSpilled(entry store.OutboxEntry) (*Envelope, error) {
+	if time.Since(entry.Queued) > SPILLOVER_TTL {
+		return nil, fmt.Errorf("envelope queued at %v expired", entry.Queued)
+	}
+	var spilled spilledEnvelope
+	if err := json.Unmarshal(entry.Data, &spilled); err != nil {
+		return nil, err
+	}
+	return UnmarshalEnvelope(payload.New(spilled.Data, spilled.Metadata))
+}
+
+// deliverSpilled puts envelope into outbox of user, waiting until there is place in it
+// returns false if user is not connected
+func (c *Client) deliverSpilled(userID string, e *Envelope) bool {
+	for {
+		addr, ok := c.AddressOf(userID)
+		if !ok {
+			return false
+		}
+		err := c.peers.Send(addr, userID, e)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, ErrOutboxFull) {
+			return false
+		}
+		time.Sleep(SPILLOVER_RETRY_INTERVAL)
+	}
+}
+
+// retrySpillover makes sure users with spilled envelopes are being connected and flushed
+func (c *Client) retrySpillover() {
+	users, err := c.store.OutboxUsers()
+	if err != nil {
+		logger.WithError(err).Error("retrySpillover: cannot read outbox")
+		return
+	}
+
+	for _, userID := range users {
+		addr, ok := c.AddressOf(userID)
+		if !ok {
+			c.lookUpParticipant(userID)
+			continue
+		}
+		c.peers.Register(addr)
+		if c.peers.IsConnected(addr) {
+			go c.flushSpillover(userID)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/store"
+	"testing"
+	"time"
+)
+
+// receiveAdverts reads count envelopes from outbox and returns chat IDs of adverts
+func receiveAdverts(t *testing.T, outbox chan *Envelope, count int) []string {
+	var chatIDs []string
+	for i := 0; i < count; i++ {
+		select {
+		case e := <-outbox:
+			chatIDs = append(chatIDs, e.Body.(*ChatAdvert).ChatID)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d envelopes, want %d", i, count)
+		}
+	}
+	return chatIDs
+}
+
+func TestClient_spillOffline(t *testing.T) {
+	c := newTestClient(t)
+	const addr = "tcp://10.5.0.2:7878"
+	c.setAddress("1", addr)
+
+	// peer is offline, sending does not block
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			if err := c.sendTo("1", &ChatAdvert{ChatID: fmt.Sprint(i)}); err != nil {
+				t.Error(err)
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sendTo() blocked on offline peer")
+	}
+	if n, _ := c.store.OutboxLen("1"); n != 3 {
+		t.Fatalf("%d envelopes spilled, want 3", n)
+	}
+	// offline peer is going to be connected
+	if _, known := c.peers.Status(addr); !known {
+		t.Error("peer of spilled envelopes not registered")
+	}
+
+	// back online, envelope sent now waits for spilled ones
+	c.peers.MarkConnected(addr, "1", newPeerLink("1"))
+	if err := c.sendTo("1", &ChatAdvert{ChatID: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	c.flushSpillover("1")
+
+	outbox := outboxOf(t, c, addr)
+	if got := receiveAdverts(t, outbox, 4); fmt.Sprint(got) != "[0 1 2 3]" {
+		t.Errorf("envelopes delivered in order %v, want [0 1 2 3]", got)
+	}
+	if c.hasSpillover("1") {
+		t.Error("delivered envelopes still spilled")
+	}
+
+	// nothing spilled, sent directly
+	if err := c.sendTo("1", &ChatAdvert{ChatID: "4"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(outbox) != 1 {
+		t.Errorf("envelope for connected peer not put into outbox")
+	}
+}
+
+func TestClient_spillFullOutbox(t *testing.T) {
+	c := newTestClient(t)
+	c.peers = NewPeerRegistry(2)
+	const addr = "tcp://10.5.0.2:7878"
+	c.setAddress("1", addr)
+	c.peers.MarkConnected(addr, "1", newPeerLink("1"))
+
+	for i := 0; i < 5; i++ {
+		if err := c.sendTo("1", &ChatAdvert{ChatID: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := c.store.OutboxLen("1"); n != 3 {
+		t.Fatalf("%d envelopes spilled from full outbox, want 3", n)
+	}
+
+	// flush waits for connection to take envelopes
+	go c.flushSpillover("1")
+	if got := receiveAdverts(t, outboxOf(t, c, addr), 5); fmt.Sprint(got) != "[0 1 2 3 4]" {
+		t.Errorf("envelopes delivered in order %v, want [0 1 2 3 4]", got)
+	}
+}
+
+func TestClient_spillUnknownAddress(t *testing.T) {
+	c := newTestClient(t)
+	if err := c.sendTo("2", &ChatAdvert{ChatID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// address learned later, flushed when peer connects
+	c.setAddress("2", "tcp://10.5.0.3:7878")
+	c.retrySpillover()
+	if _, known := c.peers.Status("tcp://10.5.0.3:7878"); !known {
+		t.Fatal("peer with spilled envelopes not registered")
+	}
+	c.peers.MarkConnected("tcp://10.5.0.3:7878", "2", newPeerLink("2"))
+	c.flushSpillover("2")
+	if got := receiveAdverts(t, outboxOf(t, c, "tcp://10.5.0.3:7878"), 1); got[0] != "1" {
+		t.Errorf("delivered %v, want advert of chat 1", got)
+	}
+}
+
+func TestClient_spillExpired(t *testing.T) {
+	c := newTestClient(t)
+	const addr = "tcp://10.5.0.2:7878"
+	c.setAddress("1", addr)
+
+	payl, err := MarshalEnvelope(NewEnvelope(c.userID, &ChatAdvert{ChatID: "old"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, _ := payl.Metadata()
+	data, _ := json.Marshal(spilledEnvelope{Metadata: metadata, Data: payl.Data()})
+	if _, err := c.store.AppendOutbox("1", store.OutboxEntry{Data: data, Queued: time.Now().Add(-SPILLOVER_TTL - time.Hour)}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.store.AppendOutbox("1", store.OutboxEntry{Data: []byte("{"), Queued: time.Now()}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sendTo("1", &ChatAdvert{ChatID: "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.peers.MarkConnected(addr, "1", newPeerLink("1"))
+	c.flushSpillover("1")
+	outbox := outboxOf(t, c, addr)
+	if got := receiveAdverts(t, outbox, 1); got[0] != "new" || len(outbox) != 0 {
+		t.Errorf("delivered %v, want only the new envelope", got)
+	}
+	if c.hasSpillover("1") {
+		t.Error("expired envelopes kept")
+	}
+}
+
+func TestPeerRegistry_Send(t *testing.T) {
+	r := NewPeerRegistry(1)
+	e := NewEnvelope("0", &ChatAdvert{ChatID: "1"})
+
+	if err := r.Send("a", "1", e); !errors.Is(err, ErrUnknownPeer) {
+		t.Errorf("Send() to unknown peer error = %v, want %v", err, ErrUnknownPeer)
+	}
+	r.Register("a")
+	if err := r.Send("a", "1", e); !errors.Is(err, ErrPeerDown) {
+		t.Errorf("Send() to disconnected peer error = %v, want %v", err, ErrPeerDown)
+	}
+	r.MarkConnected("a", "1", newPeerLink("1"))
+	// envelope is not queued to other user which logged in at address of recipient
+	if err := r.Send("a", "2", e); !errors.Is(err, ErrOtherUser) {
+		t.Errorf("Send() to other user error = %v, want %v", err, ErrOtherUser)
+	}
+	if err := r.Send("a", "1", e); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+	if err := r.Send("a", "1", e); !errors.Is(err, ErrOutboxFull) {
+		t.Errorf("Send() to full outbox error = %v, want %v", err, ErrOutboxFull)
+	}
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// typing indicators:
+// TYPING {chatID, typing} is ephemeral message (see sendEphemeral), it is never spilled, stored or synced
+// user typing in chat sends it to connected participants at most once per TYPING_INTERVAL,
+// and at once when it stops typing, posted message ends typing as well
+// participant is typing until it stops, posts message or TYPING_TIMEOUT passes without event
+// users typing in chat are published as CHAT_EVENT_TYPING whenever they change
+
+const (
+	// the shortest time between typing events sent to chat
+	TYPING_INTERVAL = 3 * time.Second
+	// participant without typing event for that long stopped typing
+	TYPING_TIMEOUT = 2 * TYPING_INTERVAL
+)
+
+// typingLimiter limits typing events sent by user, zero value is ready to use
+type typingLimiter struct {
+	mutex sync.Mutex
+	sent  map[string]time.Time // chatID : time of the last typing event
+}
+
+// allow reports if typing event should be sent to chat,
+// stop is sent only when start was, start at most once per TYPING_INTERVAL
+func (l *typingLimiter) allow(chatID string, typing bool, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	last, started := l.sent[chatID]
+	if !typing {
+		delete(l.sent, chatID)
+		return started
+	}
+	if started && now.Sub(last) < TYPING_INTERVAL {
+		return false
+	}
+	if l.sent == nil {
+		l.sent = make(map[string]time.Time)
+	}
+	l.sent[chatID] = now
+	return true
+}
+
+// reset forgets typing in chat, message was posted
+func (l *typingLimiter) reset(chatID string) {
+	l.mutex.Lock()
+	delete(l.sent, chatID)
+	l.mutex.Unlock()
+}
+
+// typingTracker keeps participants typing in chats, zero value is ready to use
+type typingTracker struct {
+	mutex sync.Mutex
+	chats map[string]map[string]*typingEntry // chatID : userID : typing
+}
+
+// typingEntry is typing of single participant
+type typingEntry struct {
+	expiration *time.Timer // guarded by mutex of tracker
+}
+
+// start marks user typing until expire is called after TYPING_TIMEOUT, reports if user was not typing
+func (t *typingTracker) start(chatID, userID string, expire func()) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.chats == nil {
+		t.chats = make(map[string]map[string]*typingEntry)
+	}
+	users, ok := t.chats[chatID]
+	if !ok {
+		users = make(map[string]*typingEntry)
+		t.chats[chatID] = users
+	}
+	previous, typing := users[userID]
+	if typing {
+		previous.expiration.Stop()
+	}
+
+	entry := &typingEntry{}
+	entry.expiration = time.AfterFunc(TYPING_TIMEOUT, func() {
+		// typing could be renewed in the meantime
+		if t.remove(chatID, userID, entry) {
+			expire()
+		}
+	})
+	users[userID] = entry
+	return !typing
+}
+
+// stop marks user not typing, reports if it was typing
+func (t *typingTracker) stop(chatID, userID string) bool {
+	return t.remove(chatID, userID, nil)
+}
+
+// remove removes typing of user if it is entry (or any for nil), reports if it was removed
+func (t *typingTracker) remove(chatID, userID string, entry *typingEntry) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	current, ok := t.chats[chatID][userID]
+	if !ok || (entry != nil && current != entry) {
+		return false
+	}
+	current.expiration.Stop()
+	delete(t.chats[chatID], userID)
+	if len(t.chats[chatID]) == 0 {
+		delete(t.chats, chatID)
+	}
+	return true
+}
+
+// typing returns sorted user IDs of participants typing in chat
+func (t *typingTracker) typing(chatID string) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	users := make([]string, 0, len(t.chats[chatID]))
+	for userID := range t.chats[chatID] {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// SetTyping tells connected participants of chat that user started or stopped typing,
+// reports if event was sent, repeated events are limited
+func (c *Client) SetTyping(chatID string, typing bool) (bool, error) {
+	tmpChat, err := c.GetChat(chatID)
+	if err != nil {
+		return false, err
+	}
+	if !tmpChat.HasParticipant(c.userID) {
+		return false, fmt.Errorf("%w: not a participant of chat %s", ErrUnknownChat, chatID)
+	}
+	if !c.typingSent.allow(chatID, typing, time.Now()) {
+		return false, nil
+	}
+
+	for _, userID := range tmpChat.ClientsIPsList() {
+		if userID == c.userID {
+			continue
+		}
+		if err := c.sendTo(userID, &Typing{ChatID: chatID, Typing: typing}); err != nil {
+			logger.WithError(err).WithField("userID", userID).Debug("SetTyping: typing event not sent")
+		}
+	}
+	return true, nil
+}
+
+// Typing returns sorted user IDs of participants typing in chat
+func (c *Client) Typing(chatID string) []string {
+	return c.typing.typing(chatID)
+}
+
+// stopTyping ends typing of author of posted message
+func (c *Client) stopTyping(chatID, userID string) {
+	if userID == c.userID {
+		c.typingSent.reset(chatID)
+		return
+	}
+	if c.typing.stop(chatID, userID) {
+		c.publishTyping(chatID)
+	}
+}
+
+// publishTyping publishes participants typing in chat
+func (c *Client) publishTyping(chatID string) {
+	c.events.Publish(ChatEvent{Kind: CHAT_EVENT_TYPING, ChatID: chatID, Typing: c.typing.typing(chatID)})
+}
+
+// handleTyping records participant starting or stopping typing
+func (c *Client) handleTyping(e *Envelope) error {
+	body, ok := e.Body.(*Typing)
+	if !ok {
+		return fmt.Errorf("%w: unexpected body %T", ErrInvalidMessage, e.Body)
+	}
+	tmpChat, err := c.GetChat(body.ChatID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if e.Source == c.userID || !isParticipant(tmpChat, e.Source) {
+		return fmt.Errorf("%w: %s does not participate in chat %s", ErrInvalidMessage, e.Source, body.ChatID)
+	}
+
+	var changed bool
+	if body.Typing {
+		changed = c.typing.start(body.ChatID, e.Source, func() { c.publishTyping(body.ChatID) })
+	} else {
+		changed = c.typing.stop(body.ChatID, e.Source)
+	}
+	if changed {
+		c.publishTyping(body.ChatID)
+	}
+	return nil
+}
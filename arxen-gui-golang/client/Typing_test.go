@@ -0,0 +1,137 @@
+package client
+
+import (
+	"errors"
+	"main/gql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTypingLimiter(t *testing.T) {
+	var limiter typingLimiter
+	start := time.Now()
+
+	if limiter.allow("1", false, start) {
+		t.Error("stop allowed before start")
+	}
+	if !limiter.allow("1", true, start) || !limiter.allow("2", true, start) {
+		t.Error("first start not allowed")
+	}
+	if limiter.allow("1", true, start.Add(TYPING_INTERVAL/2)) {
+		t.Error("repeated start allowed within interval")
+	}
+	if !limiter.allow("1", true, start.Add(TYPING_INTERVAL)) {
+		t.Error("start not allowed after interval")
+	}
+	if !limiter.allow("1", false, start) || limiter.allow("1", false, start) {
+		t.Error("stop not allowed once")
+	}
+
+	// posted message ends typing, next start is sent at once
+	limiter.reset("2")
+	if !limiter.allow("2", true, start) {
+		t.Error("start not allowed after reset")
+	}
+}
+
+func TestTypingTracker(t *testing.T) {
+	var tracker typingTracker
+	expired := func() { t.Error("typing expired") }
+
+	if !tracker.start("1", "b", expired) || !tracker.start("1", "a", expired) || tracker.start("1", "a", expired) {
+		t.Error("start did not report new typing once")
+	}
+	if typing := tracker.typing("1"); !reflect.DeepEqual(typing, []string{"a", "b"}) {
+		t.Errorf("typing = %v, want [a b]", typing)
+	}
+
+	// expiration of renewed typing does nothing
+	renewed := tracker.chats["1"]["a"]
+	tracker.start("1", "a", expired)
+	if tracker.remove("1", "a", renewed) {
+		t.Error("expiration of renewed typing removed it")
+	}
+
+	if !tracker.stop("1", "a") || !tracker.stop("1", "b") || tracker.stop("1", "b") {
+		t.Error("stop did not report ended typing once")
+	}
+	if typing := tracker.typing("1"); len(typing) != 0 || len(tracker.chats) != 0 {
+		t.Errorf("typing after stop = %v", typing)
+	}
+}
+
+func TestClient_typing(t *testing.T) {
+	a := newTestClient(t)
+	b := newTestClient(t)
+	offline := newTestClient(t)
+	link(t, a, b)
+	a.setAddress(offline.userID, offline.userIP)
+	newRolesTestChats(a, b, offline)
+	events, cancel := b.SubscribeChatEvents(CHAT_EVENT_TYPING, "123")
+	defer cancel()
+
+	// expectTyping checks users typing published by b
+	expectTyping := func(want ...string) {
+		t.Helper()
+		e := nextEvent(t, events)
+		if len(e.Typing) != len(want) || (len(want) > 0 && !reflect.DeepEqual(e.Typing, want)) {
+			t.Errorf("typing = %v, want %v", e.Typing, want)
+		}
+	}
+
+	if sent, err := a.SetTyping("123", true); err != nil || !sent {
+		t.Fatalf("SetTyping() = %v, %v", sent, err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	expectTyping(a.userID)
+	if typing := b.Typing("123"); !reflect.DeepEqual(typing, []string{a.userID}) {
+		t.Errorf("Typing() = %v", typing)
+	}
+	// typing events are not spilled for participants which are not connected
+	if a.hasSpillover(offline.userID) {
+		t.Error("typing event spilled")
+	}
+
+	if sent, _ := a.SetTyping("123", true); sent {
+		t.Error("repeated typing event sent")
+	}
+	if sent, err := a.SetTyping("123", false); err != nil || !sent {
+		t.Fatalf("SetTyping() of stop = %v, %v", sent, err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	expectTyping()
+
+	// posted message ends typing
+	if _, err := a.SetTyping("123", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(t, a, b); err != nil {
+		t.Fatal(err)
+	}
+	expectTyping(a.userID)
+	message := gql.TextMessage{MessageID: "m", ChatID: "123", User: a.userID, TimeStamp: time.Now().UTC(), Clock: 1, Text: "m"}
+	a.signMessage(&message)
+	encrypted, err := a.encryptMessage(a.chatList["123"], message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.dispatcher.DispatchEnvelope(NewEnvelope(a.userID, encrypted)); err != nil {
+		t.Fatal(err)
+	}
+	expectTyping()
+
+	// users outside of chat cannot type in it
+	stranger := newTestClient(t)
+	err = b.dispatcher.DispatchEnvelope(NewEnvelope(stranger.userID, &Typing{ChatID: "123", Typing: true}))
+	if !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("typing of stranger error = %v, want %v", err, ErrInvalidMessage)
+	}
+	if _, err := a.SetTyping("456", true); !errors.Is(err, ErrUnknownChat) {
+		t.Errorf("SetTyping() in unknown chat error = %v", err)
+	}
+}
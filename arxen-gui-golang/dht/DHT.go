@@ -0,0 +1,482 @@
+package dht
+
+import (
+	"errors"
+	"fmt"
+	logger "github.com/sirupsen/logrus"
+	"net"
+	"sync"
+	"time"
+)
+
+// Kademlia DHT used by client daemon to find current addresses of users
+// (router daemon layer of app stack, see client package)
+// values are opaque for DHT, their format is checked by Validator given by user of DHT,
+// values expire after VALUE_TTL, so publisher has to store them again before
+
+const (
+	// ALPHA is number of parallel requests during lookup
+	ALPHA = 3
+	// RPC_TIMEOUT is time node has to answer request
+	RPC_TIMEOUT = 2 * time.Second
+	// VALUE_TTL is time value is kept since it was stored
+	VALUE_TTL = time.Hour
+)
+
+var (
+	// ErrNotFound is returned when no node has value of key
+	ErrNotFound = errors.New("value not found")
+	// ErrNoContacts is returned when routing table is empty, node has to be bootstrapped first
+	ErrNoContacts = errors.New("no known nodes")
+	// ErrTimeout is returned when node does not answer request
+	ErrTimeout = errors.New("request timed out")
+	// ErrRejected is returned when value is not accepted by Validator
+	ErrRejected = errors.New("value rejected")
+	// ErrClosed is returned by requests after Close
+	ErrClosed = errors.New("dht closed")
+)
+
+// Validator checks value before it is stored or returned by FindValue
+// current is value already stored under key, nil if there is none
+type Validator func(key NodeID, value, current []byte) error
+
+type storedValue struct {
+	data    []byte
+	expires time.Time
+}
+
+// DHT is single node of Kademlia network
+type DHT struct {
+	id        NodeID
+	conn      net.PacketConn
+	table     *routingTable
+	validator Validator
+
+	mutex   sync.Mutex
+	values  map[NodeID]storedValue // key : value
+	pending map[string]chan *rpc   // RPCID : channel waiting for response
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Listen starts node with given ID on UDP address (host:port)
+// validator can be nil, then every value is accepted
+func Listen(id NodeID, address string, validator Validator) (*DHT, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DHT{
+		id:        id,
+		conn:      conn,
+		table:     newRoutingTable(id),
+		validator: validator,
+		values:    make(map[NodeID]storedValue),
+		pending:   make(map[string]chan *rpc),
+		closed:    make(chan struct{}),
+	}
+	go d.serve()
+	return d, nil
+}
+
+// ID returns ID of node
+func (d *DHT) ID() NodeID {
+	return d.id
+}
+
+// Addr returns address node listens on
+func (d *DHT) Addr() net.Addr {
+	return d.conn.LocalAddr()
+}
+
+// Size returns number of nodes in routing table
+func (d *DHT) Size() int {
+	return d.table.size()
+}
+
+// Close stops node
+func (d *DHT) Close() error {
+	err := ErrClosed
+	d.closeOnce.Do(func() {
+		close(d.closed)
+		err = d.conn.Close()
+	})
+	return err
+}
+
+// Bootstrap joins network by contacting nodes at given addresses and looking up own ID
+func (d *DHT) Bootstrap(addresses []string) error {
+	joined := false
+	for _, address := range addresses {
+		if _, err := d.Ping(address); err != nil {
+			logger.WithError(err).WithField("addr", address).Warn("Bootstrap: node not answering")
+			continue
+		}
+		joined = true
+	}
+	if !joined {
+		return fmt.Errorf("%w: no bootstrap node answered", ErrNoContacts)
+	}
+
+	_, err := d.FindNode(d.id)
+	return err
+}
+
+// Ping checks node at address and returns its contact
+func (d *DHT) Ping(address string) (Contact, error) {
+	response, err := d.request(address, &rpc{Type: PING})
+	if err != nil {
+		return Contact{}, err
+	}
+	return Contact{ID: response.Sender, Address: address}, nil
+}
+
+// FindNode returns at most BUCKET_SIZE nodes closest to key, the closest first
+func (d *DHT) FindNode(key NodeID) ([]Contact, error) {
+	contacts, _, err := d.lookup(key, FIND_NODE)
+	return contacts, err
+}
+
+// FindValue returns value stored under key
+func (d *DHT) FindValue(key NodeID) ([]byte, error) {
+	if value, ok := d.localValue(key); ok {
+		return value, nil
+	}
+
+	_, value, err := d.lookup(key, FIND_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return value, nil
+}
+
+// Store stores value on BUCKET_SIZE nodes closest to key and on this node
+// returns error if no other node accepted value
+func (d *DHT) Store(key NodeID, value []byte) error {
+	if err := d.storeLocal(key, value); err != nil {
+		return err
+	}
+
+	contacts, err := d.FindNode(key)
+	if err != nil {
+		return err
+	}
+
+	results := make(chan error, len(contacts))
+	for _, contact := range contacts {
+		go func(contact Contact) {
+			response, err := d.requestContact(contact, &rpc{Type: STORE, Key: &key, Value: value})
+			if err == nil && response.Error != "" {
+				err = fmt.Errorf("%w by %s: %s", ErrRejected, contact.ID, response.Error)
+			}
+			results <- err
+		}(contact)
+	}
+
+	stored := 0
+	var lastErr error
+	for range contacts {
+		if err := <-results; err != nil {
+			lastErr = err
+			continue
+		}
+		stored++
+	}
+	if stored == 0 {
+		return fmt.Errorf("value %s not stored: %w", key, lastErr)
+	}
+	return nil
+}
+
+// lookup finds BUCKET_SIZE nodes closest to key by asking the closest known nodes for even closer ones,
+// for FIND_VALUE it stops at the first valid value
+func (d *DHT) lookup(key NodeID, rpcType string) ([]Contact, []byte, error) {
+	shortlist := d.table.closest(key, BUCKET_SIZE)
+	if len(shortlist) == 0 {
+		return nil, nil, ErrNoContacts
+	}
+
+	seen := map[NodeID]bool{d.id: true}
+	for _, contact := range shortlist {
+		seen[contact.ID] = true
+	}
+	queried := make(map[NodeID]bool)
+
+	type result struct {
+		contact  Contact
+		response *rpc
+		err      error
+	}
+
+	for {
+		// up to ALPHA the closest not queried nodes
+		var batch []Contact
+		for i := 0; i < len(shortlist) && i < BUCKET_SIZE && len(batch) < ALPHA; i++ {
+			if !queried[shortlist[i].ID] {
+				batch = append(batch, shortlist[i])
+			}
+		}
+		// the closest nodes were asked
+		if len(batch) == 0 {
+			break
+		}
+
+		results := make(chan result, len(batch))
+		for _, contact := range batch {
+			queried[contact.ID] = true
+			go func(contact Contact) {
+				response, err := d.requestContact(contact, &rpc{Type: rpcType, Key: &key})
+				results <- result{contact, response, err}
+			}(contact)
+		}
+
+		failed := make(map[NodeID]bool)
+		for range batch {
+			res := <-results
+			if res.err != nil {
+				failed[res.contact.ID] = true
+				continue
+			}
+			if rpcType == FIND_VALUE && res.response.Value != nil {
+				if err := d.validate(key, res.response.Value, nil); err != nil {
+					logger.WithError(err).WithField("nodeID", res.contact.ID).Warn("lookup: invalid value received")
+				} else {
+					return nil, res.response.Value, nil
+				}
+			}
+			for _, contact := range res.response.Contacts {
+				if !seen[contact.ID] {
+					seen[contact.ID] = true
+					shortlist = append(shortlist, contact)
+				}
+			}
+		}
+
+		// nodes not answering are not returned
+		alive := shortlist[:0]
+		for _, contact := range shortlist {
+			if !failed[contact.ID] {
+				alive = append(alive, contact)
+			}
+		}
+		shortlist = alive
+		sortByDistance(key, shortlist)
+	}
+
+	if len(shortlist) > BUCKET_SIZE {
+		shortlist = shortlist[:BUCKET_SIZE]
+	}
+	return shortlist, nil, nil
+}
+
+// serve reads datagrams until node is closed
+func (d *DHT) serve() {
+	buffer := make([]byte, MAX_PACKET_SIZE)
+	for {
+		n, from, err := d.conn.ReadFrom(buffer)
+		if err != nil {
+			select {
+			case <-d.closed:
+				return
+			default:
+			}
+			logger.WithError(err).Warn("serve: cannot read datagram")
+			continue
+		}
+
+		r, err := unmarshalRPC(buffer[:n])
+		if err != nil {
+			logger.WithError(err).WithField("addr", from.String()).Debug("serve: malformed datagram ignored")
+			continue
+		}
+
+		d.seen(Contact{ID: r.Sender, Address: from.String()})
+		if r.Response {
+			d.deliver(r)
+		} else {
+			d.handleRequest(r, from)
+		}
+	}
+}
+
+// seen updates routing table with node which has just sent datagram
+func (d *DHT) seen(contact Contact) {
+	if contact.ID == d.id {
+		return
+	}
+	oldest, ok := d.table.update(contact)
+	if ok {
+		return
+	}
+	// bucket is full, new node replaces the least recently seen one only if it is gone
+	go func() {
+		if _, err := d.requestContact(oldest, &rpc{Type: PING}); errors.Is(err, ErrTimeout) {
+			d.table.replace(oldest, contact)
+		}
+	}()
+}
+
+// deliver passes response to request waiting for it
+func (d *DHT) deliver(r *rpc) {
+	d.mutex.Lock()
+	ch, ok := d.pending[r.RPCID]
+	delete(d.pending, r.RPCID)
+	d.mutex.Unlock()
+
+	if !ok {
+		logger.WithField("rpcID", r.RPCID).Debug("deliver: unexpected response ignored")
+		return
+	}
+	ch <- r
+}
+
+// handleRequest answers request of other node
+func (d *DHT) handleRequest(r *rpc, from net.Addr) {
+	response := &rpc{Type: r.Type, Response: true, RPCID: r.RPCID}
+
+	switch r.Type {
+	case PING:
+	case STORE:
+		if r.Key == nil {
+			return
+		}
+		if err := d.storeLocal(*r.Key, r.Value); err != nil {
+			response.Error = err.Error()
+		}
+	case FIND_NODE, FIND_VALUE:
+		if r.Key == nil {
+			return
+		}
+		if r.Type == FIND_VALUE {
+			if value, ok := d.localValue(*r.Key); ok {
+				response.Value = value
+				break
+			}
+		}
+		for _, contact := range d.table.closest(*r.Key, BUCKET_SIZE+1) {
+			if contact.ID != r.Sender && len(response.Contacts) < BUCKET_SIZE {
+				response.Contacts = append(response.Contacts, contact)
+			}
+		}
+	default:
+		logger.WithField("type", r.Type).Debug("handleRequest: unknown request ignored")
+		return
+	}
+
+	if err := d.send(from, response); err != nil {
+		logger.WithError(err).WithField("addr", from.String()).Warn("handleRequest: cannot send response")
+	}
+}
+
+// request sends request to node at address and waits for its response
+func (d *DHT) request(address string, r *rpc) (*rpc, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	r.RPCID = newRPCID()
+	ch := make(chan *rpc, 1)
+	d.mutex.Lock()
+	d.pending[r.RPCID] = ch
+	d.mutex.Unlock()
+
+	if err := d.send(addr, r); err != nil {
+		d.forget(r.RPCID)
+		return nil, err
+	}
+
+	timer := time.NewTimer(RPC_TIMEOUT)
+	defer timer.Stop()
+	select {
+	case response := <-ch:
+		if response.Type != r.Type {
+			return nil, fmt.Errorf("unexpected %s response to %s from %s", response.Type, r.Type, address)
+		}
+		return response, nil
+	case <-timer.C:
+		d.forget(r.RPCID)
+		return nil, fmt.Errorf("%w: %s to %s", ErrTimeout, r.Type, address)
+	case <-d.closed:
+		return nil, ErrClosed
+	}
+}
+
+// requestContact sends request to known node, node not answering is removed from routing table
+func (d *DHT) requestContact(contact Contact, r *rpc) (*rpc, error) {
+	response, err := d.request(contact.Address, r)
+	if errors.Is(err, ErrTimeout) {
+		d.table.remove(contact.ID)
+	}
+	if err == nil && response.Sender != contact.ID {
+		return nil, fmt.Errorf("node %s answered instead of %s", response.Sender, contact.ID)
+	}
+	return response, err
+}
+
+// forget stops waiting for response
+func (d *DHT) forget(rpcID string) {
+	d.mutex.Lock()
+	delete(d.pending, rpcID)
+	d.mutex.Unlock()
+}
+
+func (d *DHT) send(addr net.Addr, r *rpc) error {
+	r.Sender = d.id
+	data, err := marshalRPC(r)
+	if err != nil {
+		return err
+	}
+	_, err = d.conn.WriteTo(data, addr)
+	return err
+}
+
+// validate checks value with Validator
+func (d *DHT) validate(key NodeID, value, current []byte) error {
+	if len(value) == 0 {
+		return fmt.Errorf("%w: empty value", ErrRejected)
+	}
+	if d.validator == nil {
+		return nil
+	}
+	if err := d.validator(key, value, current); err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return nil
+}
+
+// storeLocal stores value on this node if it is accepted by Validator
+func (d *DHT) storeLocal(key NodeID, value []byte) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := time.Now()
+	for k, stored := range d.values {
+		if now.After(stored.expires) {
+			delete(d.values, k)
+		}
+	}
+
+	if err := d.validate(key, value, d.values[key].data); err != nil {
+		return err
+	}
+	d.values[key] = storedValue{data: append([]byte(nil), value...), expires: now.Add(VALUE_TTL)}
+	return nil
+}
+
+// localValue returns value stored on this node
+func (d *DHT) localValue(key NodeID) ([]byte, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	stored, ok := d.values[key]
+	if !ok || time.Now().After(stored.expires) {
+		return nil, false
+	}
+	return append([]byte(nil), stored.data...), true
+}
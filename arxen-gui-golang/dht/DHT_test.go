@@ -0,0 +1,119 @@
+package dht
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// newTestNetwork starts count nodes on loopback, all bootstrapped from the first one
+func newTestNetwork(t *testing.T, count int, validator Validator) []*DHT {
+	var nodes []*DHT
+	for i := 0; i < count; i++ {
+		node, err := Listen(RandomNodeID(), "127.0.0.1:0", validator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+		if i > 0 {
+			if err := node.Bootstrap([]string{nodes[0].Addr().String()}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return nodes
+}
+
+func closeAll(nodes []*DHT) {
+	for _, node := range nodes {
+		node.Close()
+	}
+}
+
+func TestDHT_findNode(t *testing.T) {
+	nodes := newTestNetwork(t, 30, nil)
+	defer closeAll(nodes)
+
+	// every node is found from every other one
+	for _, target := range []*DHT{nodes[0], nodes[7], nodes[29]} {
+		contacts, err := nodes[13].FindNode(target.ID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != nodes[13] && (len(contacts) == 0 || contacts[0].ID != target.ID() || contacts[0].Address != target.Addr().String()) {
+			t.Errorf("FindNode(%s) = %v, want the node itself first", target.ID(), contacts)
+		}
+	}
+}
+
+func TestDHT_storeAndFindValue(t *testing.T) {
+	nodes := newTestNetwork(t, 30, nil)
+	defer closeAll(nodes)
+
+	key := RandomNodeID()
+	if _, err := nodes[5].FindValue(key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindValue() of missing key error = %v, want %v", err, ErrNotFound)
+	}
+	if err := nodes[5].Store(key, []byte("tcp://10.6.0.2:7878")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, node := range []*DHT{nodes[0], nodes[20], nodes[29]} {
+		value, err := node.FindValue(key)
+		if err != nil || string(value) != "tcp://10.6.0.2:7878" {
+			t.Errorf("FindValue() = %q, %v", value, err)
+		}
+	}
+
+	// publisher leaves, value is still there
+	nodes[5].Close()
+	if value, err := nodes[25].FindValue(key); err != nil || string(value) != "tcp://10.6.0.2:7878" {
+		t.Errorf("FindValue() after publisher left = %q, %v", value, err)
+	}
+}
+
+func TestDHT_validator(t *testing.T) {
+	// values can be only replaced by longer ones
+	validator := func(key NodeID, value, current []byte) error {
+		if len(value) <= len(current) {
+			return errors.New("not newer")
+		}
+		return nil
+	}
+	nodes := newTestNetwork(t, 5, validator)
+	defer closeAll(nodes)
+
+	key := RandomNodeID()
+	if err := nodes[1].Store(key, []byte("bb")); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[2].Store(key, []byte("a")); !errors.Is(err, ErrRejected) {
+		t.Errorf("Store() of rejected value error = %v, want %v", err, ErrRejected)
+	}
+	if value, err := nodes[3].FindValue(key); err != nil || !bytes.Equal(value, []byte("bb")) {
+		t.Errorf("FindValue() = %q, %v, want bb", value, err)
+	}
+}
+
+func TestDHT_bootstrapFailure(t *testing.T) {
+	node, err := Listen(RandomNodeID(), "127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if _, err := node.FindNode(RandomNodeID()); !errors.Is(err, ErrNoContacts) {
+		t.Errorf("FindNode() without contacts error = %v, want %v", err, ErrNoContacts)
+	}
+
+	// nobody listens there
+	dead, err := Listen(RandomNodeID(), "127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := dead.Addr().String()
+	dead.Close()
+	if err := node.Bootstrap([]string{address}); !errors.Is(err, ErrNoContacts) {
+		t.Errorf("Bootstrap() error = %v, want %v", err, ErrNoContacts)
+	}
+}
@@ -0,0 +1,94 @@
+package dht
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// node IDs:
+// every node is identified by 160 bit ID, node of user daemon has ID equal to user ID,
+// keys of values share the same space, so value is stored on nodes closest to its key
+// distance between IDs is their XOR interpreted as unsigned integer
+
+// length of node ID in bytes
+const ID_SIZE = 20
+
+// ErrInvalidID is returned when ID has wrong length or format
+var ErrInvalidID = errors.New("invalid node ID")
+
+// NodeID is ID of node or key of value
+type NodeID [ID_SIZE]byte
+
+// ParseNodeID decodes hex encoded ID, user IDs can be used directly
+func ParseNodeID(s string) (NodeID, error) {
+	var id NodeID
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != ID_SIZE {
+		return id, fmt.Errorf("%w: %q", ErrInvalidID, s)
+	}
+	copy(id[:], data)
+	return id, nil
+}
+
+// RandomNodeID returns random ID, used for refreshing buckets
+func RandomNodeID() NodeID {
+	var id NodeID
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (id NodeID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id NodeID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *NodeID) UnmarshalText(text []byte) error {
+	parsed, err := ParseNodeID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// Xor returns distance between IDs
+func (id NodeID) Xor(other NodeID) NodeID {
+	var distance NodeID
+	for i := range id {
+		distance[i] = id[i] ^ other[i]
+	}
+	return distance
+}
+
+// Less compares IDs (and distances) as big endian integers
+func (id NodeID) Less(other NodeID) bool {
+	for i := range id {
+		if id[i] != other[i] {
+			return id[i] < other[i]
+		}
+	}
+	return false
+}
+
+// prefixLen returns number of leading zero bits, ID_SIZE*8 for zero ID
+func (id NodeID) prefixLen() int {
+	for i, b := range id {
+		if b != 0 {
+			return i*8 + bits.LeadingZeros8(b)
+		}
+	}
+	return ID_SIZE * 8
+}
+
+// closer reports whether a is closer to target than b
+func closer(target, a, b NodeID) bool {
+	return a.Xor(target).Less(b.Xor(target))
+}
@@ -0,0 +1,42 @@
+package dht
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseNodeID(t *testing.T) {
+	id := RandomNodeID()
+	if parsed, err := ParseNodeID(id.String()); err != nil || parsed != id {
+		t.Errorf("ParseNodeID(%s) = %s, %v", id, parsed, err)
+	}
+	for _, s := range []string{"", "zz", "0102"} {
+		if _, err := ParseNodeID(s); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("ParseNodeID(%q) error = %v, want %v", s, err, ErrInvalidID)
+		}
+	}
+}
+
+func TestNodeID_distance(t *testing.T) {
+	var zero, one, high NodeID
+	one[ID_SIZE-1] = 1
+	high[0] = 0x10
+
+	tests := []struct {
+		a, b      NodeID
+		prefixLen int
+	}{
+		{zero, zero, ID_SIZE * 8},
+		{zero, one, ID_SIZE*8 - 1},
+		{one, high, 3},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Xor(tt.b).prefixLen(); got != tt.prefixLen {
+			t.Errorf("prefixLen(%s ^ %s) = %d, want %d", tt.a, tt.b, got, tt.prefixLen)
+		}
+	}
+
+	if !closer(zero, one, high) || closer(zero, high, one) || closer(high, one, high) {
+		t.Error("closer() does not compare XOR distances")
+	}
+}
@@ -0,0 +1,58 @@
+package dht
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// RPCs:
+// every RPC is single JSON encoded UDP datagram, response carries RPCID of request
+// PING       - checks if node is alive
+// STORE      - stores Value under Key
+// FIND_NODE  - returns BUCKET_SIZE contacts closest to Key known to node
+// FIND_VALUE - returns Value stored under Key or the same as FIND_NODE if there is none
+// sender of every RPC is added to routing table of receiver with address datagram came from
+
+// RPC types
+const (
+	PING       = "PING"
+	STORE      = "STORE"
+	FIND_NODE  = "FIND_NODE"
+	FIND_VALUE = "FIND_VALUE"
+)
+
+// maximal size of datagram, responses with BUCKET_SIZE contacts fit easily
+const MAX_PACKET_SIZE = 8192
+
+type rpc struct {
+	Type     string    `json:"type"`
+	Response bool      `json:"response,omitempty"`
+	RPCID    string    `json:"rpcID"`
+	Sender   NodeID    `json:"sender"`
+	Key      *NodeID   `json:"key,omitempty"`
+	Value    []byte    `json:"value,omitempty"`
+	Contacts []Contact `json:"contacts,omitempty"`
+	Error    string    `json:"error,omitempty"` // reason of rejected STORE
+}
+
+// newRPCID returns random ID of request
+func newRPCID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+func marshalRPC(r *rpc) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func unmarshalRPC(data []byte) (*rpc, error) {
+	var r rpc
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
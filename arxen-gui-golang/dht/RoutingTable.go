@@ -0,0 +1,138 @@
+package dht
+
+import (
+	"sort"
+	"sync"
+)
+
+// routing table:
+// k-bucket i keeps up to BUCKET_SIZE contacts which IDs share exactly i leading bits with own ID,
+// contacts in bucket are ordered from the least recently seen,
+// when bucket is full the least recently seen contact is pinged and replaced only if it does not answer
+// (long living nodes are preferred, they are likely to stay online)
+
+// maximal number of contacts in bucket, also number of nodes value is stored on (k)
+const BUCKET_SIZE = 20
+
+// Contact is node known to routing table
+type Contact struct {
+	ID      NodeID `json:"id"`
+	Address string `json:"address"` // UDP host:port
+}
+
+type routingTable struct {
+	mutex   sync.Mutex
+	self    NodeID
+	buckets [ID_SIZE * 8][]Contact
+}
+
+func newRoutingTable(self NodeID) *routingTable {
+	return &routingTable{self: self}
+}
+
+// bucketIndex returns index of bucket for ID, -1 for own ID
+func (t *routingTable) bucketIndex(id NodeID) int {
+	index := t.self.Xor(id).prefixLen()
+	if index == ID_SIZE*8 {
+		return -1
+	}
+	return index
+}
+
+// update marks contact as just seen
+// returns false and the least recently seen contact of bucket if there is no place for new contact
+func (t *routingTable) update(contact Contact) (Contact, bool) {
+	index := t.bucketIndex(contact.ID)
+	if index < 0 {
+		return Contact{}, true
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	bucket := t.buckets[index]
+	for i, known := range bucket {
+		if known.ID == contact.ID {
+			// move to the end, address could change
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			t.buckets[index] = append(bucket, contact)
+			return Contact{}, true
+		}
+	}
+	if len(bucket) < BUCKET_SIZE {
+		t.buckets[index] = append(bucket, contact)
+		return Contact{}, true
+	}
+	return bucket[0], false
+}
+
+// replace removes stale contact and adds new one on its place, if stale contact is still there
+func (t *routingTable) replace(stale, contact Contact) {
+	index := t.bucketIndex(stale.ID)
+	if index < 0 {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	bucket := t.buckets[index]
+	for i, known := range bucket {
+		if known.ID == stale.ID {
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			t.buckets[index] = append(bucket, contact)
+			return
+		}
+	}
+}
+
+// remove forgets contact not answering requests
+func (t *routingTable) remove(id NodeID) {
+	index := t.bucketIndex(id)
+	if index < 0 {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	bucket := t.buckets[index]
+	for i, known := range bucket {
+		if known.ID == id {
+			t.buckets[index] = append(bucket[:i], bucket[i+1:]...)
+			return
+		}
+	}
+}
+
+// closest returns at most count known contacts closest to target, the closest first
+func (t *routingTable) closest(target NodeID, count int) []Contact {
+	t.mutex.Lock()
+	var contacts []Contact
+	for _, bucket := range t.buckets {
+		contacts = append(contacts, bucket...)
+	}
+	t.mutex.Unlock()
+
+	sortByDistance(target, contacts)
+	if len(contacts) > count {
+		contacts = contacts[:count]
+	}
+	return contacts
+}
+
+// size returns number of known contacts
+func (t *routingTable) size() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	size := 0
+	for _, bucket := range t.buckets {
+		size += len(bucket)
+	}
+	return size
+}
+
+func sortByDistance(target NodeID, contacts []Contact) {
+	sort.Slice(contacts, func(i, j int) bool { return closer(target, contacts[i].ID, contacts[j].ID) })
+}
@@ -0,0 +1,72 @@
+package dht
+
+import (
+	"fmt"
+	"testing"
+)
+
+// idWithPrefix returns ID sharing exactly prefixLen leading bits with zero ID, n makes IDs different
+func idWithPrefix(prefixLen int, n byte) NodeID {
+	var id NodeID
+	id[prefixLen/8] = 0x80 >> (prefixLen % 8)
+	id[ID_SIZE-1] |= n
+	return id
+}
+
+func TestRoutingTable_update(t *testing.T) {
+	table := newRoutingTable(NodeID{})
+
+	// own ID is never stored
+	table.update(Contact{ID: NodeID{}})
+	if table.size() != 0 {
+		t.Errorf("size() = %d after adding own ID, want 0", table.size())
+	}
+
+	for i := 0; i < BUCKET_SIZE; i++ {
+		if _, ok := table.update(Contact{ID: idWithPrefix(0, byte(i)), Address: fmt.Sprint(i)}); !ok {
+			t.Fatalf("update() of contact %d rejected", i)
+		}
+	}
+	// seen again, becomes the most recently seen
+	table.update(Contact{ID: idWithPrefix(0, 0), Address: "new"})
+
+	newcomer := Contact{ID: idWithPrefix(0, byte(BUCKET_SIZE))}
+	oldest, ok := table.update(newcomer)
+	if ok || oldest.ID != idWithPrefix(0, 1) {
+		t.Fatalf("update() of contact to full bucket = %v, %v, want the least recently seen contact 1", oldest, ok)
+	}
+	table.replace(oldest, newcomer)
+
+	bucket := table.buckets[0]
+	if len(bucket) != BUCKET_SIZE || bucket[len(bucket)-1].ID != newcomer.ID {
+		t.Errorf("bucket after replace ends with %v, want newcomer", bucket[len(bucket)-1])
+	}
+	if bucket[len(bucket)-2].Address != "new" {
+		t.Errorf("address of updated contact = %q, want new", bucket[len(bucket)-2].Address)
+	}
+
+	table.remove(newcomer.ID)
+	if table.size() != BUCKET_SIZE-1 {
+		t.Errorf("size() after remove = %d, want %d", table.size(), BUCKET_SIZE-1)
+	}
+}
+
+func TestRoutingTable_closest(t *testing.T) {
+	table := newRoutingTable(NodeID{})
+	for prefixLen := 0; prefixLen < 10; prefixLen++ {
+		table.update(Contact{ID: idWithPrefix(prefixLen, 0)})
+	}
+
+	target := idWithPrefix(5, 1)
+	closest := table.closest(target, 3)
+	// the same bucket as target first, then contacts sharing 5 bits with target ordered by the rest
+	want := []NodeID{idWithPrefix(5, 0), idWithPrefix(9, 0), idWithPrefix(8, 0)}
+	if len(closest) != len(want) {
+		t.Fatalf("closest() returned %d contacts, want %d", len(closest), len(want))
+	}
+	for i := range want {
+		if closest[i].ID != want[i] {
+			t.Errorf("closest()[%d] = %s, want %s", i, closest[i].ID, want[i])
+		}
+	}
+}
@@ -158,10 +158,1093 @@ func (m *Message) GetTimestamp() *timestamp.Timestamp {
 	return nil
 }
 
+type Header struct {
+	Version              uint32   `protobuf:"varint,1,opt,name=Version,proto3" json:"Version,omitempty"`
+	Type                 string   `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"`
+	Source               string   `protobuf:"bytes,3,opt,name=Source,proto3" json:"Source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Header) Reset()         { *m = Header{} }
+func (m *Header) String() string { return proto.CompactTextString(m) }
+func (*Header) ProtoMessage()    {}
+func (*Header) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{3}
+}
+
+func (m *Header) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Header.Unmarshal(m, b)
+}
+func (m *Header) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Header.Marshal(b, m, deterministic)
+}
+func (m *Header) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Header.Merge(m, src)
+}
+func (m *Header) XXX_Size() int {
+	return xxx_messageInfo_Header.Size(m)
+}
+func (m *Header) XXX_DiscardUnknown() {
+	xxx_messageInfo_Header.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Header proto.InternalMessageInfo
+
+func (m *Header) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Header) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Header) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+// CHAT_MESSAGE
+// User, TimeStamp and Text are sent encrypted in Ciphertext with chat key of Epoch
+type ChatMessage struct {
+	ChatID               string               `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	MessageID            string               `protobuf:"bytes,2,opt,name=MessageID,proto3" json:"MessageID,omitempty"`
+	User                 string               `protobuf:"bytes,3,opt,name=User,proto3" json:"User,omitempty"`
+	TimeStamp            *timestamp.Timestamp `protobuf:"bytes,4,opt,name=TimeStamp,proto3" json:"TimeStamp,omitempty"`
+	Text                 string               `protobuf:"bytes,5,opt,name=Text,proto3" json:"Text,omitempty"`
+	Epoch                uint32               `protobuf:"varint,6,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+	Ciphertext           []byte               `protobuf:"bytes,7,opt,name=Ciphertext,proto3" json:"Ciphertext,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ChatMessage) Reset()         { *m = ChatMessage{} }
+func (m *ChatMessage) String() string { return proto.CompactTextString(m) }
+func (*ChatMessage) ProtoMessage()    {}
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{4}
+}
+
+func (m *ChatMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatMessage.Unmarshal(m, b)
+}
+func (m *ChatMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatMessage.Marshal(b, m, deterministic)
+}
+func (m *ChatMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatMessage.Merge(m, src)
+}
+func (m *ChatMessage) XXX_Size() int {
+	return xxx_messageInfo_ChatMessage.Size(m)
+}
+func (m *ChatMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatMessage proto.InternalMessageInfo
+
+func (m *ChatMessage) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *ChatMessage) GetMessageID() string {
+	if m != nil {
+		return m.MessageID
+	}
+	return ""
+}
+
+func (m *ChatMessage) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *ChatMessage) GetTimeStamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.TimeStamp
+	}
+	return nil
+}
+
+func (m *ChatMessage) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+func (m *ChatMessage) GetEpoch() uint32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ChatMessage) GetCiphertext() []byte {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
+// CHAT_ADVERT_REQUEST
+type ChatAdvertRequest struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChatAdvertRequest) Reset()         { *m = ChatAdvertRequest{} }
+func (m *ChatAdvertRequest) String() string { return proto.CompactTextString(m) }
+func (*ChatAdvertRequest) ProtoMessage()    {}
+func (*ChatAdvertRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{5}
+}
+
+func (m *ChatAdvertRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatAdvertRequest.Unmarshal(m, b)
+}
+func (m *ChatAdvertRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatAdvertRequest.Marshal(b, m, deterministic)
+}
+func (m *ChatAdvertRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatAdvertRequest.Merge(m, src)
+}
+func (m *ChatAdvertRequest) XXX_Size() int {
+	return xxx_messageInfo_ChatAdvertRequest.Size(m)
+}
+func (m *ChatAdvertRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatAdvertRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatAdvertRequest proto.InternalMessageInfo
+
+func (m *ChatAdvertRequest) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+// CHAT_ADVERT
+type ChatAdvert struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	ChatName             string   `protobuf:"bytes,2,opt,name=ChatName,proto3" json:"ChatName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChatAdvert) Reset()         { *m = ChatAdvert{} }
+func (m *ChatAdvert) String() string { return proto.CompactTextString(m) }
+func (*ChatAdvert) ProtoMessage()    {}
+func (*ChatAdvert) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{6}
+}
+
+func (m *ChatAdvert) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatAdvert.Unmarshal(m, b)
+}
+func (m *ChatAdvert) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatAdvert.Marshal(b, m, deterministic)
+}
+func (m *ChatAdvert) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatAdvert.Merge(m, src)
+}
+func (m *ChatAdvert) XXX_Size() int {
+	return xxx_messageInfo_ChatAdvert.Size(m)
+}
+func (m *ChatAdvert) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatAdvert.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatAdvert proto.InternalMessageInfo
+
+func (m *ChatAdvert) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *ChatAdvert) GetChatName() string {
+	if m != nil {
+		return m.ChatName
+	}
+	return ""
+}
+
+// CHAT_PARTICIPANTS_REQUEST
+type ChatParticipantsRequest struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChatParticipantsRequest) Reset()         { *m = ChatParticipantsRequest{} }
+func (m *ChatParticipantsRequest) String() string { return proto.CompactTextString(m) }
+func (*ChatParticipantsRequest) ProtoMessage()    {}
+func (*ChatParticipantsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{7}
+}
+
+func (m *ChatParticipantsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatParticipantsRequest.Unmarshal(m, b)
+}
+func (m *ChatParticipantsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatParticipantsRequest.Marshal(b, m, deterministic)
+}
+func (m *ChatParticipantsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatParticipantsRequest.Merge(m, src)
+}
+func (m *ChatParticipantsRequest) XXX_Size() int {
+	return xxx_messageInfo_ChatParticipantsRequest.Size(m)
+}
+func (m *ChatParticipantsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatParticipantsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatParticipantsRequest proto.InternalMessageInfo
+
+func (m *ChatParticipantsRequest) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+// CHAT_PARTICIPANTS_RESPONSE
+// Participants are user IDs, Addresses maps user ID to its last known address
+// Owner, Admins and Versions are roles of participants (arxen-gui-golang/chat/Roles.go)
+// Creation is creation of chat signed by Owner, History are all signed changes of roles
+// ChatName, ChatAvatar and MetadataVersions are metadata of chat (arxen-gui-golang/chat/Metadata.go)
+type ChatParticipantsResponse struct {
+	ChatID               string                    `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	Participants         []string                  `protobuf:"bytes,2,rep,name=Participants,proto3" json:"Participants,omitempty"`
+	Addresses            map[string]string         `protobuf:"bytes,3,rep,name=Addresses,proto3" json:"Addresses,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Key                  *ChatKey                  `protobuf:"bytes,4,opt,name=Key,proto3" json:"Key,omitempty"`
+	Owner                string                    `protobuf:"bytes,5,opt,name=Owner,proto3" json:"Owner,omitempty"`
+	Admins               []string                  `protobuf:"bytes,6,rep,name=Admins,proto3" json:"Admins,omitempty"`
+	Versions             map[string]*MemberVersion `protobuf:"bytes,7,rep,name=Versions,proto3" json:"Versions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ChatName             string                    `protobuf:"bytes,8,opt,name=ChatName,proto3" json:"ChatName,omitempty"`
+	ChatAvatar           string                    `protobuf:"bytes,9,opt,name=ChatAvatar,proto3" json:"ChatAvatar,omitempty"`
+	MetadataVersions     map[string]*MemberVersion `protobuf:"bytes,10,rep,name=MetadataVersions,proto3" json:"MetadataVersions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	PublicKeys           map[string][]byte         `protobuf:"bytes,11,rep,name=PublicKeys,proto3" json:"PublicKeys,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Creation             *MemberVersion            `protobuf:"bytes,12,opt,name=Creation,proto3" json:"Creation,omitempty"`
+	History              []*MemberVersion          `protobuf:"bytes,13,rep,name=History,proto3" json:"History,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *ChatParticipantsResponse) Reset()         { *m = ChatParticipantsResponse{} }
+func (m *ChatParticipantsResponse) String() string { return proto.CompactTextString(m) }
+func (*ChatParticipantsResponse) ProtoMessage()    {}
+func (*ChatParticipantsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{8}
+}
+
+func (m *ChatParticipantsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatParticipantsResponse.Unmarshal(m, b)
+}
+func (m *ChatParticipantsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatParticipantsResponse.Marshal(b, m, deterministic)
+}
+func (m *ChatParticipantsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatParticipantsResponse.Merge(m, src)
+}
+func (m *ChatParticipantsResponse) XXX_Size() int {
+	return xxx_messageInfo_ChatParticipantsResponse.Size(m)
+}
+func (m *ChatParticipantsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatParticipantsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatParticipantsResponse proto.InternalMessageInfo
+
+func (m *ChatParticipantsResponse) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *ChatParticipantsResponse) GetParticipants() []string {
+	if m != nil {
+		return m.Participants
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetAddresses() map[string]string {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetKey() *ChatKey {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *ChatParticipantsResponse) GetAdmins() []string {
+	if m != nil {
+		return m.Admins
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetVersions() map[string]*MemberVersion {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetChatName() string {
+	if m != nil {
+		return m.ChatName
+	}
+	return ""
+}
+
+func (m *ChatParticipantsResponse) GetChatAvatar() string {
+	if m != nil {
+		return m.ChatAvatar
+	}
+	return ""
+}
+
+func (m *ChatParticipantsResponse) GetMetadataVersions() map[string]*MemberVersion {
+	if m != nil {
+		return m.MetadataVersions
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetPublicKeys() map[string][]byte {
+	if m != nil {
+		return m.PublicKeys
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetCreation() *MemberVersion {
+	if m != nil {
+		return m.Creation
+	}
+	return nil
+}
+
+func (m *ChatParticipantsResponse) GetHistory() []*MemberVersion {
+	if m != nil {
+		return m.History
+	}
+	return nil
+}
+
+// change of role of chat participant, UserID is set in History
+type MemberVersion struct {
+	Clock                int64    `protobuf:"varint,1,opt,name=Clock,proto3" json:"Clock,omitempty"`
+	Actor                string   `protobuf:"bytes,2,opt,name=Actor,proto3" json:"Actor,omitempty"`
+	Action               string   `protobuf:"bytes,3,opt,name=Action,proto3" json:"Action,omitempty"`
+	Signature            []byte   `protobuf:"bytes,4,opt,name=Signature,proto3" json:"Signature,omitempty"`
+	UserID               string   `protobuf:"bytes,5,opt,name=UserID,proto3" json:"UserID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MemberVersion) Reset()         { *m = MemberVersion{} }
+func (m *MemberVersion) String() string { return proto.CompactTextString(m) }
+func (*MemberVersion) ProtoMessage()    {}
+func (*MemberVersion) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{9}
+}
+
+func (m *MemberVersion) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MemberVersion.Unmarshal(m, b)
+}
+func (m *MemberVersion) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MemberVersion.Marshal(b, m, deterministic)
+}
+func (m *MemberVersion) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MemberVersion.Merge(m, src)
+}
+func (m *MemberVersion) XXX_Size() int {
+	return xxx_messageInfo_MemberVersion.Size(m)
+}
+func (m *MemberVersion) XXX_DiscardUnknown() {
+	xxx_messageInfo_MemberVersion.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MemberVersion proto.InternalMessageInfo
+
+func (m *MemberVersion) GetClock() int64 {
+	if m != nil {
+		return m.Clock
+	}
+	return 0
+}
+
+func (m *MemberVersion) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *MemberVersion) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *MemberVersion) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *MemberVersion) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+// HISTORY_SYNC_REQUEST
+type HistorySyncRequest struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	SinceMessageID       string   `protobuf:"bytes,2,opt,name=SinceMessageID,proto3" json:"SinceMessageID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HistorySyncRequest) Reset()         { *m = HistorySyncRequest{} }
+func (m *HistorySyncRequest) String() string { return proto.CompactTextString(m) }
+func (*HistorySyncRequest) ProtoMessage()    {}
+func (*HistorySyncRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{10}
+}
+
+func (m *HistorySyncRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HistorySyncRequest.Unmarshal(m, b)
+}
+func (m *HistorySyncRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HistorySyncRequest.Marshal(b, m, deterministic)
+}
+func (m *HistorySyncRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistorySyncRequest.Merge(m, src)
+}
+func (m *HistorySyncRequest) XXX_Size() int {
+	return xxx_messageInfo_HistorySyncRequest.Size(m)
+}
+func (m *HistorySyncRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistorySyncRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistorySyncRequest proto.InternalMessageInfo
+
+func (m *HistorySyncRequest) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *HistorySyncRequest) GetSinceMessageID() string {
+	if m != nil {
+		return m.SinceMessageID
+	}
+	return ""
+}
+
+// HISTORY_SYNC_RESPONSE
+type HistorySyncResponse struct {
+	ChatID               string         `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	Messages             []*ChatMessage `protobuf:"bytes,2,rep,name=Messages,proto3" json:"Messages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *HistorySyncResponse) Reset()         { *m = HistorySyncResponse{} }
+func (m *HistorySyncResponse) String() string { return proto.CompactTextString(m) }
+func (*HistorySyncResponse) ProtoMessage()    {}
+func (*HistorySyncResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{11}
+}
+
+func (m *HistorySyncResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HistorySyncResponse.Unmarshal(m, b)
+}
+func (m *HistorySyncResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HistorySyncResponse.Marshal(b, m, deterministic)
+}
+func (m *HistorySyncResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistorySyncResponse.Merge(m, src)
+}
+func (m *HistorySyncResponse) XXX_Size() int {
+	return xxx_messageInfo_HistorySyncResponse.Size(m)
+}
+func (m *HistorySyncResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistorySyncResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistorySyncResponse proto.InternalMessageInfo
+
+func (m *HistorySyncResponse) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *HistorySyncResponse) GetMessages() []*ChatMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+// CHAT_KEY
+// SealedKey is chat key sealed (NaCl box) for recipient
+type ChatKey struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	Epoch                uint32   `protobuf:"varint,2,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+	SealedKey            []byte   `protobuf:"bytes,3,opt,name=SealedKey,proto3" json:"SealedKey,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChatKey) Reset()         { *m = ChatKey{} }
+func (m *ChatKey) String() string { return proto.CompactTextString(m) }
+func (*ChatKey) ProtoMessage()    {}
+func (*ChatKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{12}
+}
+
+func (m *ChatKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatKey.Unmarshal(m, b)
+}
+func (m *ChatKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatKey.Marshal(b, m, deterministic)
+}
+func (m *ChatKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatKey.Merge(m, src)
+}
+func (m *ChatKey) XXX_Size() int {
+	return xxx_messageInfo_ChatKey.Size(m)
+}
+func (m *ChatKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatKey proto.InternalMessageInfo
+
+func (m *ChatKey) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *ChatKey) GetEpoch() uint32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ChatKey) GetSealedKey() []byte {
+	if m != nil {
+		return m.SealedKey
+	}
+	return nil
+}
+
+// keys of epoch missing to decrypt message of participant, see arxen-gui-golang/client/Encryption.go
+type ChatKeyRequest struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	Epoch                uint32   `protobuf:"varint,2,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChatKeyRequest) Reset()         { *m = ChatKeyRequest{} }
+func (m *ChatKeyRequest) String() string { return proto.CompactTextString(m) }
+func (*ChatKeyRequest) ProtoMessage()    {}
+func (*ChatKeyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{13}
+}
+
+func (m *ChatKeyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatKeyRequest.Unmarshal(m, b)
+}
+func (m *ChatKeyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatKeyRequest.Marshal(b, m, deterministic)
+}
+func (m *ChatKeyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatKeyRequest.Merge(m, src)
+}
+func (m *ChatKeyRequest) XXX_Size() int {
+	return xxx_messageInfo_ChatKeyRequest.Size(m)
+}
+func (m *ChatKeyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatKeyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatKeyRequest proto.InternalMessageInfo
+
+func (m *ChatKeyRequest) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *ChatKeyRequest) GetEpoch() uint32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+// MESSAGE_RECEIPT
+// sent to author of messages when they are delivered or read, Status is "delivered" or "read"
+type MessageReceipt struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	MessageIDs           []string `protobuf:"bytes,2,rep,name=MessageIDs,proto3" json:"MessageIDs,omitempty"`
+	Status               string   `protobuf:"bytes,3,opt,name=Status,proto3" json:"Status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MessageReceipt) Reset()         { *m = MessageReceipt{} }
+func (m *MessageReceipt) String() string { return proto.CompactTextString(m) }
+func (*MessageReceipt) ProtoMessage()    {}
+func (*MessageReceipt) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{14}
+}
+
+func (m *MessageReceipt) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MessageReceipt.Unmarshal(m, b)
+}
+func (m *MessageReceipt) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MessageReceipt.Marshal(b, m, deterministic)
+}
+func (m *MessageReceipt) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MessageReceipt.Merge(m, src)
+}
+func (m *MessageReceipt) XXX_Size() int {
+	return xxx_messageInfo_MessageReceipt.Size(m)
+}
+func (m *MessageReceipt) XXX_DiscardUnknown() {
+	xxx_messageInfo_MessageReceipt.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MessageReceipt proto.InternalMessageInfo
+
+func (m *MessageReceipt) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *MessageReceipt) GetMessageIDs() []string {
+	if m != nil {
+		return m.MessageIDs
+	}
+	return nil
+}
+
+func (m *MessageReceipt) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+// CHAT_MEMBERSHIP
+// change of chat participants sent to all of them, Action is "join", "leave", "kick", "promote" or "demote"
+// Actor signs change stamped with Clock of chat, see arxen-gui-golang/client/Membership.go
+type ChatMembership struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	Action               string   `protobuf:"bytes,2,opt,name=Action,proto3" json:"Action,omitempty"`
+	UserID               string   `protobuf:"bytes,3,opt,name=UserID,proto3" json:"UserID,omitempty"`
+	Actor                string   `protobuf:"bytes,5,opt,name=Actor,proto3" json:"Actor,omitempty"`
+	Clock                int64    `protobuf:"varint,6,opt,name=Clock,proto3" json:"Clock,omitempty"`
+	Signature            []byte   `protobuf:"bytes,7,opt,name=Signature,proto3" json:"Signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChatMembership) Reset()         { *m = ChatMembership{} }
+func (m *ChatMembership) String() string { return proto.CompactTextString(m) }
+func (*ChatMembership) ProtoMessage()    {}
+func (*ChatMembership) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{15}
+}
+
+func (m *ChatMembership) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatMembership.Unmarshal(m, b)
+}
+func (m *ChatMembership) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatMembership.Marshal(b, m, deterministic)
+}
+func (m *ChatMembership) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatMembership.Merge(m, src)
+}
+func (m *ChatMembership) XXX_Size() int {
+	return xxx_messageInfo_ChatMembership.Size(m)
+}
+func (m *ChatMembership) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatMembership.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatMembership proto.InternalMessageInfo
+
+func (m *ChatMembership) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *ChatMembership) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *ChatMembership) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *ChatMembership) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *ChatMembership) GetClock() int64 {
+	if m != nil {
+		return m.Clock
+	}
+	return 0
+}
+
+func (m *ChatMembership) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// CHAT_METADATA
+// change of name or avatar of chat sent to all participants, Field is "name" or "avatar"
+// Actor signs change stamped with Clock of chat, see arxen-gui-golang/client/Metadata.go
+type ChatMetadata struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	Field                string   `protobuf:"bytes,2,opt,name=Field,proto3" json:"Field,omitempty"`
+	Value                string   `protobuf:"bytes,3,opt,name=Value,proto3" json:"Value,omitempty"`
+	Actor                string   `protobuf:"bytes,4,opt,name=Actor,proto3" json:"Actor,omitempty"`
+	Clock                int64    `protobuf:"varint,5,opt,name=Clock,proto3" json:"Clock,omitempty"`
+	Signature            []byte   `protobuf:"bytes,6,opt,name=Signature,proto3" json:"Signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChatMetadata) Reset()         { *m = ChatMetadata{} }
+func (m *ChatMetadata) String() string { return proto.CompactTextString(m) }
+func (*ChatMetadata) ProtoMessage()    {}
+func (*ChatMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{16}
+}
+
+func (m *ChatMetadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatMetadata.Unmarshal(m, b)
+}
+func (m *ChatMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatMetadata.Marshal(b, m, deterministic)
+}
+func (m *ChatMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatMetadata.Merge(m, src)
+}
+func (m *ChatMetadata) XXX_Size() int {
+	return xxx_messageInfo_ChatMetadata.Size(m)
+}
+func (m *ChatMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatMetadata proto.InternalMessageInfo
+
+func (m *ChatMetadata) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *ChatMetadata) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *ChatMetadata) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *ChatMetadata) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *ChatMetadata) GetClock() int64 {
+	if m != nil {
+		return m.Clock
+	}
+	return 0
+}
+
+func (m *ChatMetadata) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// FRIENDSHIP
+// Action is "request", "accept", "decline", "remove" or "nick", see arxen-gui-golang/client/Friends.go
+// PublicKey and Address are of sender, sent with request and acceptance
+type Friendship struct {
+	Action               string   `protobuf:"bytes,1,opt,name=Action,proto3" json:"Action,omitempty"`
+	Nick                 string   `protobuf:"bytes,2,opt,name=Nick,proto3" json:"Nick,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,3,opt,name=PublicKey,proto3" json:"PublicKey,omitempty"`
+	Address              string   `protobuf:"bytes,4,opt,name=Address,proto3" json:"Address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Friendship) Reset()         { *m = Friendship{} }
+func (m *Friendship) String() string { return proto.CompactTextString(m) }
+func (*Friendship) ProtoMessage()    {}
+func (*Friendship) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{17}
+}
+
+func (m *Friendship) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Friendship.Unmarshal(m, b)
+}
+func (m *Friendship) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Friendship.Marshal(b, m, deterministic)
+}
+func (m *Friendship) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Friendship.Merge(m, src)
+}
+func (m *Friendship) XXX_Size() int {
+	return xxx_messageInfo_Friendship.Size(m)
+}
+func (m *Friendship) XXX_DiscardUnknown() {
+	xxx_messageInfo_Friendship.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Friendship proto.InternalMessageInfo
+
+func (m *Friendship) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *Friendship) GetNick() string {
+	if m != nil {
+		return m.Nick
+	}
+	return ""
+}
+
+func (m *Friendship) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *Friendship) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+// PRESENCE
+// heartbeat, Status is "online", "away" or "offline", see arxen-gui-golang/client/Presence.go
+type Presence struct {
+	Status               string   `protobuf:"bytes,1,opt,name=Status,proto3" json:"Status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Presence) Reset()         { *m = Presence{} }
+func (m *Presence) String() string { return proto.CompactTextString(m) }
+func (*Presence) ProtoMessage()    {}
+func (*Presence) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{18}
+}
+
+func (m *Presence) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Presence.Unmarshal(m, b)
+}
+func (m *Presence) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Presence.Marshal(b, m, deterministic)
+}
+func (m *Presence) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Presence.Merge(m, src)
+}
+func (m *Presence) XXX_Size() int {
+	return xxx_messageInfo_Presence.Size(m)
+}
+func (m *Presence) XXX_DiscardUnknown() {
+	xxx_messageInfo_Presence.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Presence proto.InternalMessageInfo
+
+func (m *Presence) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+// TYPING
+// ephemeral, user started or stopped typing in chat, see arxen-gui-golang/client/Typing.go
+type Typing struct {
+	ChatID               string   `protobuf:"bytes,1,opt,name=ChatID,proto3" json:"ChatID,omitempty"`
+	Typing               bool     `protobuf:"varint,2,opt,name=Typing,proto3" json:"Typing,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Typing) Reset()         { *m = Typing{} }
+func (m *Typing) String() string { return proto.CompactTextString(m) }
+func (*Typing) ProtoMessage()    {}
+func (*Typing) Descriptor() ([]byte, []int) {
+	return fileDescriptor_14263d62e1c6ef9e, []int{19}
+}
+
+func (m *Typing) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Typing.Unmarshal(m, b)
+}
+func (m *Typing) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Typing.Marshal(b, m, deterministic)
+}
+func (m *Typing) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Typing.Merge(m, src)
+}
+func (m *Typing) XXX_Size() int {
+	return xxx_messageInfo_Typing.Size(m)
+}
+func (m *Typing) XXX_DiscardUnknown() {
+	xxx_messageInfo_Typing.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Typing proto.InternalMessageInfo
+
+func (m *Typing) GetChatID() string {
+	if m != nil {
+		return m.ChatID
+	}
+	return ""
+}
+
+func (m *Typing) GetTyping() bool {
+	if m != nil {
+		return m.Typing
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*Empty)(nil), "Empty")
 	proto.RegisterType((*MessageDeliveryStatusResponse)(nil), "MessageDeliveryStatusResponse")
 	proto.RegisterType((*Message)(nil), "Message")
+	proto.RegisterType((*Header)(nil), "Header")
+	proto.RegisterType((*ChatMessage)(nil), "ChatMessage")
+	proto.RegisterType((*ChatAdvertRequest)(nil), "ChatAdvertRequest")
+	proto.RegisterType((*ChatAdvert)(nil), "ChatAdvert")
+	proto.RegisterType((*ChatParticipantsRequest)(nil), "ChatParticipantsRequest")
+	proto.RegisterType((*ChatParticipantsResponse)(nil), "ChatParticipantsResponse")
+	proto.RegisterMapType((map[string]string)(nil), "ChatParticipantsResponse.AddressesEntry")
+	proto.RegisterMapType((map[string]*MemberVersion)(nil), "ChatParticipantsResponse.MetadataVersionsEntry")
+	proto.RegisterMapType((map[string][]byte)(nil), "ChatParticipantsResponse.PublicKeysEntry")
+	proto.RegisterMapType((map[string]*MemberVersion)(nil), "ChatParticipantsResponse.VersionsEntry")
+	proto.RegisterType((*MemberVersion)(nil), "MemberVersion")
+	proto.RegisterType((*HistorySyncRequest)(nil), "HistorySyncRequest")
+	proto.RegisterType((*HistorySyncResponse)(nil), "HistorySyncResponse")
+	proto.RegisterType((*ChatKey)(nil), "ChatKey")
+	proto.RegisterType((*ChatKeyRequest)(nil), "ChatKeyRequest")
+	proto.RegisterType((*MessageReceipt)(nil), "MessageReceipt")
+	proto.RegisterType((*ChatMembership)(nil), "ChatMembership")
+	proto.RegisterType((*ChatMetadata)(nil), "ChatMetadata")
+	proto.RegisterType((*Friendship)(nil), "Friendship")
+	proto.RegisterType((*Presence)(nil), "Presence")
+	proto.RegisterType((*Typing)(nil), "Typing")
 }
 
 func init() {
@@ -169,22 +1252,70 @@ func init() {
 }
 
 var fileDescriptor_14263d62e1c6ef9e = []byte{
-	// 236 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4e, 0x2c, 0xaa, 0x48,
-	0xcd, 0xd3, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x97, 0x92, 0x4f, 0xcf, 0xcf, 0x4f, 0xcf, 0x49, 0xd5,
-	0x07, 0xf3, 0x92, 0x4a, 0xd3, 0xf4, 0x4b, 0x32, 0x73, 0x53, 0x8b, 0x4b, 0x12, 0x73, 0x0b, 0x20,
-	0x0a, 0x94, 0xd8, 0xb9, 0x58, 0x5d, 0x73, 0x0b, 0x4a, 0x2a, 0x95, 0xcc, 0xb9, 0x64, 0x7d, 0x53,
-	0x8b, 0x8b, 0x13, 0xd3, 0x53, 0x5d, 0x52, 0x73, 0x32, 0xcb, 0x52, 0x8b, 0x2a, 0x83, 0x4b, 0x12,
-	0x4b, 0x4a, 0x8b, 0x83, 0x52, 0x8b, 0x0b, 0xf2, 0xf3, 0x8a, 0x53, 0x85, 0xc4, 0xb8, 0xd8, 0x20,
-	0x22, 0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x1c, 0x41, 0x50, 0x9e, 0xd2, 0x44, 0x46, 0x2e, 0x76, 0xa8,
-	0x4e, 0x21, 0x21, 0x2e, 0x16, 0xbf, 0xc4, 0xdc, 0x54, 0xb0, 0x0a, 0xce, 0x20, 0x30, 0x1b, 0xa4,
-	0xcf, 0x39, 0x23, 0xb1, 0xc4, 0xd3, 0x45, 0x82, 0x09, 0x2c, 0x0a, 0xe5, 0x09, 0x29, 0x70, 0x71,
-	0x43, 0xb5, 0x39, 0xe5, 0xa7, 0x54, 0x4a, 0x30, 0x83, 0x25, 0x91, 0x85, 0x84, 0x2c, 0xb8, 0x38,
-	0x43, 0x60, 0xce, 0x95, 0x60, 0x51, 0x60, 0xd4, 0xe0, 0x36, 0x92, 0xd2, 0x83, 0x78, 0x48, 0x0f,
-	0xe6, 0x21, 0x3d, 0xb8, 0x8a, 0x20, 0x84, 0x62, 0xa3, 0x40, 0x2e, 0x11, 0x34, 0xcf, 0xb8, 0x96,
-	0xa5, 0xe6, 0x95, 0x08, 0x59, 0x72, 0x71, 0x07, 0xa7, 0xe6, 0xa5, 0xc0, 0x9c, 0xcb, 0xa1, 0x07,
-	0x65, 0x49, 0xc9, 0xe9, 0xe1, 0xf5, 0xbc, 0x12, 0x43, 0x12, 0x1b, 0xd8, 0x46, 0x63, 0x40, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x2e, 0x7b, 0xe8, 0x64, 0x5f, 0x01, 0x00, 0x00,
+	// 1007 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xdd, 0x6e, 0xeb, 0x44,
+	0x10, 0xc6, 0x75, 0x93, 0x38, 0x93, 0xb4, 0x94, 0xe5, 0x00, 0x56, 0x04, 0x25, 0x5a, 0x21, 0x08,
+	0x20, 0xb9, 0xa2, 0x5c, 0x50, 0x10, 0x20, 0x4a, 0xda, 0xea, 0x94, 0xaa, 0xa5, 0x38, 0x3d, 0x87,
+	0x0b, 0x6e, 0x70, 0xed, 0x21, 0xb5, 0x9a, 0xd8, 0x66, 0xbd, 0x09, 0xf5, 0x0b, 0x20, 0x71, 0xc9,
+	0x3d, 0x0f, 0xc0, 0x3b, 0xf1, 0x32, 0x68, 0x7f, 0xfc, 0x97, 0xc6, 0x8d, 0x10, 0x77, 0xfb, 0xcd,
+	0xce, 0x8c, 0xbf, 0x99, 0xfd, 0x76, 0xbc, 0xd0, 0xf3, 0xd8, 0x03, 0x46, 0x4e, 0xc2, 0x62, 0x1e,
+	0x0f, 0xde, 0x9d, 0xc6, 0xf1, 0x74, 0x86, 0x07, 0x12, 0xdd, 0x2e, 0x7e, 0x39, 0xe0, 0xe1, 0x1c,
+	0x53, 0xee, 0xcd, 0x13, 0xe5, 0x40, 0x3b, 0xd0, 0x3a, 0x9d, 0x27, 0x3c, 0xa3, 0x9f, 0xc1, 0x3b,
+	0x97, 0x98, 0xa6, 0xde, 0x14, 0x4f, 0x70, 0x16, 0x2e, 0x91, 0x65, 0x13, 0xee, 0xf1, 0x45, 0xea,
+	0x62, 0x9a, 0xc4, 0x51, 0x8a, 0xe4, 0x4d, 0x68, 0x2b, 0x8b, 0x6d, 0x0c, 0x8d, 0x91, 0xe5, 0x6a,
+	0x44, 0xff, 0x34, 0xa0, 0xa3, 0x23, 0x09, 0x81, 0xed, 0x2b, 0x6f, 0x8e, 0xd2, 0xa3, 0xeb, 0xca,
+	0xb5, 0x88, 0x1b, 0xdf, 0x79, 0xfc, 0xfc, 0xc4, 0xde, 0x92, 0x56, 0x8d, 0xc8, 0x10, 0x7a, 0x3a,
+	0xec, 0xdb, 0x38, 0xc8, 0x6c, 0x53, 0x6e, 0x56, 0x4d, 0xe4, 0x08, 0xba, 0x37, 0x39, 0x5d, 0x7b,
+	0x7b, 0x68, 0x8c, 0x7a, 0x87, 0x03, 0x47, 0x15, 0xe4, 0xe4, 0x05, 0x39, 0x85, 0x87, 0x5b, 0x3a,
+	0xd3, 0x2b, 0x68, 0x3f, 0x47, 0x2f, 0x40, 0x46, 0x6c, 0xe8, 0xbc, 0x44, 0x96, 0x86, 0x71, 0x24,
+	0x49, 0xed, 0xb8, 0x39, 0x14, 0x5c, 0x6f, 0xb2, 0x04, 0x35, 0x2b, 0xb9, 0x96, 0x35, 0xc6, 0x0b,
+	0xe6, 0xa3, 0xa6, 0xa3, 0x11, 0xfd, 0xc7, 0x80, 0x9e, 0xa0, 0x9d, 0xd7, 0x59, 0xd6, 0x64, 0xd4,
+	0x6a, 0x7a, 0x1b, 0xba, 0xda, 0xa5, 0x28, 0xb7, 0x34, 0x88, 0x2f, 0xbe, 0x48, 0x91, 0xe9, 0xdc,
+	0x72, 0x9d, 0xd7, 0x38, 0xf9, 0x2f, 0x35, 0x4a, 0x67, 0xc9, 0x1f, 0x1f, 0xb8, 0xdd, 0xd2, 0xfc,
+	0xf1, 0x81, 0x93, 0x67, 0xd0, 0x3a, 0x4d, 0x62, 0xff, 0xce, 0x6e, 0xcb, 0x5a, 0x15, 0x20, 0xfb,
+	0x00, 0xe3, 0x30, 0xb9, 0x43, 0xc6, 0x85, 0x7f, 0x67, 0x68, 0x8c, 0xfa, 0x6e, 0xc5, 0x42, 0x3f,
+	0x86, 0xd7, 0x04, 0xff, 0xe3, 0x60, 0x89, 0x8c, 0xbb, 0xf8, 0xeb, 0x02, 0x53, 0xde, 0x54, 0x22,
+	0xfd, 0x06, 0xa0, 0x74, 0x6e, 0x6c, 0xc4, 0x00, 0x2c, 0xb1, 0x92, 0x62, 0x50, 0x7d, 0x28, 0x30,
+	0xfd, 0x04, 0xde, 0x12, 0xeb, 0x6b, 0x8f, 0xf1, 0xd0, 0x0f, 0x13, 0x2f, 0xe2, 0xe9, 0xa6, 0x8f,
+	0xfe, 0xde, 0x01, 0xfb, 0x71, 0x4c, 0x29, 0xcc, 0xb5, 0x1c, 0x28, 0xf4, 0xab, 0xfe, 0xf6, 0xd6,
+	0xd0, 0x1c, 0x75, 0xdd, 0x9a, 0x8d, 0x9c, 0x41, 0xf7, 0x38, 0x08, 0x18, 0xa6, 0x29, 0xa6, 0xb6,
+	0x39, 0x34, 0x47, 0xbd, 0xc3, 0x91, 0xd3, 0xf4, 0x25, 0xa7, 0x70, 0x3d, 0x8d, 0x38, 0xcb, 0xdc,
+	0x32, 0x94, 0x0c, 0xc0, 0xbc, 0xc0, 0x4c, 0x1f, 0xa0, 0x25, 0x33, 0x5c, 0x60, 0xe6, 0x0a, 0xa3,
+	0x38, 0x94, 0xef, 0x7f, 0x8b, 0x90, 0xe9, 0x93, 0x52, 0x40, 0xb0, 0x3e, 0x0e, 0xe6, 0x61, 0x94,
+	0xda, 0x6d, 0xc9, 0x4b, 0x23, 0x32, 0x06, 0x4b, 0x2b, 0x34, 0xb5, 0x3b, 0x92, 0xd0, 0x07, 0xcd,
+	0x84, 0x72, 0x4f, 0xc5, 0xa7, 0x08, 0xac, 0xb5, 0xdf, 0xaa, 0xb7, 0x9f, 0xec, 0xeb, 0x03, 0x5c,
+	0x7a, 0xdc, 0x63, 0x76, 0x57, 0xee, 0x56, 0x2c, 0xe4, 0x27, 0xd8, 0xbb, 0x44, 0xee, 0x05, 0x1e,
+	0xf7, 0x0a, 0x22, 0x20, 0x89, 0x1c, 0x34, 0x13, 0x59, 0x8d, 0x50, 0x84, 0x1e, 0x25, 0x22, 0xe7,
+	0x00, 0xd7, 0x8b, 0xdb, 0x59, 0xe8, 0x5f, 0x60, 0x96, 0xda, 0x3d, 0x99, 0xf6, 0xc3, 0xe6, 0xb4,
+	0xa5, 0xaf, 0x4a, 0x58, 0x09, 0x26, 0x1f, 0x81, 0x35, 0x66, 0xe8, 0x71, 0x71, 0xb5, 0xfb, 0xb2,
+	0xef, 0xbb, 0xce, 0x25, 0xce, 0x6f, 0x91, 0xe9, 0xaf, 0xb9, 0xc5, 0x3e, 0x19, 0x41, 0xe7, 0x79,
+	0x98, 0xf2, 0x98, 0x65, 0xf6, 0xce, 0xd0, 0x5c, 0xe3, 0x9a, 0x6f, 0x0f, 0xbe, 0x84, 0xdd, 0xfa,
+	0x29, 0x93, 0x3d, 0x30, 0xef, 0x31, 0xd3, 0xda, 0x32, 0xef, 0xd5, 0x81, 0x2e, 0xbd, 0xd9, 0x22,
+	0x57, 0xb6, 0x02, 0x5f, 0x6c, 0x1d, 0x19, 0x83, 0x0b, 0xd8, 0xa9, 0x75, 0x60, 0x4d, 0xf0, 0x7b,
+	0xd5, 0xe0, 0xc7, 0x44, 0x2a, 0xc9, 0x26, 0xf0, 0xc6, 0xda, 0xb6, 0xfe, 0xaf, 0xa4, 0x5f, 0xc1,
+	0xab, 0x2b, 0x4d, 0xdd, 0x54, 0x60, 0xbf, 0x12, 0x4e, 0xff, 0x30, 0x60, 0xa7, 0x96, 0x5b, 0xf8,
+	0x8e, 0x67, 0xb1, 0x7f, 0x2f, 0xe3, 0x4d, 0x57, 0x01, 0x61, 0x3d, 0xf6, 0x79, 0xcc, 0xf2, 0x16,
+	0x49, 0x20, 0x35, 0xef, 0xcb, 0x03, 0xd3, 0xe3, 0x55, 0x21, 0x31, 0x36, 0x27, 0xe1, 0x34, 0xf2,
+	0xf8, 0x82, 0xa1, 0xbc, 0x43, 0x7d, 0xb7, 0x34, 0x88, 0x28, 0x31, 0x2a, 0xcf, 0x4f, 0xf4, 0x05,
+	0xd2, 0x88, 0xde, 0x00, 0xd1, 0xa7, 0x36, 0xc9, 0x22, 0x7f, 0xc3, 0x08, 0x21, 0xef, 0xc3, 0xee,
+	0x24, 0x8c, 0x7c, 0x5c, 0x9d, 0xcf, 0x2b, 0x56, 0xfa, 0x23, 0xbc, 0x5e, 0xcb, 0xba, 0x61, 0xc8,
+	0x8c, 0xc0, 0xd2, 0xb1, 0x6a, 0xc0, 0xf4, 0x0e, 0xfb, 0x4e, 0xe5, 0x4f, 0xe1, 0x16, 0xbb, 0xf4,
+	0x05, 0x74, 0xf4, 0x58, 0x68, 0x4c, 0x56, 0x8c, 0xef, 0xad, 0xea, 0xf8, 0x16, 0xdd, 0x41, 0x6f,
+	0x86, 0x81, 0x98, 0x30, 0xa6, 0xee, 0x4e, 0x6e, 0xa0, 0x5f, 0xc3, 0x6e, 0x3e, 0x6d, 0x36, 0x74,
+	0x60, 0x6d, 0x76, 0xfa, 0x33, 0xec, 0xe6, 0x5c, 0xd1, 0xc7, 0x30, 0x69, 0x8e, 0xdf, 0x07, 0x28,
+	0xda, 0x94, 0x4f, 0xd3, 0x8a, 0xa5, 0xf2, 0x40, 0xc8, 0x7f, 0x9e, 0x12, 0xd1, 0xbf, 0x0d, 0x45,
+	0x51, 0xe9, 0x26, 0xbd, 0x0b, 0x93, 0xc6, 0x4f, 0x94, 0x02, 0xd9, 0xaa, 0x09, 0xa4, 0x94, 0x80,
+	0x59, 0x95, 0x40, 0x29, 0xb3, 0x56, 0x55, 0x66, 0x85, 0x24, 0xdb, 0x55, 0x49, 0xd6, 0x44, 0xd6,
+	0x59, 0x11, 0xd9, 0x77, 0xdb, 0xd6, 0xf6, 0x5e, 0x8b, 0xfe, 0x65, 0x40, 0x5f, 0x51, 0x55, 0xf7,
+	0xee, 0xa9, 0x5e, 0x9e, 0x85, 0x38, 0x0b, 0x72, 0x7d, 0x4b, 0x20, 0xac, 0x2f, 0xe5, 0xbd, 0x51,
+	0x2c, 0x15, 0x28, 0x49, 0x6e, 0xaf, 0x25, 0xd9, 0x6a, 0x24, 0xd9, 0x5e, 0x21, 0x49, 0x13, 0x80,
+	0x33, 0x16, 0x62, 0x14, 0xe4, 0x4d, 0xd4, 0xcd, 0x32, 0x6a, 0xcd, 0x12, 0x8f, 0xb0, 0xd0, 0xbf,
+	0xcf, 0x1f, 0x36, 0x57, 0xa1, 0xca, 0x5b, 0x5c, 0xfb, 0x5c, 0x43, 0x85, 0x41, 0x3c, 0x92, 0xf4,
+	0xd0, 0xd3, 0x1c, 0x73, 0x48, 0x29, 0x58, 0xd7, 0x0c, 0x53, 0x8c, 0xfc, 0xd5, 0x07, 0x60, 0x79,
+	0xbe, 0x47, 0xd0, 0xbe, 0xc9, 0x92, 0x30, 0x9a, 0x3e, 0x75, 0xac, 0xca, 0x43, 0x72, 0xb2, 0x5c,
+	0x8d, 0x0e, 0x7f, 0x80, 0x67, 0x2b, 0x6f, 0xce, 0xd3, 0x25, 0x46, 0x9c, 0x7c, 0x0e, 0xbd, 0x09,
+	0x46, 0x81, 0xde, 0x23, 0x96, 0xa3, 0x57, 0x83, 0x7d, 0xe7, 0xc9, 0x37, 0x2a, 0x7d, 0xe5, 0xb6,
+	0x2d, 0x1f, 0x4d, 0x9f, 0xfe, 0x3b, 0x00, 0xc7, 0x29, 0x00, 0x5c, 0x06, 0x0b, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -219,7 +1350,7 @@ func (c *messageDeliveryEventClient) SendMessage(ctx context.Context, in *Messag
 	return out, nil
 }
 
-// MessageDeliveryEventServer is the gql API for MessageDeliveryEvent service.
+// MessageDeliveryEventServer is the server API for MessageDeliveryEvent service.
 type MessageDeliveryEventServer interface {
 	SendMessage(context.Context, *Message) (*MessageDeliveryStatusResponse, error)
 }
@@ -11,23 +11,63 @@ type Chat struct {
 	ClientsIPsList []string     `json:"clientsIPsList"`
 	LatestMessage  *TextMessage `json:"latestMessage"`
 	ChatAvatar     *string      `json:"chatAvatar"`
-	ClientWriting  *string      `json:"clientWriting"`
+	ClientWriting  []string     `json:"clientWriting"`
 	ChatName       *string      `json:"chatName"`
+	Owner          *string      `json:"owner"`
+	Admins         []string     `json:"admins"`
 }
 
 type Friend struct {
-	Nick       *string `json:"nick"`
-	UserID     string  `json:"userID"`
-	UserIP     *string `json:"userIP"`
-	UserAvatar *string `json:"userAvatar"`
-	Status     *bool   `json:"status"`
+	Nick       *string    `json:"nick"`
+	UserID     string     `json:"userID"`
+	UserIP     *string    `json:"userIP"`
+	UserAvatar *string    `json:"userAvatar"`
+	Status     *bool      `json:"status"`
+	State      string     `json:"state"`
+	Presence   string     `json:"presence"`
+	LastSeen   *time.Time `json:"lastSeen"`
+}
+
+type MessageReceipt struct {
+	ChatID    string `json:"chatId"`
+	MessageID string `json:"messageId"`
+	UserID    string `json:"userID"`
+	Status    string `json:"status"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type Peer struct {
+	UserID  string `json:"userID"`
+	Address string `json:"address"`
+	State   string `json:"state"`
+	Online  bool   `json:"online"`
 }
 
 type TextMessage struct {
-	MessageID string    `json:"messageId"`
-	ChatID    string    `json:"chatId"`
-	UserNick  *string   `json:"userNick"`
-	User      string    `json:"user"`
-	TimeStamp time.Time `json:"timeStamp"`
-	Text      string    `json:"text"`
+	MessageID string            `json:"messageId"`
+	ChatID    string            `json:"chatId"`
+	UserNick  *string           `json:"userNick"`
+	User      string            `json:"user"`
+	TimeStamp time.Time         `json:"timeStamp"`
+	Text      string            `json:"text"`
+	Signature *string           `json:"signature"`
+	Verified  bool              `json:"verified"`
+	Receipts  []*MessageReceipt `json:"receipts"`
+	Clock     int               `json:"clock"`
+}
+
+type TextMessageConnection struct {
+	Edges    []*TextMessageEdge `json:"edges"`
+	PageInfo *PageInfo          `json:"pageInfo"`
+}
+
+type TextMessageEdge struct {
+	Cursor string       `json:"cursor"`
+	Node   *TextMessage `json:"node"`
 }
@@ -0,0 +1,78 @@
+package hub
+
+import (
+	"reflect"
+	"sync"
+)
+
+// hub of subscribers:
+// every subscriber (e.g. each open screen) gets its own buffered channel and values it is interested in,
+// publishing never blocks, subscriber which does not keep up misses values,
+// so slow subscribers do not stall publisher and values published while nobody subscribes are lost
+// channels can have any element type published values are assignable to, so users of hub keep typed channels
+
+// Hub passes published values to subscribed channels, safe for concurrent use, zero value is ready to use
+type Hub struct {
+	mutex       sync.Mutex
+	subscribers map[interface{}]subscriber // channel : subscriber
+}
+
+// subscriber is single subscribed channel
+type subscriber struct {
+	ch    reflect.Value
+	match func(value interface{}) bool // nil matches all values
+}
+
+// Subscribe adds channel receiving published values for which match reports true (all values if match is nil),
+// returns function ending subscription and closing channel, it panics if ch is not a channel
+func (h *Hub) Subscribe(ch interface{}, match func(value interface{}) bool) func() {
+	value := reflect.ValueOf(ch)
+	if value.Kind() != reflect.Chan {
+		panic("hub: subscribed value is not a channel")
+	}
+
+	h.mutex.Lock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[interface{}]subscriber)
+	}
+	h.subscribers[ch] = subscriber{ch: value, match: match}
+	h.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mutex.Lock()
+			delete(h.subscribers, ch)
+			h.mutex.Unlock()
+			value.Close()
+		})
+	}
+}
+
+// Publish passes value to matching subscribers without blocking, returns number of subscribers which missed it
+func (h *Hub) Publish(value interface{}) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	missed := 0
+	for _, s := range h.subscribers {
+		if s.match != nil && !s.match(value) {
+			continue
+		}
+		v := reflect.ValueOf(value)
+		if value == nil {
+			v = reflect.Zero(s.ch.Type().Elem())
+		}
+		if !s.ch.TrySend(v) {
+			missed++
+		}
+	}
+	return missed
+}
+
+// Len returns number of subscribers
+func (h *Hub) Len() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return len(h.subscribers)
+}
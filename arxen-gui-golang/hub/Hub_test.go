@@ -0,0 +1,89 @@
+package hub
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHub(t *testing.T) {
+	var h Hub
+	// values published while nobody subscribes are not kept
+	h.Publish("lost")
+
+	all := make(chan string, 1)
+	cancelAll := h.Subscribe(all, nil)
+	prefixed := make(chan string, 1)
+	cancelPrefixed := h.Subscribe(prefixed, func(value interface{}) bool { return strings.HasPrefix(value.(string), "a") })
+	defer cancelPrefixed()
+	if n := h.Len(); n != 2 {
+		t.Fatalf("Len() = %d, want 2", n)
+	}
+
+	h.Publish("ab")
+	for i, ch := range []chan string{all, prefixed} {
+		select {
+		case v := <-ch:
+			if v != "ab" {
+				t.Errorf("subscriber %d got %s, want ab", i, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber %d got nothing", i)
+		}
+	}
+	h.Publish("b")
+	if v := <-all; v != "b" {
+		t.Errorf("subscriber of all values got %s, want b", v)
+	}
+	if len(prefixed) != 0 {
+		t.Error("value not matching filter received")
+	}
+
+	// ended subscription is closed and removed
+	cancelAll()
+	cancelAll()
+	if _, ok := <-all; ok {
+		t.Error("value received after subscription ended")
+	}
+	if n := h.Len(); n != 1 {
+		t.Errorf("Len() after cancel = %d, want 1", n)
+	}
+}
+
+func TestHub_slowSubscriber(t *testing.T) {
+	const buffer = 4
+	var h Hub
+	slow := make(chan int, buffer)
+	cancelSlow := h.Subscribe(slow, nil)
+	defer cancelSlow()
+	fast := make(chan int, buffer)
+	cancelFast := h.Subscribe(fast, nil)
+	defer cancelFast()
+
+	// slow subscriber never reads, publishing does not block
+	missed := 0
+	for i := 0; i < buffer+10; i++ {
+		missed += h.Publish(i)
+		if v := <-fast; v != i {
+			t.Fatalf("fast subscriber got %d, want %d", v, i)
+		}
+	}
+	if missed != 10 {
+		t.Errorf("missed = %d, want 10", missed)
+	}
+	if len(slow) != buffer {
+		t.Errorf("slow subscriber buffered %d values, want %d", len(slow), buffer)
+	}
+}
+
+func TestHub_nil(t *testing.T) {
+	var h Hub
+	ch := make(chan *int, 1)
+	cancel := h.Subscribe(ch, nil)
+	defer cancel()
+
+	h.Publish(nil)
+	if v := <-ch; v != nil {
+		t.Errorf("got %v, want nil", v)
+	}
+}
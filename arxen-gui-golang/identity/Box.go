@@ -0,0 +1,103 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/nacl/box"
+	"math/big"
+)
+
+// public key encryption:
+// Ed25519 keys of identity are converted to X25519 keys (as in libsodium crypto_sign_ed25519_*_to_curve25519),
+// so user needs no other key to receive data sealed for it (NaCl box)
+// sealed data = nonce | box
+
+// size of nonce prepended to sealed data
+const BOX_NONCE_SIZE = 24
+
+// ErrOpenFailed is returned when sealed data cannot be decrypted or was not sealed by given sender
+var ErrOpenFailed = errors.New("cannot open sealed data")
+
+// prime of curve25519 field, 2^255 - 19
+var curvePrime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// Seal encrypts message for owner of recipient public key, recipient can check it was sealed by this identity
+func (id *Identity) Seal(recipient ed25519.PublicKey, message []byte) ([]byte, error) {
+	recipientKey, err := curvePublicKey(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [BOX_NONCE_SIZE]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	return box.Seal(nonce[:], message, &nonce, recipientKey, id.curvePrivateKey()), nil
+}
+
+// Open decrypts data sealed for this identity by owner of sender public key
+func (id *Identity) Open(sender ed25519.PublicKey, sealed []byte) ([]byte, error) {
+	senderKey, err := curvePublicKey(sender)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < BOX_NONCE_SIZE+box.Overhead {
+		return nil, fmt.Errorf("%w: too short", ErrOpenFailed)
+	}
+
+	var nonce [BOX_NONCE_SIZE]byte
+	copy(nonce[:], sealed)
+	message, ok := box.Open(nil, sealed[BOX_NONCE_SIZE:], &nonce, senderKey, id.curvePrivateKey())
+	if !ok {
+		return nil, ErrOpenFailed
+	}
+	return message, nil
+}
+
+// curvePrivateKey returns X25519 private key corresponding to Ed25519 private key
+func (id *Identity) curvePrivateKey() *[32]byte {
+	h := sha512.Sum512(id.privateKey.Seed())
+	var key [32]byte
+	copy(key[:], h[:32])
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+	return &key
+}
+
+// curvePublicKey converts Ed25519 public key to X25519 one, u = (1 + y) / (1 - y)
+func curvePublicKey(publicKey ed25519.PublicKey) (*[32]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: public key of %d bytes", ErrInvalidKey, len(publicKey))
+	}
+
+	// y is little endian, the highest bit is sign of x
+	y := new(big.Int).SetBytes(reverse(publicKey))
+	y.SetBit(y, 255, 0)
+
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, curvePrime)
+	if denominator.Sign() == 0 {
+		return nil, fmt.Errorf("%w: point of low order", ErrInvalidKey)
+	}
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, denominator.ModInverse(denominator, curvePrime))
+	u.Mod(u, curvePrime)
+
+	var key [32]byte
+	uBytes := u.Bytes()
+	copy(key[:], reverse(uBytes))
+	return &key, nil
+}
+
+// reverse returns copy of bytes in reversed order (little <-> big endian)
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -0,0 +1,53 @@
+package identity
+
+import (
+	"bytes"
+	"errors"
+	"golang.org/x/crypto/curve25519"
+	"testing"
+)
+
+func TestCurvePublicKey(t *testing.T) {
+	// X25519 public key computed from converted private key must equal converted public key
+	for i := 0; i < 10; i++ {
+		id, err := New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want [32]byte
+		curve25519.ScalarBaseMult(&want, id.curvePrivateKey())
+
+		got, err := curvePublicKey(id.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != want {
+			t.Fatalf("curvePublicKey() = %x, want %x", *got, want)
+		}
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	alice, _ := New()
+	bob, _ := New()
+	eve, _ := New()
+
+	message := []byte("chat key")
+	sealed, err := alice.Seal(bob.PublicKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := bob.Open(alice.PublicKey, sealed); err != nil || !bytes.Equal(got, message) {
+		t.Errorf("Open() = %q, %v, want %q", got, err, message)
+	}
+	if _, err := eve.Open(alice.PublicKey, sealed); !errors.Is(err, ErrOpenFailed) {
+		t.Errorf("Open() by other recipient error = %v, want %v", err, ErrOpenFailed)
+	}
+	if _, err := bob.Open(eve.PublicKey, sealed); !errors.Is(err, ErrOpenFailed) {
+		t.Errorf("Open() with other sender error = %v, want %v", err, ErrOpenFailed)
+	}
+	if _, err := bob.Open(alice.PublicKey, sealed[:10]); !errors.Is(err, ErrOpenFailed) {
+		t.Errorf("Open() of truncated data error = %v, want %v", err, ErrOpenFailed)
+	}
+}
@@ -0,0 +1,137 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// user identity:
+// every daemon owns Ed25519 keypair generated on first start and kept in file,
+// user ID is derived from public key, so it does not change when user changes network
+// user ID = hex(sha256(publicKey)[:USER_ID_SIZE])
+
+// length of user ID in bytes (160 bits), the same as length of node ID in DHT
+const USER_ID_SIZE = 20
+
+var (
+	// ErrInvalidKey is returned when key has wrong length or format
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrIDMismatch is returned when user ID was not derived from given public key
+	ErrIDMismatch = errors.New("user ID does not match public key")
+)
+
+// Identity is keypair of user
+type Identity struct {
+	PublicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+	userID     string
+}
+
+// New generates new random identity
+func New() (*Identity, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return fromPrivateKey(privateKey, publicKey), nil
+}
+
+// FromSeed restores identity from 32 bytes seed of private key
+func FromSeed(seed []byte) (*Identity, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%w: seed of %d bytes", ErrInvalidKey, len(seed))
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return fromPrivateKey(privateKey, privateKey.Public().(ed25519.PublicKey)), nil
+}
+
+func fromPrivateKey(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *Identity {
+	return &Identity{
+		PublicKey:  publicKey,
+		privateKey: privateKey,
+		userID:     UserIDFromPublicKey(publicKey),
+	}
+}
+
+// LoadOrCreate reads identity from file at path, new identity is generated and saved if there is no file
+func LoadOrCreate(path string) (*Identity, error) {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return FromSeed(seed)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	id, err := New()
+	if err != nil {
+		return nil, err
+	}
+	// private key is readable only by owner
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(id.privateKey.Seed())+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// UserID returns user ID derived from public key
+func (id *Identity) UserID() string {
+	return id.userID
+}
+
+// PublicKeyHex returns public key encoded as hex string
+func (id *Identity) PublicKeyHex() string {
+	return hex.EncodeToString(id.PublicKey)
+}
+
+// Sign signs data with private key
+func (id *Identity) Sign(data []byte) []byte {
+	return ed25519.Sign(id.privateKey, data)
+}
+
+// UserIDFromPublicKey derives user ID from public key
+func UserIDFromPublicKey(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:USER_ID_SIZE])
+}
+
+// ParsePublicKey decodes public key encoded as hex string
+func ParsePublicKey(publicKeyHex string) (ed25519.PublicKey, error) {
+	publicKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: public key of %d bytes", ErrInvalidKey, len(publicKey))
+	}
+	return publicKey, nil
+}
+
+// VerifyUserID checks if user ID was derived from public key encoded as hex string
+// returns decoded public key
+func VerifyUserID(userID, publicKeyHex string) (ed25519.PublicKey, error) {
+	publicKey, err := ParsePublicKey(publicKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	if UserIDFromPublicKey(publicKey) != userID {
+		return nil, fmt.Errorf("%w: %s", ErrIDMismatch, userID)
+	}
+	return publicKey, nil
+}
+
+// Verify reports whether sig is valid signature of data made by owner of public key
+func Verify(publicKey ed25519.PublicKey, data, sig []byte) bool {
+	return len(publicKey) == ed25519.PublicKeySize && ed25519.Verify(publicKey, data, sig)
+}
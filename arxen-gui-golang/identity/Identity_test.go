@@ -0,0 +1,90 @@
+package identity
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arxen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "identity.key")
+	created, err := LoadOrCreate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("key file = %v, %v, want mode 0600", info, err)
+	}
+
+	loaded, err := LoadOrCreate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.UserID() != created.UserID() || len(created.UserID()) != 2*USER_ID_SIZE {
+		t.Errorf("UserID() after reload = %s, want %s", loaded.UserID(), created.UserID())
+	}
+
+	if err := ioutil.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOrCreate(path); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("LoadOrCreate() of broken file error = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+func TestVerifyUserID(t *testing.T) {
+	id, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		userID       string
+		publicKeyHex string
+		wantErr      error
+	}{
+		{"valid", id.UserID(), id.PublicKeyHex(), nil},
+		{"other key", id.UserID(), other.PublicKeyHex(), ErrIDMismatch},
+		{"not hex", id.UserID(), "zz", ErrInvalidKey},
+		{"too short", id.UserID(), "abcd", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyUserID(tt.userID, tt.publicKeyHex); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyUserID() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	id, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("message")
+	sig := id.Sign(data)
+
+	if !Verify(id.PublicKey, data, sig) {
+		t.Error("Verify() of valid signature = false")
+	}
+	if Verify(id.PublicKey, []byte("other message"), sig) {
+		t.Error("Verify() of other data = true")
+	}
+	if Verify(nil, data, sig) {
+		t.Error("Verify() without key = true")
+	}
+}
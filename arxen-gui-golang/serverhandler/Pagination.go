@@ -0,0 +1,105 @@
+package serverhandler
+
+import (
+	"encoding/base64"
+	"errors"
+	"main/gql"
+	"main/store"
+)
+
+// pagination of chat history:
+// connection lists messages the newest first, so next page (first, after) goes back in history
+// and previous page (last, before) towards the newest messages,
+// cursor is opaque MessageID of message (see store.Store.MessagesPage for order of messages)
+
+// number of messages in page when neither first nor last is given
+const DEFAULT_PAGE_SIZE = 50
+
+// the largest page returned, larger ones are cut
+const MAX_PAGE_SIZE = 500
+
+// cursor prefix, makes cursors of other types invalid
+const MESSAGE_CURSOR_PREFIX = "message:"
+
+var (
+	// ErrInvalidCursor is returned when cursor was not returned by connection
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidPage is returned when page arguments cannot be used together
+	ErrInvalidPage = errors.New("first and last cannot be used together and cannot be negative")
+)
+
+// pageQuery is page of history in terms of store.Store.MessagesPage
+type pageQuery struct {
+	afterMessageID  string
+	beforeMessageID string
+	limit           int
+	newest          bool
+}
+
+// newPageQuery converts connection arguments to query of store
+func newPageQuery(first *int, after *string, last *int, before *string) (pageQuery, error) {
+	query := pageQuery{limit: DEFAULT_PAGE_SIZE, newest: true}
+	if (first != nil && last != nil) || (first != nil && *first < 0) || (last != nil && *last < 0) {
+		return query, ErrInvalidPage
+	}
+	switch {
+	case first != nil:
+		query.limit = *first
+	case last != nil:
+		// the oldest of newer messages are next to before cursor
+		query.limit = *last
+		query.newest = false
+	}
+	if query.limit > MAX_PAGE_SIZE {
+		query.limit = MAX_PAGE_SIZE
+	}
+
+	// list is reversed, messages after cursor are older
+	var err error
+	if after != nil {
+		if query.beforeMessageID, err = decodeCursor(*after); err != nil {
+			return query, err
+		}
+	}
+	if before != nil {
+		if query.afterMessageID, err = decodeCursor(*before); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// messageConnection converts page of history to connection, the newest message first
+func messageConnection(page store.MessagePage) *gql.TextMessageConnection {
+	connection := &gql.TextMessageConnection{
+		Edges: make([]*gql.TextMessageEdge, 0, len(page.Messages)),
+		PageInfo: &gql.PageInfo{
+			HasNextPage:     page.Older,
+			HasPreviousPage: page.Newer,
+		},
+	}
+	for i := len(page.Messages) - 1; i >= 0; i-- {
+		message := page.Messages[i]
+		connection.Edges = append(connection.Edges, &gql.TextMessageEdge{Cursor: encodeCursor(message.MessageID), Node: message})
+	}
+	if len(connection.Edges) > 0 {
+		start, end := connection.Edges[0].Cursor, connection.Edges[len(connection.Edges)-1].Cursor
+		connection.PageInfo.StartCursor = &start
+		connection.PageInfo.EndCursor = &end
+	}
+	return connection
+}
+
+// encodeCursor returns cursor of message
+func encodeCursor(messageID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(MESSAGE_CURSOR_PREFIX + messageID))
+}
+
+// decodeCursor returns MessageID of message with given cursor
+func decodeCursor(cursor string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) <= len(MESSAGE_CURSOR_PREFIX) || string(data[:len(MESSAGE_CURSOR_PREFIX)]) != MESSAGE_CURSOR_PREFIX {
+		return "", ErrInvalidCursor
+	}
+	return string(data[len(MESSAGE_CURSOR_PREFIX):]), nil
+}
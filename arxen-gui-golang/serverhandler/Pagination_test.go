@@ -0,0 +1,70 @@
+package serverhandler
+
+import (
+	"errors"
+	"main/gql"
+	"main/store"
+	"reflect"
+	"testing"
+)
+
+func intPtr(i int) *int { return &i }
+
+func stringPtr(s string) *string { return &s }
+
+func TestNewPageQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  *int
+		after  *string
+		last   *int
+		before *string
+		want   pageQuery
+		err    error
+	}{
+		{"default", nil, nil, nil, nil, pageQuery{limit: DEFAULT_PAGE_SIZE, newest: true}, nil},
+		{"next page", intPtr(10), stringPtr(encodeCursor("m")), nil, nil, pageQuery{beforeMessageID: "m", limit: 10, newest: true}, nil},
+		{"previous page", nil, nil, intPtr(10), stringPtr(encodeCursor("m")), pageQuery{afterMessageID: "m", limit: 10}, nil},
+		{"too large", intPtr(MAX_PAGE_SIZE + 1), nil, nil, nil, pageQuery{limit: MAX_PAGE_SIZE, newest: true}, nil},
+		{"first and last", intPtr(1), nil, intPtr(1), nil, pageQuery{}, ErrInvalidPage},
+		{"negative", intPtr(-1), nil, nil, nil, pageQuery{}, ErrInvalidPage},
+		{"invalid cursor", intPtr(1), stringPtr("m"), nil, nil, pageQuery{}, ErrInvalidCursor},
+		{"cursor of other type", intPtr(1), stringPtr("dXNlcjp4"), nil, nil, pageQuery{}, ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newPageQuery(tt.first, tt.after, tt.last, tt.before)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("newPageQuery() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("newPageQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageConnection(t *testing.T) {
+	page := store.MessagePage{Messages: []*gql.TextMessage{{MessageID: "a"}, {MessageID: "b"}}, Older: true}
+	connection := messageConnection(page)
+
+	var ids []string
+	for _, edge := range connection.Edges {
+		messageID, err := decodeCursor(edge.Cursor)
+		if err != nil || messageID != edge.Node.MessageID {
+			t.Errorf("cursor of %s = %s, %v", edge.Node.MessageID, messageID, err)
+		}
+		ids = append(ids, edge.Node.MessageID)
+	}
+	if !reflect.DeepEqual(ids, []string{"b", "a"}) {
+		t.Errorf("messages = %v, want the newest first", ids)
+	}
+	info := connection.PageInfo
+	if !info.HasNextPage || info.HasPreviousPage || *info.StartCursor != encodeCursor("b") || *info.EndCursor != encodeCursor("a") {
+		t.Errorf("page info = %+v", info)
+	}
+
+	if empty := messageConnection(store.MessagePage{}); len(empty.Edges) != 0 || empty.PageInfo.EndCursor != nil {
+		t.Errorf("connection of empty page = %+v", empty)
+	}
+}
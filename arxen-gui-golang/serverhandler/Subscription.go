@@ -0,0 +1,44 @@
+package serverhandler
+
+import (
+	"context"
+	"reflect"
+)
+
+// subscriptions of graphql:
+// every subscription request gets its own subscription of client, values received from it are converted
+// to graphql types and sent to channel of request until request ends, then subscription of client ends as well
+
+// forward passes values received from channel in to channel out until ctx is done, then it calls cancel,
+// convert returns value sent for received one, received value is skipped if it reports false,
+// nil convert sends received values as they are
+func forward(ctx context.Context, in, out interface{}, cancel func(), convert func(value interface{}) (interface{}, bool)) {
+	done := reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	receive := reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(in)}
+	outValue := reflect.ValueOf(out)
+
+	go func() {
+		defer cancel()
+		for {
+			chosen, received, ok := reflect.Select([]reflect.SelectCase{done, receive})
+			if chosen == 0 || !ok {
+				return
+			}
+			value := received.Interface()
+			if convert != nil {
+				if value, ok = convert(value); !ok {
+					continue
+				}
+			}
+
+			sent := reflect.Zero(outValue.Type().Elem())
+			if value != nil {
+				sent = reflect.ValueOf(value)
+			}
+			send := reflect.SelectCase{Dir: reflect.SelectSend, Chan: outValue, Send: sent}
+			if chosen, _, _ := reflect.Select([]reflect.SelectCase{done, send}); chosen == 0 {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,59 @@
+package serverhandler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestForward(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	in := make(chan int, 3)
+	out := make(chan *string, 1)
+	cancelled := make(chan struct{})
+
+	// odd numbers are skipped, even ones converted
+	forward(ctx, in, out, func() { close(cancelled) }, func(value interface{}) (interface{}, bool) {
+		if value.(int)%2 == 1 {
+			return nil, false
+		}
+		s := "even"
+		return &s, true
+	})
+	in <- 1
+	in <- 2
+	select {
+	case s := <-out:
+		if *s != "even" {
+			t.Errorf("forwarded %q, want even", *s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("value not forwarded")
+	}
+
+	// subscription ends with request, also while waiting for reader
+	in <- 4
+	in <- 6
+	cancelCtx()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("subscription not ended with request")
+	}
+}
+
+func TestForward_nil(t *testing.T) {
+	in := make(chan *string, 1)
+	out := make(chan *string, 1)
+	forward(context.Background(), in, out, func() {}, nil)
+
+	in <- nil
+	select {
+	case s := <-out:
+		if s != nil {
+			t.Errorf("forwarded %v, want nil", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("value not forwarded")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"main/chat"
 	"main/client"
 	"main/gql"
+	"main/store"
 	"net/http"
 	"strings"
 	"sync"
@@ -29,26 +30,44 @@ type ClientServer struct {
 	mutex  sync.Mutex
 }
 
-// NewChatLastMessage implement me
+// NewChatLastMessage is subscription event with text of the last message of chat, when it changes
 func (c *ClientServer) NewChatLastMessage(ctx context.Context, chatID string) (<-chan *string, error) {
-	panic("implement me")
+	if _, err := c.client.GetChat(chatID); err != nil {
+		return nil, err
+	}
+	events, cancel := c.client.SubscribeChatEvents(client.CHAT_EVENT_LAST_MESSAGE, chatID)
+	texts := make(chan *string, 1)
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+	}).Debug("NewChatLastMessage:")
+
+	forward(ctx, events, texts, cancel, func(value interface{}) (interface{}, bool) {
+		text := value.(client.ChatEvent).LastMessage.Text
+		return &text, true
+	})
+	return texts, nil
 }
 
-// NewFriend implement me
+// NewFriend is subscription event when user requests friendship, accepts it or changes nick
 func (c *ClientServer) NewFriend(ctx context.Context) (<-chan *gql.Friend, error) {
-	panic("implement me")
+	friends, cancel := c.client.SubscribeFriends()
+	out := make(chan *gql.Friend, 1)
+
+	log.Debug("NewFriend:")
+
+	forward(ctx, friends, out, cancel, func(value interface{}) (interface{}, bool) {
+		return value.(client.ChatEvent).Friend, true
+	})
+	return out, nil
 }
 
 // GetFriendsTypeList returns friends of user as string Friend struct
 func (c *ClientServer) GetFriendsTypeList(ctx context.Context) ([]*gql.Friend, error) {
-	var tmpFriendsList []*gql.Friend
-
-	// find chat and forward message
-	c.mutex.Lock()
-	for _, friend := range c.client.FriendsList {
-		tmpFriendsList = append(tmpFriendsList, friend)
+	tmpFriendsList, err := c.client.Friends()
+	if err != nil {
+		return nil, err
 	}
-	c.mutex.Unlock()
 
 	//log.Println("FetchMessages: chatID ", chatID, " resp: ", textList)
 
@@ -64,23 +83,43 @@ func (c *ClientServer) GetFriendsTypeList(ctx context.Context) ([]*gql.Friend, e
 	return []*gql.Friend{}, nil
 }
 
-// ChangeChatName implement me
+// ChangeChatName is mutation renaming chat, returns new name
 func (c *ClientServer) ChangeChatName(ctx context.Context, chatID string, chatName string) (*string, error) {
-	panic("implement me")
+	if err := c.client.ChangeChatName(chatID, chatName); err != nil {
+		return nil, err
+	}
+	ch, err := c.client.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	name := ch.ChatName()
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+		"resp":   name,
+	}).Debug("ChangeChatName:")
+
+	return &name, nil
 }
 
 // GetFriendList returns friends of user as string list
 func (c *ClientServer) GetFriendList(ctx context.Context) ([]*string, error) {
 	var friendsStringList []*string
 
-	// map each friend to name (in future {name, userID})
-	c.mutex.Lock()
-	for _, friend := range c.client.FriendsList {
+	friends, err := c.client.Friends()
+	if err != nil {
+		return nil, err
+	}
+
+	// map each friend to name (in future {name, userID}), requests and blocked users are not friends
+	for _, friend := range friends {
+		if friend.State != client.FRIEND_ACCEPTED {
+			continue
+		}
 		log.Debug("GetFriendList: having ", friend)
 		tmpStr := friend.Nick
 		friendsStringList = append(friendsStringList, tmpStr)
 	}
-	c.mutex.Unlock()
 
 	log.WithFields(log.Fields{
 		"friendsStringList": friendsStringList,
@@ -94,32 +133,141 @@ func (c *ClientServer) GetUserName(ctx context.Context) (string, error) {
 	return c.client.GetUserID(), nil
 }
 
-// AddFriend implement me
+// GetUserNick returns own nick, nil if not set
+func (c *ClientServer) GetUserNick(ctx context.Context) (*string, error) {
+	nick := c.client.Nick()
+	if nick == "" {
+		return nil, nil
+	}
+	return &nick, nil
+}
+
+// AddFriend is mutation sending friend request to user, returns state of friend
 func (c *ClientServer) AddFriend(ctx context.Context, userUUID string) (*string, error) {
-	panic("implement me")
+	state, err := c.client.AddFriend(userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"userID": userUUID,
+		"state":  state,
+	}).Debug("AddFriend:")
+
+	return &state, nil
+}
+
+// AcceptFriend is mutation accepting friend request of user
+func (c *ClientServer) AcceptFriend(ctx context.Context, userID string) (*gql.Friend, error) {
+	return c.client.AcceptFriend(userID)
+}
+
+// DeclineFriend is mutation declining friend request of user
+func (c *ClientServer) DeclineFriend(ctx context.Context, userID string) (bool, error) {
+	if err := c.client.DeclineFriend(userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveFriend is mutation removing friend or cancelling own friend request
+func (c *ClientServer) RemoveFriend(ctx context.Context, userID string) (bool, error) {
+	if err := c.client.RemoveFriend(userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// BlockUser is mutation dropping adverts, messages and friend requests of user
+func (c *ClientServer) BlockUser(ctx context.Context, userID string) (bool, error) {
+	if err := c.client.BlockUser(userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnblockUser is mutation removing block of user
+func (c *ClientServer) UnblockUser(ctx context.Context, userID string) (bool, error) {
+	if err := c.client.UnblockUser(userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetStatus is mutation setting own status announced to other clients, returns it
+func (c *ClientServer) SetStatus(ctx context.Context, status string) (string, error) {
+	if err := c.client.SetStatus(client.PresenceStatus(status)); err != nil {
+		return "", err
+	}
+
+	log.WithFields(log.Fields{
+		"status": status,
+	}).Debug("SetStatus:")
+
+	return string(c.client.Status()), nil
+}
+
+// GetUserStatus returns own status announced to other clients
+func (c *ClientServer) GetUserStatus(ctx context.Context) (string, error) {
+	return string(c.client.Status()), nil
+}
+
+// PresenceChanged is subscription event when friend goes online, away or offline
+func (c *ClientServer) PresenceChanged(ctx context.Context) (<-chan *gql.Friend, error) {
+	friends, cancel := c.client.SubscribePresence()
+	out := make(chan *gql.Friend, 1)
+
+	log.Debug("PresenceChanged:")
+
+	forward(ctx, friends, out, cancel, func(value interface{}) (interface{}, bool) {
+		return value.(client.ChatEvent).Friend, true
+	})
+	return out, nil
 }
 
-// ChangeNick implement me
+// ChangeNick is mutation changing own nick sent to friends, returns new nick
 func (c *ClientServer) ChangeNick(ctx context.Context, userNick string) (*string, error) {
-	panic("implement me")
+	if err := c.client.ChangeNick(userNick); err != nil {
+		return nil, err
+	}
+	nick := c.client.Nick()
+
+	log.WithFields(log.Fields{
+		"nick": nick,
+	}).Debug("ChangeNick:")
+
+	return &nick, nil
 }
 
-// ClientWritingAlert implement me
-func (c *ClientServer) ClientWritingAlert(ctx context.Context, chatID string) (<-chan *string, error) {
-	panic("implement me")
+// ClientWritingAlert is subscription event when participants of chat start or stop typing
+func (c *ClientServer) ClientWritingAlert(ctx context.Context, chatID string) (<-chan []string, error) {
+	if _, err := c.client.GetChat(chatID); err != nil {
+		return nil, err
+	}
+	events, cancel := c.client.SubscribeChatEvents(client.CHAT_EVENT_TYPING, chatID)
+	typing := make(chan []string, 1)
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+	}).Debug("ClientWritingAlert:")
+
+	forward(ctx, events, typing, cancel, func(value interface{}) (interface{}, bool) {
+		return value.(client.ChatEvent).Typing, true
+	})
+	return typing, nil
 }
 
-// FetchMessages returns numOfMessages messages from particular chat
+// FetchMessages returns the newest numOfMessages messages from particular chat, the oldest first
 func (c *ClientServer) FetchMessages(ctx context.Context, chatID string, numOfMessages int) ([]*gql.TextMessage, error) {
-	// find chat and forward message
-	c.mutex.Lock()
-	numOfExistingMessages := len(c.client.GetChatList()[chatID].TextMessageList)
-	// make sure not exiting number of map array elements
-	if numOfMessages > numOfExistingMessages {
-		numOfMessages = numOfExistingMessages
+	if numOfMessages <= 0 {
+		return []*gql.TextMessage{}, nil
 	}
-	textList := c.client.GetChatList()[chatID].TextMessageList[0:numOfMessages]
-	c.mutex.Unlock()
+	// find chat and read its newest messages from store
+	page, err := c.client.MessagesPage(chatID, "", "", numOfMessages, true)
+	if err != nil {
+		return nil, err
+	}
+	textList := page.Messages
 
 	//log.Println("FetchMessages: chatID ", chatID, " resp: ", textList)
 
@@ -136,14 +284,28 @@ func (c *ClientServer) FetchMessages(ctx context.Context, chatID string, numOfMe
 	return []*gql.TextMessage{}, nil
 }
 
-// ClientWriting implement me
-func (c *ClientServer) ClientWriting(ctx context.Context, chatID string, userID string) (*string, error) {
-	panic("implement me")
+// ClientWriting is mutation telling participants of chat that user started or stopped typing
+func (c *ClientServer) ClientWriting(ctx context.Context, chatID string, typing bool) (bool, error) {
+	return c.client.SetTyping(chatID, typing)
 }
 
-// ChangeChatAvatar implement me
+// ChangeChatAvatar is mutation changing avatar of chat, returns its new address
 func (c *ClientServer) ChangeChatAvatar(ctx context.Context, chatID string, avatarAddr string) (*string, error) {
-	panic("implement me")
+	if err := c.client.ChangeChatAvatar(chatID, avatarAddr); err != nil {
+		return nil, err
+	}
+	ch, err := c.client.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	avatar := ch.ChatAvatar()
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+		"resp":   avatar,
+	}).Debug("ChangeChatAvatar:")
+
+	return &avatar, nil
 }
 
 // NewClientServer returns new ClientServer
@@ -190,17 +352,23 @@ func (c *ClientServer) Serve(port int) error {
 // PostMessage is mutation used to post new message on chat
 func (c *ClientServer) PostMessage(ctx context.Context, chatID string, text string) (*gql.TextMessage, error) {
 
+	ch, err := c.client.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if !ch.HasParticipant(c.client.GetUserID()) {
+		return nil, client.ErrNotParticipant
+	}
+
 	m := gql.TextMessage{
 		MessageID: ksuid.New().String(),
 		ChatID:    chatID,
 		User:      c.client.GetUserID(),
 		TimeStamp: time.Now().UTC(),
+		Clock:     ch.Tick(),
 		Text:      text,
 	}
-
-	c.mutex.Lock()
-	c.client.GetChatList()[chatID].SendMessageChan <- m
-	c.mutex.Unlock()
+	ch.SendMessageChan <- m
 
 	//log.Println("PostMessage: chatID ", chatID, " text \"", text, "\", resp: ", m)
 
@@ -217,9 +385,12 @@ func (c *ClientServer) PostMessage(ctx context.Context, chatID string, text stri
 func (c *ClientServer) CreateChat(ctx context.Context, users []string) (*gql.Chat, error) {
 	ch := c.client.CreateChat(users)
 
+	owner := ch.Owner()
 	tmpChat := &gql.Chat{
 		ChatID:         ch.ChatID,
 		ClientsIPsList: ch.ClientsIPsList(),
+		Owner:          &owner,
+		Admins:         ch.Admins(),
 	}
 
 	//log.Println("CreateChat: users ", users, " resp: ", tmpChat.ChatID)
@@ -235,10 +406,11 @@ func (c *ClientServer) CreateChat(ctx context.Context, users []string) (*gql.Cha
 
 // Messages is query returns all messages from particular chat
 func (c *ClientServer) Messages(ctx context.Context, chatID string) ([]*gql.TextMessage, error) {
-	// find chat and forward message
-	c.mutex.Lock()
-	textList := c.client.GetChatList()[chatID].TextMessageList
-	c.mutex.Unlock()
+	// find chat and read its messages from store
+	textList, err := c.client.Messages(chatID)
+	if err != nil {
+		return nil, err
+	}
 
 	// log.Println("Messages: chatID ", chatID, " resp: ", textList)
 
@@ -255,11 +427,36 @@ func (c *ClientServer) Messages(ctx context.Context, chatID string) ([]*gql.Text
 	return []*gql.TextMessage{}, nil
 }
 
+// MessagesConnection is query returning page of messages of chat, the newest first (see Pagination.go)
+func (c *ClientServer) MessagesConnection(ctx context.Context, chatID string, first *int, after *string, last *int, before *string) (*gql.TextMessageConnection, error) {
+	query, err := newPageQuery(first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
+	// store does not limit page of size 0
+	if query.limit == 0 {
+		return messageConnection(store.MessagePage{}), nil
+	}
+	page, err := c.client.MessagesPage(chatID, query.afterMessageID, query.beforeMessageID, query.limit, query.newest)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+		"resp":   len(page.Messages),
+	}).Debug("MessagesConnection:")
+
+	return messageConnection(page), nil
+}
+
 // ChatUsers is query that returns chat users
 func (c *ClientServer) ChatUsers(ctx context.Context, chatID string) ([]string, error) {
-	c.mutex.Lock()
-	list := c.client.GetChatList()[chatID].ClientsIPsList()
-	c.mutex.Unlock()
+	ch, err := c.client.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	list := ch.ClientsIPsList()
 
 	// log.Println("ChatUsers: chatID ", chatID, " resp: ", list)
 
@@ -285,17 +482,11 @@ func (c *ClientServer) Chats(ctx context.Context) ([]*gql.Chat, error) {
 	c.mutex.Unlock()
 
 	for _, ch := range chats {
-		var lastMessage *gql.TextMessage
-		if len(ch.TextMessageList) != 0 {
-			lastMessage = ch.TextMessageList[len(ch.TextMessageList)-1]
+		tmpChat, err := c.chatToGraphql(ch)
+		if err != nil {
+			return nil, err
 		}
-		gqlChats = append(gqlChats, &gql.Chat{
-			ChatID:         ch.ChatID,
-			ClientsIPsList: ch.ClientsIPsList(),
-			LatestMessage: 	lastMessage,
-			ClientWriting:  nil,
-			ChatName:       &ch.ChatName,
-		})
+		gqlChats = append(gqlChats, tmpChat)
 	}
 
 	// log.Println("Chats: resp: ", gqlChats)
@@ -310,39 +501,209 @@ func (c *ClientServer) Chats(ctx context.Context) ([]*gql.Chat, error) {
 
 // MessagePosted is subscription event when new message is posted in particular chat
 func (c *ClientServer) MessagePosted(ctx context.Context, chatID string) (<-chan *gql.TextMessage, error) {
-	// Create new channel for request
-	c.mutex.Lock()
-	messages := c.client.GetChatList()[chatID].MessagesChan
-	c.mutex.Unlock()
-
-	// log.Println("MessagePosted: chatID ", chatID, " resp: ", messages)
+	ch, err := c.client.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	// every subscription has its own channel, it ends with request
+	messages, cancel := ch.SubscribeMessages()
+	out := make(chan *gql.TextMessage, 1)
 
 	log.WithFields(log.Fields{
-		"chatID": chatID,
-		"resp": messages,
+		"chatID":      chatID,
+		"subscribers": ch.MessageSubscribers(),
 	}).Debug("MessagePosted:")
 
-	return messages, nil
+	forward(ctx, messages, out, cancel, nil)
+	return out, nil
 }
 
 // UserJoined is subscription event when new user joins chat
 func (c *ClientServer) UserJoined(ctx context.Context, chatID string) (<-chan string, error) {
-	// TODO implement me
-	// log.Println("UserJoined: chatID ", chatID)
+	if _, err := c.client.GetChat(chatID); err != nil {
+		return nil, err
+	}
+	joined, cancel := c.client.SubscribeJoined(chatID)
+	out := make(chan string, 1)
 
 	log.WithFields(log.Fields{
 		"chatID": chatID,
 	}).Debug("UserJoined:")
 
-	return make(chan string, 1), nil
+	forward(ctx, joined, out, cancel, func(value interface{}) (interface{}, bool) {
+		return value.(client.ChatEvent).UserID, true
+	})
+	return out, nil
+}
+
+// AddChatMember is mutation adding user to chat
+func (c *ClientServer) AddChatMember(ctx context.Context, chatID string, userID string) ([]string, error) {
+	if err := c.client.AddMember(chatID, userID); err != nil {
+		return nil, err
+	}
+	return c.ChatUsers(ctx, chatID)
+}
+
+// RemoveChatMember is mutation removing other user from chat
+func (c *ClientServer) RemoveChatMember(ctx context.Context, chatID string, userID string) ([]string, error) {
+	if err := c.client.RemoveMember(chatID, userID); err != nil {
+		return nil, err
+	}
+	return c.ChatUsers(ctx, chatID)
+}
+
+// SetChatAdmin is mutation making participant admin of chat or member again
+func (c *ClientServer) SetChatAdmin(ctx context.Context, chatID string, userID string, admin bool) ([]string, error) {
+	if err := c.client.SetAdmin(chatID, userID, admin); err != nil {
+		return nil, err
+	}
+	ch, err := c.client.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+		"userID": userID,
+		"admin":  admin,
+	}).Debug("SetChatAdmin:")
+
+	return ch.Admins(), nil
+}
+
+// LeaveChat is mutation leaving chat
+func (c *ClientServer) LeaveChat(ctx context.Context, chatID string) (bool, error) {
+	if err := c.client.LeaveChat(chatID); err != nil {
+		return false, err
+	}
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+	}).Debug("LeaveChat:")
+
+	return true, nil
+}
+
+// ChatUpdated is subscription event when name or avatar of chat is changed
+func (c *ClientServer) ChatUpdated(ctx context.Context, chatID string) (<-chan *gql.Chat, error) {
+	ch, err := c.client.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	changed, cancel := c.client.SubscribeChatChanged(chatID)
+	chats := make(chan *gql.Chat, 1)
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+	}).Debug("ChatUpdated:")
+
+	forward(ctx, changed, chats, cancel, func(interface{}) (interface{}, bool) {
+		tmpChat, err := c.chatToGraphql(ch)
+		if err != nil {
+			log.WithError(err).Warn("ChatUpdated: chat not sent")
+			return nil, false
+		}
+		return tmpChat, true
+	})
+	return chats, nil
+}
+
+// chatToGraphql converts chat to its graphql type
+func (c *ClientServer) chatToGraphql(ch *chat.Chat) (*gql.Chat, error) {
+	lastMessage, err := ch.LastMessage()
+	if err != nil {
+		return nil, err
+	}
+	name, owner := ch.ChatName(), ch.Owner()
+	tmpChat := &gql.Chat{
+		ChatID:         ch.ChatID,
+		ClientsIPsList: ch.ClientsIPsList(),
+		LatestMessage:  lastMessage,
+		ClientWriting:  c.client.Typing(ch.ChatID),
+		ChatName:       &name,
+		Owner:          &owner,
+		Admins:         ch.Admins(),
+	}
+	if avatar := ch.ChatAvatar(); avatar != "" {
+		tmpChat.ChatAvatar = &avatar
+	}
+	return tmpChat, nil
 }
 
-// ChatCreated is subscription event when new chat is created
-// to be implemented
+// ChatCreated is subscription event when new chat is created by user or by other participant
 func (c *ClientServer) ChatCreated(ctx context.Context) (<-chan *gql.Chat, error) {
-	// TODO implement me
-	log.Println("ChatCreated: ")
-	return make(chan *gql.Chat, 1), nil
+	events, cancel := c.client.SubscribeChatEvents(client.CHAT_EVENT_CREATED, "")
+	chats := make(chan *gql.Chat, 1)
+
+	log.Debug("ChatCreated:")
+
+	forward(ctx, events, chats, cancel, func(value interface{}) (interface{}, bool) {
+		ch, err := c.client.GetChat(value.(client.ChatEvent).ChatID)
+		if err != nil {
+			return nil, false
+		}
+		tmpChat, err := c.chatToGraphql(ch)
+		if err != nil {
+			log.WithError(err).Warn("ChatCreated: chat not sent")
+			return nil, false
+		}
+		return tmpChat, true
+	})
+	return chats, nil
+}
+
+// MarkRead is mutation marking messages of chat up to messageID as read
+func (c *ClientServer) MarkRead(ctx context.Context, chatID string, messageID string) (int, error) {
+	count, err := c.client.MarkRead(chatID, messageID)
+
+	log.WithFields(log.Fields{
+		"chatID": chatID,
+		"resp":   count,
+	}).Debug("MarkRead:")
+
+	return count, err
+}
+
+// MessageReceipt is subscription event when message of chat is delivered to or read by participant
+func (c *ClientServer) MessageReceipt(ctx context.Context, chatID string) (<-chan *gql.MessageReceipt, error) {
+	if _, err := c.client.GetChat(chatID); err != nil {
+		return nil, err
+	}
+	receipts, cancel := c.client.SubscribeReceipts(chatID)
+	out := make(chan *gql.MessageReceipt, 1)
+
+	forward(ctx, receipts, out, cancel, nil)
+	return out, nil
+}
+
+// Peers is query returning state of connections with other clients
+func (c *ClientServer) Peers(ctx context.Context) ([]*gql.Peer, error) {
+	peers := []*gql.Peer{}
+	for _, p := range c.client.Peers() {
+		peers = append(peers, peerToGraphql(p))
+	}
+	return peers, nil
+}
+
+// PeerStatusChanged is subscription event when connection with other client changes its state
+func (c *ClientServer) PeerStatusChanged(ctx context.Context) (<-chan *gql.Peer, error) {
+	events, cancel := c.client.SubscribePeerEvents()
+	peers := make(chan *gql.Peer, 1)
+
+	forward(ctx, events, peers, cancel, func(value interface{}) (interface{}, bool) {
+		return peerToGraphql(value.(client.PeerEvent)), true
+	})
+	return peers, nil
+}
+
+// peerToGraphql converts connection state to its graphql type
+func peerToGraphql(e client.PeerEvent) *gql.Peer {
+	return &gql.Peer{
+		UserID:  e.UserID,
+		Address: e.Address,
+		State:   string(e.State),
+		Online:  e.State == client.PEER_CONNECTED,
+	}
 }
 
 // Mutation returns mutation resolver
@@ -0,0 +1,601 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	bolt "go.etcd.io/bbolt"
+	"main/gql"
+	"time"
+)
+
+// buckets of BoltStore
+// messages bucket has nested bucket per chat, keyed by MessageID
+// order bucket has nested bucket per chat indexing its messages, keyed by big endian clock and MessageID
+// (see orderKey), so messages are iterated with cursor in order of MessageBefore
+// outbox bucket has nested bucket per user, keyed by big endian entry ID (so iterated in order)
+// receipts bucket has nested bucket per chat, keyed by MessageID, values are maps userID : status
+var (
+	chatsBucket    = []byte("chats")
+	messagesBucket = []byte("messages")
+	orderBucket    = []byte("order")
+	friendsBucket  = []byte("friends")
+	addrsBucket    = []byte("addresses")
+	keysBucket     = []byte("keys")
+	outboxBucket   = []byte("outbox")
+	receiptsBucket = []byte("receipts")
+	settingsBucket = []byte("settings")
+)
+
+// BoltStore is Store kept in single embedded database file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens (or creates) database file at path
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{chatsBucket, messagesBucket, orderBucket, friendsBucket, addrsBucket, keysBucket, outboxBucket, receiptsBucket, settingsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return indexMessages(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) SaveChat(chat ChatRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(chatsBucket), chat.ChatID, chat)
+	})
+}
+
+func (s *BoltStore) Chat(chatID string) (ChatRecord, error) {
+	var chat ChatRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(chatsBucket), chatID, &chat)
+	})
+	return chat, err
+}
+
+func (s *BoltStore) Chats() ([]ChatRecord, error) {
+	var chats []ChatRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(chatsBucket).ForEach(func(k, v []byte) error {
+			var chat ChatRecord
+			if err := json.Unmarshal(v, &chat); err != nil {
+				return err
+			}
+			chats = append(chats, chat)
+			return nil
+		})
+	})
+	return chats, err
+}
+
+func (s *BoltStore) SetParticipants(chatID string, participants []string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var chat ChatRecord
+		if err := getJSON(tx.Bucket(chatsBucket), chatID, &chat); err != nil {
+			return err
+		}
+		chat.Participants = participants
+		return putJSON(tx.Bucket(chatsBucket), chatID, chat)
+	})
+}
+
+func (s *BoltStore) Participants(chatID string) ([]string, error) {
+	chat, err := s.Chat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	return chat.Participants, nil
+}
+
+func (s *BoltStore) AddMessage(message *gql.TextMessage) (bool, error) {
+	added := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(messagesBucket).CreateBucketIfNotExists([]byte(message.ChatID))
+		if err != nil {
+			return err
+		}
+		if bucket.Get([]byte(message.MessageID)) != nil {
+			return nil
+		}
+		order, err := tx.Bucket(orderBucket).CreateBucketIfNotExists([]byte(message.ChatID))
+		if err != nil {
+			return err
+		}
+		added = true
+		if err := order.Put(orderKey(message.Clock, message.MessageID), nil); err != nil {
+			return err
+		}
+		return putJSON(bucket, message.MessageID, message)
+	})
+	return added, err
+}
+
+func (s *BoltStore) Messages(chatID string) ([]*gql.TextMessage, error) {
+	messages := []*gql.TextMessage{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		order := tx.Bucket(orderBucket).Bucket([]byte(chatID))
+		if order == nil {
+			return nil
+		}
+		bucket := tx.Bucket(messagesBucket).Bucket([]byte(chatID))
+		return order.ForEach(func(k, v []byte) error {
+			message, err := orderedMessage(bucket, k)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, message)
+			return nil
+		})
+	})
+	return messages, err
+}
+
+func (s *BoltStore) MessagesSince(chatID string, sinceMessageID string, limit int) ([]*gql.TextMessage, error) {
+	messages := []*gql.TextMessage{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(messagesBucket).Bucket([]byte(chatID))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		k, v := cursor.Seek([]byte(sinceMessageID))
+		if k != nil && string(k) == sinceMessageID {
+			k, v = cursor.Next()
+		}
+		for ; k != nil && (limit <= 0 || len(messages) < limit); k, v = cursor.Next() {
+			var message gql.TextMessage
+			if err := json.Unmarshal(v, &message); err != nil {
+				return err
+			}
+			messages = append(messages, &message)
+		}
+		return nil
+	})
+	return messages, err
+}
+
+func (s *BoltStore) MessagesPage(chatID string, afterMessageID, beforeMessageID string, limit int, newest bool) (MessagePage, error) {
+	page := MessagePage{Messages: []*gql.TextMessage{}}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(messagesBucket).Bucket([]byte(chatID))
+		// page is between keys of given messages, nil does not limit it
+		var low, high []byte
+		if afterMessageID != "" {
+			key, err := messageOrderKey(bucket, afterMessageID)
+			if err != nil {
+				return err
+			}
+			low = key
+		}
+		if beforeMessageID != "" {
+			key, err := messageOrderKey(bucket, beforeMessageID)
+			if err != nil {
+				return err
+			}
+			high = key
+		}
+		if low != nil && high != nil && bytes.Compare(high, low) < 0 {
+			high = low
+		}
+		order := tx.Bucket(orderBucket).Bucket([]byte(chatID))
+		if order == nil {
+			return nil
+		}
+
+		// keys of page, the newest are read backwards from the end of page
+		var keys [][]byte
+		cursor := order.Cursor()
+		full := func() bool { return limit > 0 && len(keys) >= limit }
+		if newest {
+			k, _ := cursor.Last()
+			if high != nil {
+				if k, _ = cursor.Seek(high); k == nil {
+					k, _ = cursor.Last()
+				} else {
+					k, _ = cursor.Prev()
+				}
+			}
+			for ; k != nil && (low == nil || bytes.Compare(k, low) > 0) && !full(); k, _ = cursor.Prev() {
+				keys = append(keys, k)
+			}
+			for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+				keys[i], keys[j] = keys[j], keys[i]
+			}
+		} else {
+			k := nextKey(cursor, low)
+			for ; k != nil && (high == nil || bytes.Compare(k, high) < 0) && !full(); k, _ = cursor.Next() {
+				keys = append(keys, k)
+			}
+		}
+
+		if len(keys) == 0 {
+			// empty page lies just after message afterMessageID
+			page.Older = low != nil
+			page.Newer = nextKey(cursor, low) != nil
+			return nil
+		}
+		for _, k := range keys {
+			message, err := orderedMessage(bucket, k)
+			if err != nil {
+				return err
+			}
+			page.Messages = append(page.Messages, message)
+		}
+		cursor.Seek(keys[0])
+		k, _ := cursor.Prev()
+		page.Older = k != nil
+		page.Newer = nextKey(cursor, keys[len(keys)-1]) != nil
+		return nil
+	})
+	if err != nil {
+		return MessagePage{}, err
+	}
+	return page, nil
+}
+
+func (s *BoltStore) LastMessage(chatID string) (*gql.TextMessage, error) {
+	var message *gql.TextMessage
+	err := s.db.View(func(tx *bolt.Tx) error {
+		order := tx.Bucket(orderBucket).Bucket([]byte(chatID))
+		if order == nil {
+			return ErrNotFound
+		}
+		k, _ := order.Cursor().Last()
+		if k == nil {
+			return ErrNotFound
+		}
+		var err error
+		message, err = orderedMessage(tx.Bucket(messagesBucket).Bucket([]byte(chatID)), k)
+		return err
+	})
+	return message, err
+}
+
+func (s *BoltStore) SetReceipt(chatID string, messageID string, userID string, status ReceiptStatus) (bool, error) {
+	changed := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(receiptsBucket).CreateBucketIfNotExists([]byte(chatID))
+		if err != nil {
+			return err
+		}
+		receipts := make(map[string]ReceiptStatus)
+		if err := getJSON(bucket, messageID, &receipts); err != nil && err != ErrNotFound {
+			return err
+		}
+		if receipts[userID].Covers(status) {
+			return nil
+		}
+		receipts[userID] = status
+		changed = true
+		return putJSON(bucket, messageID, receipts)
+	})
+	return changed, err
+}
+
+func (s *BoltStore) Receipts(chatID string, messageID string) (map[string]ReceiptStatus, error) {
+	receipts := make(map[string]ReceiptStatus)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(receiptsBucket).Bucket([]byte(chatID))
+		if bucket == nil {
+			return nil
+		}
+		if err := getJSON(bucket, messageID, &receipts); err != nil && err != ErrNotFound {
+			return err
+		}
+		return nil
+	})
+	return receipts, err
+}
+
+func (s *BoltStore) SaveFriend(friend *gql.Friend) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(friendsBucket), friend.UserID, friend)
+	})
+}
+
+func (s *BoltStore) Friends() ([]*gql.Friend, error) {
+	var friends []*gql.Friend
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(friendsBucket).ForEach(func(k, v []byte) error {
+			var friend gql.Friend
+			if err := json.Unmarshal(v, &friend); err != nil {
+				return err
+			}
+			friends = append(friends, &friend)
+			return nil
+		})
+	})
+	return friends, err
+}
+
+func (s *BoltStore) Friend(userID string) (*gql.Friend, error) {
+	var friend gql.Friend
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(friendsBucket), userID, &friend)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &friend, nil
+}
+
+func (s *BoltStore) DeleteFriend(userID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(friendsBucket).Delete([]byte(userID))
+	})
+}
+
+func (s *BoltStore) SetSetting(key string, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(settingsBucket).Put([]byte(key), []byte(value))
+	})
+}
+
+func (s *BoltStore) Setting(key string) (string, error) {
+	var value string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(settingsBucket).Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		value = string(data)
+		return nil
+	})
+	return value, err
+}
+
+func (s *BoltStore) SetAddress(userID string, address string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(addrsBucket).Put([]byte(userID), []byte(address))
+	})
+}
+
+func (s *BoltStore) Addresses() (map[string]string, error) {
+	addrs := make(map[string]string)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(addrsBucket).ForEach(func(k, v []byte) error {
+			addrs[string(k)] = string(v)
+			return nil
+		})
+	})
+	return addrs, err
+}
+
+func (s *BoltStore) SetPublicKey(userID string, publicKey []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(keysBucket).Put([]byte(userID), publicKey)
+	})
+}
+
+func (s *BoltStore) PublicKey(userID string) ([]byte, error) {
+	var publicKey []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(keysBucket).Get([]byte(userID))
+		if data == nil {
+			return ErrNotFound
+		}
+		// data is valid only during transaction
+		publicKey = append([]byte(nil), data...)
+		return nil
+	})
+	return publicKey, err
+}
+
+func (s *BoltStore) AppendOutbox(userID string, entry OutboxEntry, limit int) (int, error) {
+	dropped := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(outboxBucket).CreateBucketIfNotExists([]byte(userID))
+		if err != nil {
+			return err
+		}
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := putJSON(bucket, string(outboxKey(id)), entry); err != nil {
+			return err
+		}
+		if limit <= 0 {
+			return nil
+		}
+
+		// drop the oldest
+		count := countKeys(bucket)
+		cursor := bucket.Cursor()
+		for k, _ := cursor.First(); k != nil && count-dropped > limit; k, _ = cursor.Next() {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+			dropped++
+		}
+		return nil
+	})
+	return dropped, err
+}
+
+func (s *BoltStore) Outbox(userID string, limit int) ([]OutboxEntry, error) {
+	var entries []OutboxEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBucket).Bucket([]byte(userID))
+		if bucket == nil {
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil && (limit <= 0 || len(entries) < limit); k, v = cursor.Next() {
+			var entry OutboxEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			entry.ID = binary.BigEndian.Uint64(k)
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (s *BoltStore) OutboxLen(userID string) (int, error) {
+	count := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(outboxBucket).Bucket([]byte(userID)); bucket != nil {
+			count = countKeys(bucket)
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (s *BoltStore) DeleteOutbox(userID string, ids []uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBucket).Bucket([]byte(userID))
+		if bucket == nil {
+			return nil
+		}
+		for _, id := range ids {
+			if err := bucket.Delete(outboxKey(id)); err != nil {
+				return err
+			}
+		}
+		// users with empty outbox are not returned by OutboxUsers
+		if k, _ := bucket.Cursor().First(); k == nil {
+			return tx.Bucket(outboxBucket).DeleteBucket([]byte(userID))
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) OutboxUsers() ([]string, error) {
+	var users []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).ForEach(func(k, v []byte) error {
+			users = append(users, string(k))
+			return nil
+		})
+	})
+	return users, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// countKeys returns number of keys in bucket, including ones put in current transaction
+func countKeys(bucket *bolt.Bucket) int {
+	count := 0
+	cursor := bucket.Cursor()
+	for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+		count++
+	}
+	return count
+}
+
+// orderKey returns key of message in order bucket, keys are ordered like messages with MessageBefore
+// clock is stored with flipped sign bit, so negative clocks come first
+func orderKey(clock int, messageID string) []byte {
+	key := make([]byte, 8, 8+len(messageID))
+	binary.BigEndian.PutUint64(key, uint64(int64(clock))^(1<<63))
+	return append(key, messageID...)
+}
+
+// messageOrderKey returns key in order bucket of message stored in chat bucket, ErrNotFound if it is not stored
+func messageOrderKey(bucket *bolt.Bucket, messageID string) ([]byte, error) {
+	var message gql.TextMessage
+	if bucket == nil {
+		return nil, fmt.Errorf("%w: message %s", ErrNotFound, messageID)
+	}
+	if err := getJSON(bucket, messageID, &message); err != nil {
+		if err == ErrNotFound {
+			return nil, fmt.Errorf("%w: message %s", ErrNotFound, messageID)
+		}
+		return nil, err
+	}
+	return orderKey(message.Clock, message.MessageID), nil
+}
+
+// orderedMessage returns message of chat bucket with given key of order bucket
+func orderedMessage(bucket *bolt.Bucket, key []byte) (*gql.TextMessage, error) {
+	var message gql.TextMessage
+	if bucket == nil {
+		return nil, ErrNotFound
+	}
+	if err := getJSON(bucket, string(key[8:]), &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// nextKey moves cursor to the first key after given one (the first key for nil) and returns it
+func nextKey(cursor *bolt.Cursor, key []byte) []byte {
+	if key == nil {
+		k, _ := cursor.First()
+		return k
+	}
+	k, _ := cursor.Seek(key)
+	if k != nil && bytes.Equal(k, key) {
+		k, _ = cursor.Next()
+	}
+	return k
+}
+
+// indexMessages builds order buckets of chats stored before messages were indexed
+func indexMessages(tx *bolt.Tx) error {
+	return tx.Bucket(messagesBucket).ForEach(func(chatID, v []byte) error {
+		if tx.Bucket(orderBucket).Bucket(chatID) != nil {
+			return nil
+		}
+		order, err := tx.Bucket(orderBucket).CreateBucket(chatID)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(messagesBucket).Bucket(chatID).ForEach(func(k, v []byte) error {
+			var message gql.TextMessage
+			if err := json.Unmarshal(v, &message); err != nil {
+				return err
+			}
+			return order.Put(orderKey(message.Clock, message.MessageID), nil)
+		})
+	})
+}
+
+// outboxKey returns key of outbox entry with given ID
+func outboxKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// putJSON stores value marshalled to JSON under key
+func putJSON(bucket *bolt.Bucket, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), data)
+}
+
+// getJSON unmarshalls value stored under key, returns ErrNotFound if there is none
+func getJSON(bucket *bolt.Bucket, key string, value interface{}) error {
+	data := bucket.Get([]byte(key))
+	if data == nil {
+		return ErrNotFound
+	}
+	return json.Unmarshal(data, value)
+}
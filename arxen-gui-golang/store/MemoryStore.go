@@ -0,0 +1,414 @@
+package store
+
+import (
+	"fmt"
+	"main/gql"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is Store keeping everything in memory, used in tests
+type MemoryStore struct {
+	mutex    sync.RWMutex
+	chats    map[string]ChatRecord                  // chatID : chat
+	messages map[string]map[string]*gql.TextMessage // chatID : messageID : message
+	ordered  map[string][]*gql.TextMessage          // chatID : messages ordered with MessageBefore
+	friends  map[string]*gql.Friend                 // userID : friend
+	addrs    map[string]string                      // userID : address
+	keys     map[string][]byte                      // userID : public key
+	outbox   map[string][]OutboxEntry               // userID : queued entries, the oldest first
+	outboxID uint64                                 // ID of the last queued entry
+	receipts map[string]map[string]ReceiptStatus    // chatID/messageID : userID : status
+	settings map[string]string                      // key : value
+}
+
+// NewMemoryStore returns empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		chats:    make(map[string]ChatRecord),
+		messages: make(map[string]map[string]*gql.TextMessage),
+		ordered:  make(map[string][]*gql.TextMessage),
+		friends:  make(map[string]*gql.Friend),
+		addrs:    make(map[string]string),
+		keys:     make(map[string][]byte),
+		outbox:   make(map[string][]OutboxEntry),
+		receipts: make(map[string]map[string]ReceiptStatus),
+		settings: make(map[string]string),
+	}
+}
+
+func (s *MemoryStore) SaveChat(chat ChatRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.chats[chat.ChatID] = copyChat(chat)
+	return nil
+}
+
+func (s *MemoryStore) Chat(chatID string) (ChatRecord, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	chat, ok := s.chats[chatID]
+	if !ok {
+		return ChatRecord{}, ErrNotFound
+	}
+	return copyChat(chat), nil
+}
+
+func (s *MemoryStore) Chats() ([]ChatRecord, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	chats := make([]ChatRecord, 0, len(s.chats))
+	for _, chat := range s.chats {
+		chats = append(chats, copyChat(chat))
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].ChatID < chats[j].ChatID })
+	return chats, nil
+}
+
+func (s *MemoryStore) SetParticipants(chatID string, participants []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chat, ok := s.chats[chatID]
+	if !ok {
+		return ErrNotFound
+	}
+	chat.Participants = append([]string(nil), participants...)
+	s.chats[chatID] = chat
+	return nil
+}
+
+func (s *MemoryStore) Participants(chatID string) ([]string, error) {
+	chat, err := s.Chat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	return chat.Participants, nil
+}
+
+func (s *MemoryStore) AddMessage(message *gql.TextMessage) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chatMessages, ok := s.messages[message.ChatID]
+	if !ok {
+		chatMessages = make(map[string]*gql.TextMessage)
+		s.messages[message.ChatID] = chatMessages
+	}
+	if _, ok := chatMessages[message.MessageID]; ok {
+		return false, nil
+	}
+	tmpMessage := *message
+	chatMessages[message.MessageID] = &tmpMessage
+	ordered := s.ordered[message.ChatID]
+	i := searchMessage(ordered, &tmpMessage)
+	ordered = append(ordered, nil)
+	copy(ordered[i+1:], ordered[i:])
+	ordered[i] = &tmpMessage
+	s.ordered[message.ChatID] = ordered
+	return true, nil
+}
+
+func (s *MemoryStore) Messages(chatID string) ([]*gql.TextMessage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return copyMessages(s.ordered[chatID]), nil
+}
+
+func (s *MemoryStore) MessagesSince(chatID string, sinceMessageID string, limit int) ([]*gql.TextMessage, error) {
+	messages, err := s.Messages(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(messages, func(i, j int) bool { return messages[i].MessageID < messages[j].MessageID })
+	i := sort.Search(len(messages), func(i int) bool { return messages[i].MessageID > sinceMessageID })
+	messages = messages[i:]
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+func (s *MemoryStore) MessagesPage(chatID string, afterMessageID, beforeMessageID string, limit int, newest bool) (MessagePage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ordered := s.ordered[chatID]
+	start, end := 0, len(ordered)
+	if afterMessageID != "" {
+		message, ok := s.messages[chatID][afterMessageID]
+		if !ok {
+			return MessagePage{}, fmt.Errorf("%w: message %s", ErrNotFound, afterMessageID)
+		}
+		start = searchMessage(ordered, message) + 1
+	}
+	if beforeMessageID != "" {
+		message, ok := s.messages[chatID][beforeMessageID]
+		if !ok {
+			return MessagePage{}, fmt.Errorf("%w: message %s", ErrNotFound, beforeMessageID)
+		}
+		end = searchMessage(ordered, message)
+	}
+	if end < start {
+		end = start
+	}
+	if limit > 0 && end-start > limit {
+		if newest {
+			start = end - limit
+		} else {
+			end = start + limit
+		}
+	}
+	return MessagePage{Messages: copyMessages(ordered[start:end]), Older: start > 0, Newer: end < len(ordered)}, nil
+}
+
+func (s *MemoryStore) LastMessage(chatID string) (*gql.TextMessage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ordered := s.ordered[chatID]
+	if len(ordered) == 0 {
+		return nil, ErrNotFound
+	}
+	tmpMessage := *ordered[len(ordered)-1]
+	return &tmpMessage, nil
+}
+
+// copyMessages returns copies of messages, so callers cannot change stored ones
+func copyMessages(messages []*gql.TextMessage) []*gql.TextMessage {
+	copies := make([]*gql.TextMessage, 0, len(messages))
+	for _, message := range messages {
+		tmpMessage := *message
+		copies = append(copies, &tmpMessage)
+	}
+	return copies
+}
+
+func (s *MemoryStore) SetReceipt(chatID string, messageID string, userID string, status ReceiptStatus) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := chatID + "/" + messageID
+	receipts, ok := s.receipts[key]
+	if !ok {
+		receipts = make(map[string]ReceiptStatus)
+		s.receipts[key] = receipts
+	}
+	if receipts[userID].Covers(status) {
+		return false, nil
+	}
+	receipts[userID] = status
+	return true, nil
+}
+
+func (s *MemoryStore) Receipts(chatID string, messageID string) (map[string]ReceiptStatus, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	receipts := make(map[string]ReceiptStatus)
+	for userID, status := range s.receipts[chatID+"/"+messageID] {
+		receipts[userID] = status
+	}
+	return receipts, nil
+}
+
+func (s *MemoryStore) SaveFriend(friend *gql.Friend) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tmpFriend := *friend
+	s.friends[friend.UserID] = &tmpFriend
+	return nil
+}
+
+func (s *MemoryStore) Friends() ([]*gql.Friend, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	friends := make([]*gql.Friend, 0, len(s.friends))
+	for _, friend := range s.friends {
+		tmpFriend := *friend
+		friends = append(friends, &tmpFriend)
+	}
+	sort.Slice(friends, func(i, j int) bool { return friends[i].UserID < friends[j].UserID })
+	return friends, nil
+}
+
+func (s *MemoryStore) Friend(userID string) (*gql.Friend, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	friend, ok := s.friends[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	tmpFriend := *friend
+	return &tmpFriend, nil
+}
+
+func (s *MemoryStore) DeleteFriend(userID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.friends, userID)
+	return nil
+}
+
+func (s *MemoryStore) SetSetting(key string, value string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.settings[key] = value
+	return nil
+}
+
+func (s *MemoryStore) Setting(key string) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	value, ok := s.settings[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *MemoryStore) SetAddress(userID string, address string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.addrs[userID] = address
+	return nil
+}
+
+func (s *MemoryStore) Addresses() (map[string]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	addrs := make(map[string]string, len(s.addrs))
+	for userID, address := range s.addrs {
+		addrs[userID] = address
+	}
+	return addrs, nil
+}
+
+func (s *MemoryStore) SetPublicKey(userID string, publicKey []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.keys[userID] = append([]byte(nil), publicKey...)
+	return nil
+}
+
+func (s *MemoryStore) PublicKey(userID string) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	publicKey, ok := s.keys[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), publicKey...), nil
+}
+
+func (s *MemoryStore) AppendOutbox(userID string, entry OutboxEntry, limit int) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.outboxID++
+	entry.ID = s.outboxID
+	entry.Data = append([]byte(nil), entry.Data...)
+	entries := append(s.outbox[userID], entry)
+
+	dropped := 0
+	if limit > 0 && len(entries) > limit {
+		dropped = len(entries) - limit
+		entries = append([]OutboxEntry(nil), entries[dropped:]...)
+	}
+	s.outbox[userID] = entries
+	return dropped, nil
+}
+
+func (s *MemoryStore) Outbox(userID string, limit int) ([]OutboxEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := s.outbox[userID]
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return append([]OutboxEntry(nil), entries...), nil
+}
+
+func (s *MemoryStore) OutboxLen(userID string) (int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.outbox[userID]), nil
+}
+
+func (s *MemoryStore) DeleteOutbox(userID string, ids []uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deleted := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	var entries []OutboxEntry
+	for _, entry := range s.outbox[userID] {
+		if !deleted[entry.ID] {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		delete(s.outbox, userID)
+		return nil
+	}
+	s.outbox[userID] = entries
+	return nil
+}
+
+func (s *MemoryStore) OutboxUsers() ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	users := make([]string, 0, len(s.outbox))
+	for userID := range s.outbox {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// copyChat returns chat record not sharing slices and maps with given one
+func copyChat(chat ChatRecord) ChatRecord {
+	chat.Participants = append([]string(nil), chat.Participants...)
+	if chat.Keys != nil {
+		keys := make(map[uint32][]byte, len(chat.Keys))
+		for epoch, key := range chat.Keys {
+			keys[epoch] = append([]byte(nil), key...)
+		}
+		chat.Keys = keys
+	}
+	if chat.ConcurrentKeys != nil {
+		concurrentKeys := make(map[uint32][][]byte, len(chat.ConcurrentKeys))
+		for epoch, keys := range chat.ConcurrentKeys {
+			for _, key := range keys {
+				concurrentKeys[epoch] = append(concurrentKeys[epoch], append([]byte(nil), key...))
+			}
+		}
+		chat.ConcurrentKeys = concurrentKeys
+	}
+	chat.History = append([]MemberVersion(nil), chat.History...)
+	return chat
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"errors"
+	"main/gql"
+	"sort"
+	"time"
+)
+
+// ErrNotFound is returned when requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ChatRecord is persisted state of chat.Chat
+type ChatRecord struct {
+	ChatID       string            `json:"chatID"`
+	ChatName     string            `json:"chatName"`
+	Participants []string          `json:"participants"`
+	KeyEpoch     uint32            `json:"keyEpoch,omitempty"` // epoch of current key
+	Keys         map[uint32][]byte `json:"keys,omitempty"`     // epoch : chat key
+	Owner        string            `json:"owner,omitempty"`    // user ID of creator
+	Admins       []string          `json:"admins,omitempty"`
+	// epoch : keys generated concurrently with the used one, kept to decrypt messages
+	ConcurrentKeys map[uint32][][]byte `json:"concurrentKeys,omitempty"`
+	// userID : the last applied change of role of user
+	Versions   map[string]MemberVersion `json:"versions,omitempty"`
+	ChatAvatar string                   `json:"chatAvatar,omitempty"` // address of avatar image
+	// name or avatar : the last applied change of it
+	MetadataVersions map[string]MemberVersion `json:"metadataVersions,omitempty"`
+	// creation of chat signed by owner and all applied changes of roles, passed to users joining later
+	Creation *MemberVersion  `json:"creation,omitempty"`
+	History  []MemberVersion `json:"history,omitempty"`
+}
+
+// MemberVersion identifies change of role of chat participant or of chat name and avatar,
+// its Lamport clock and user who made it, signature of actor is kept, so change can be passed to users joining later
+type MemberVersion struct {
+	Clock     int    `json:"clock"`
+	Actor     string `json:"actor"`
+	Action    string `json:"action,omitempty"` // action of change of role
+	Signature []byte `json:"signature,omitempty"`
+	UserID    string `json:"userID,omitempty"` // user whose role changed, set in history of chat
+}
+
+// Newer reports if change v was made after other one, concurrent changes are decided by their actors
+func (v MemberVersion) Newer(other MemberVersion) bool {
+	if v.Clock != other.Clock {
+		return v.Clock > other.Clock
+	}
+	return v.Actor > other.Actor
+}
+
+// OutboxEntry is encoded envelope waiting until its recipient is connected
+type OutboxEntry struct {
+	ID     uint64    `json:"-"` // increasing in order entries were queued
+	Data   []byte    `json:"data"`
+	Queued time.Time `json:"queued"`
+}
+
+// MessageBefore reports if message a is ordered before b in their chat
+// messages are ordered by Lamport clock, so every message follows messages its author knew when writing it,
+// concurrent messages (with the same clock) are ordered by MessageID, so all participants see the same order
+func MessageBefore(a, b *gql.TextMessage) bool {
+	if a.Clock != b.Clock {
+		return a.Clock < b.Clock
+	}
+	return a.MessageID < b.MessageID
+}
+
+// searchMessage returns index of message in messages ordered with MessageBefore,
+// or index it would be inserted at if it is not there
+func searchMessage(messages []*gql.TextMessage, message *gql.TextMessage) int {
+	return sort.Search(len(messages), func(i int) bool { return !MessageBefore(messages[i], message) })
+}
+
+// MessagePage is part of history of chat returned by Store.MessagesPage
+type MessagePage struct {
+	Messages []*gql.TextMessage // ordered with MessageBefore, the oldest first
+	Older    bool               // chat has messages ordered before the page
+	Newer    bool               // chat has messages ordered after the page
+}
+
+// ReceiptStatus is how far message got to one of its recipients
+type ReceiptStatus string
+
+const (
+	RECEIPT_DELIVERED ReceiptStatus = "delivered"
+	RECEIPT_READ      ReceiptStatus = "read"
+)
+
+// Covers reports if status already implies other one, statuses only move forward
+func (s ReceiptStatus) Covers(other ReceiptStatus) bool {
+	return s == other || s == RECEIPT_READ
+}
+
+// Store keeps chats, their participants and messages, friends of user,
+// last known addresses of other users, envelopes waiting for them and receipts of messages
+// implementations are safe for concurrent use
+type Store interface {
+	// SaveChat creates or replaces chat record
+	SaveChat(chat ChatRecord) error
+	// Chat returns chat record or ErrNotFound
+	Chat(chatID string) (ChatRecord, error)
+	// Chats returns all chat records
+	Chats() ([]ChatRecord, error)
+
+	// SetParticipants replaces participants of existing chat
+	SetParticipants(chatID string, participants []string) error
+	// Participants returns participants of chat or ErrNotFound
+	Participants(chatID string) ([]string, error)
+
+	// AddMessage stores message in its chat
+	// returns false if message with the same MessageID is already stored
+	AddMessage(message *gql.TextMessage) (bool, error)
+	// Messages returns all messages of chat ordered with MessageBefore
+	Messages(chatID string) ([]*gql.TextMessage, error)
+	// MessagesSince returns at most limit (0 means no limit) messages of chat
+	// with MessageID greater than sinceMessageID, ordered by MessageID (ksuid, so by time of author)
+	MessagesSince(chatID string, sinceMessageID string, limit int) ([]*gql.TextMessage, error)
+	// MessagesPage returns at most limit (0 means no limit) messages of chat ordered with MessageBefore,
+	// only messages after message afterMessageID and before message beforeMessageID (empty IDs do not limit it),
+	// the newest or the oldest of them, ErrNotFound if one of the messages is not stored
+	MessagesPage(chatID string, afterMessageID, beforeMessageID string, limit int, newest bool) (MessagePage, error)
+	// LastMessage returns the last message of chat ordered with MessageBefore (the one with the highest clock) or ErrNotFound
+	LastMessage(chatID string) (*gql.TextMessage, error)
+
+	// SetReceipt saves status of message for recipient with given user ID
+	// returns false if already saved status covers it
+	SetReceipt(chatID string, messageID string, userID string, status ReceiptStatus) (bool, error)
+	// Receipts returns statuses of message, userID : status
+	Receipts(chatID string, messageID string) (map[string]ReceiptStatus, error)
+
+	// SaveFriend creates or replaces friend record, friends are identified by UserID
+	SaveFriend(friend *gql.Friend) error
+	// Friends returns all friends
+	Friends() ([]*gql.Friend, error)
+	// Friend returns friend with given user ID or ErrNotFound
+	Friend(userID string) (*gql.Friend, error)
+	// DeleteFriend removes friend, missing one is not an error
+	DeleteFriend(userID string) error
+
+	// SetSetting saves setting of user, like its nick
+	SetSetting(key string, value string) error
+	// Setting returns setting of user or ErrNotFound
+	Setting(key string) (string, error)
+
+	// SetAddress saves last known address of user
+	SetAddress(userID string, address string) error
+	// Addresses returns last known addresses of users, userID : address
+	Addresses() (map[string]string, error)
+
+	// SetPublicKey saves public key of user
+	SetPublicKey(userID string, publicKey []byte) error
+	// PublicKey returns public key of user or ErrNotFound
+	PublicKey(userID string) ([]byte, error)
+
+	// AppendOutbox queues entry for user, the oldest entries above limit (0 means no limit) are dropped
+	// returns number of dropped entries
+	AppendOutbox(userID string, entry OutboxEntry, limit int) (int, error)
+	// Outbox returns at most limit (0 means no limit) the oldest entries queued for user
+	Outbox(userID string, limit int) ([]OutboxEntry, error)
+	// OutboxLen returns number of entries queued for user
+	OutboxLen(userID string) (int, error)
+	// DeleteOutbox removes entries of user with given IDs
+	DeleteOutbox(userID string, ids []uint64) error
+	// OutboxUsers returns users with queued entries
+	OutboxUsers() ([]string, error)
+
+	// Close releases resources of store
+	Close() error
+}
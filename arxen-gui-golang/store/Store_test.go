@@ -0,0 +1,514 @@
+package store
+
+import (
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"io/ioutil"
+	"main/gql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testStores returns every Store implementation, each one empty, and function removing them
+func testStores(t *testing.T) (map[string]Store, func()) {
+	dir, err := ioutil.TempDir("", "arxen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bolt, err := OpenBoltStore(filepath.Join(dir, "arxen.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"bolt":   bolt,
+	}, func() {
+		bolt.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestStore_Chats(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Chat("1"); err != ErrNotFound {
+				t.Errorf("Chat() error = %v, want %v", err, ErrNotFound)
+			}
+			if err := s.SetParticipants("1", nil); err != ErrNotFound {
+				t.Errorf("SetParticipants() error = %v, want %v", err, ErrNotFound)
+			}
+
+			chat := ChatRecord{ChatID: "1", ChatName: `"name"`, Participants: []string{"a", "b"}, KeyEpoch: 2, Keys: map[uint32][]byte{1: {1}, 2: {2}}}
+			if err := s.SaveChat(chat); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SaveChat(ChatRecord{ChatID: "2"}); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.Chat("1"); err != nil || !reflect.DeepEqual(got, chat) {
+				t.Errorf("Chat() = %v, %v, want %v", got, err, chat)
+			}
+
+			if err := s.SetParticipants("1", []string{"a"}); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.Participants("1"); err != nil || !reflect.DeepEqual(got, []string{"a"}) {
+				t.Errorf("Participants() = %v, %v, want [a]", got, err)
+			}
+
+			chats, err := s.Chats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chats) != 2 || chats[0].ChatID != "1" || chats[1].ChatID != "2" {
+				t.Errorf("Chats() = %v, want chats 1 and 2", chats)
+			}
+		})
+	}
+}
+
+func TestStore_Messages(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.LastMessage("1"); err != ErrNotFound {
+				t.Errorf("LastMessage() error = %v, want %v", err, ErrNotFound)
+			}
+
+			timeStamp := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+			// added out of order, returned ordered by MessageID
+			for _, id := range []string{"b", "a", "c", "a"} {
+				if _, err := s.AddMessage(&gql.TextMessage{MessageID: id, ChatID: "1", User: "u", TimeStamp: timeStamp, Text: id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			added, err := s.AddMessage(&gql.TextMessage{MessageID: "a", ChatID: "1"})
+			if err != nil || added {
+				t.Errorf("AddMessage() of duplicate = %v, %v, want false", added, err)
+			}
+			if _, err := s.AddMessage(&gql.TextMessage{MessageID: "a", ChatID: "2"}); err != nil {
+				t.Fatal(err)
+			}
+
+			messages, err := s.Messages("1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, m := range messages {
+				ids = append(ids, m.MessageID)
+			}
+			if !reflect.DeepEqual(ids, []string{"a", "b", "c"}) {
+				t.Errorf("Messages() ids = %v, want [a b c]", ids)
+			}
+			if messages[0].Text != "a" || !messages[0].TimeStamp.Equal(timeStamp) {
+				t.Errorf("Messages()[0] = %v, content not preserved", messages[0])
+			}
+
+			since, err := s.MessagesSince("1", "a", 0)
+			if err != nil || len(since) != 2 || since[0].MessageID != "b" {
+				t.Errorf("MessagesSince(a) = %v, %v, want [b c]", since, err)
+			}
+			since, err = s.MessagesSince("1", "", 1)
+			if err != nil || len(since) != 1 || since[0].MessageID != "a" {
+				t.Errorf("MessagesSince(\"\", 1) = %v, %v, want [a]", since, err)
+			}
+			since, err = s.MessagesSince("1", "bb", 0)
+			if err != nil || len(since) != 1 || since[0].MessageID != "c" {
+				t.Errorf("MessagesSince(bb) = %v, %v, want [c]", since, err)
+			}
+
+			last, err := s.LastMessage("1")
+			if err != nil || last.MessageID != "c" {
+				t.Errorf("LastMessage() = %v, %v, want c", last, err)
+			}
+		})
+	}
+}
+
+func TestStore_MessagesOrder(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			// reply written with older clock of its author arrives late
+			for _, m := range []struct {
+				id    string
+				clock int
+			}{{"a", 1}, {"c", 2}, {"b", 3}, {"0", 2}} {
+				if _, err := s.AddMessage(&gql.TextMessage{MessageID: m.id, ChatID: "1", Clock: m.clock}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			messages, err := s.Messages("1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, m := range messages {
+				ids = append(ids, m.MessageID)
+			}
+			if !reflect.DeepEqual(ids, []string{"a", "0", "c", "b"}) {
+				t.Errorf("Messages() ids = %v, want [a 0 c b]", ids)
+			}
+			if last, err := s.LastMessage("1"); err != nil || last.MessageID != "b" {
+				t.Errorf("LastMessage() = %v, %v, want b", last, err)
+			}
+			// synchronised by MessageID regardless of clock
+			if since, err := s.MessagesSince("1", "a", 0); err != nil || len(since) != 2 || since[0].MessageID != "b" {
+				t.Errorf("MessagesSince(a) = %v, %v, want [b c]", since, err)
+			}
+		})
+	}
+}
+
+func TestStore_MessagesPage(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			// ordered a 0 c b d e
+			for _, m := range []struct {
+				id    string
+				clock int
+			}{{"a", 1}, {"c", 2}, {"b", 3}, {"0", 2}, {"d", 4}, {"e", 5}} {
+				if _, err := s.AddMessage(&gql.TextMessage{MessageID: m.id, ChatID: "1", Clock: m.clock}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tests := []struct {
+				name          string
+				after, before string
+				limit         int
+				newest        bool
+				want          []string
+				older, newer  bool
+			}{
+				{"the newest", "", "", 2, true, []string{"d", "e"}, true, false},
+				{"the oldest", "", "", 2, false, []string{"a", "0"}, false, true},
+				{"all", "", "", 0, true, []string{"a", "0", "c", "b", "d", "e"}, false, false},
+				{"before message", "", "d", 2, true, []string{"c", "b"}, true, true},
+				{"after message", "0", "", 2, false, []string{"c", "b"}, true, true},
+				{"between messages", "a", "b", 5, true, []string{"0", "c"}, true, true},
+				{"before the first", "", "a", 2, true, []string{}, false, true},
+			}
+			for _, tt := range tests {
+				page, err := s.MessagesPage("1", tt.after, tt.before, tt.limit, tt.newest)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := []string{}
+				for _, m := range page.Messages {
+					ids = append(ids, m.MessageID)
+				}
+				if !reflect.DeepEqual(ids, tt.want) || page.Older != tt.older || page.Newer != tt.newer {
+					t.Errorf("%s: MessagesPage() = %v, older %v, newer %v, want %v, %v, %v",
+						tt.name, ids, page.Older, page.Newer, tt.want, tt.older, tt.newer)
+				}
+			}
+
+			if _, err := s.MessagesPage("1", "x", "", 2, true); !errors.Is(err, ErrNotFound) {
+				t.Errorf("MessagesPage() after unknown message error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestStore_Friends(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			nick := "nick"
+			for _, id := range []string{"b", "a", "b"} {
+				if err := s.SaveFriend(&gql.Friend{UserID: id, Nick: &nick}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			friends, err := s.Friends()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(friends) != 2 || friends[0].UserID != "a" || friends[1].UserID != "b" || *friends[0].Nick != nick {
+				t.Errorf("Friends() = %v, want friends a and b", friends)
+			}
+
+			if friend, err := s.Friend("a"); err != nil || friend.UserID != "a" {
+				t.Errorf("Friend(a) = %v, %v, want friend a", friend, err)
+			}
+			if err := s.DeleteFriend("a"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.DeleteFriend("c"); err != nil {
+				t.Errorf("DeleteFriend() of missing friend error = %v", err)
+			}
+			if _, err := s.Friend("a"); err != ErrNotFound {
+				t.Errorf("Friend() of deleted friend error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestStore_Settings(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Setting("nick"); err != ErrNotFound {
+				t.Errorf("Setting() of missing key error = %v, want %v", err, ErrNotFound)
+			}
+			for _, value := range []string{"first", "second"} {
+				if err := s.SetSetting("nick", value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if value, err := s.Setting("nick"); err != nil || value != "second" {
+				t.Errorf("Setting() = %q, %v, want second", value, err)
+			}
+		})
+	}
+}
+
+func TestStore_Addresses(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, addr := range [][2]string{{"a", "tcp://10.5.0.1:7878"}, {"b", "tcp://10.5.0.2:7878"}, {"a", "tcp://10.5.0.3:7878"}} {
+				if err := s.SetAddress(addr[0], addr[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want := map[string]string{"a": "tcp://10.5.0.3:7878", "b": "tcp://10.5.0.2:7878"}
+			if got, err := s.Addresses(); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("Addresses() = %v, %v, want %v", got, err, want)
+			}
+		})
+	}
+}
+
+func TestStore_PublicKeys(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.PublicKey("a"); err != ErrNotFound {
+				t.Errorf("PublicKey() error = %v, want %v", err, ErrNotFound)
+			}
+			if err := s.SetPublicKey("a", []byte{1, 2}); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.PublicKey("a"); err != nil || !reflect.DeepEqual(got, []byte{1, 2}) {
+				t.Errorf("PublicKey() = %v, %v, want [1 2]", got, err)
+			}
+		})
+	}
+}
+
+func TestBoltStore_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arxen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "arxen.db")
+	s, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveChat(ChatRecord{ChatID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddMessage(&gql.TextMessage{MessageID: "a", ChatID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.Chat("1"); err != nil {
+		t.Errorf("Chat() after reopen error = %v", err)
+	}
+	if messages, err := s.Messages("1"); err != nil || len(messages) != 1 {
+		t.Errorf("Messages() after reopen = %v, %v, want 1 message", messages, err)
+	}
+}
+
+// chats saved before messages were ordered by index get it when store opens
+func TestBoltStore_IndexMessages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arxen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "arxen.db")
+	s, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*gql.TextMessage{
+		{MessageID: "a", ChatID: "1", Clock: 10},
+		{MessageID: "b", ChatID: "1", Clock: 2},
+		{MessageID: "c", ChatID: "1", Clock: 256},
+	} {
+		if _, err := s.AddMessage(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(orderBucket).DeleteBucket([]byte("1"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	messages, err := s.Messages("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, m := range messages {
+		ids = append(ids, m.MessageID)
+	}
+	if !reflect.DeepEqual(ids, []string{"b", "a", "c"}) {
+		t.Errorf("Messages() after reopen = %v, want [b a c]", ids)
+	}
+	if last, err := s.LastMessage("1"); err != nil || last.MessageID != "c" {
+		t.Errorf("LastMessage() after reopen = %v, %v, want c", last, err)
+	}
+}
+
+func TestStore_Outbox(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			queued := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+			dropped := 0
+			for _, data := range []string{"a", "b", "c", "d"} {
+				n, err := s.AppendOutbox("u", OutboxEntry{Data: []byte(data), Queued: queued}, 3)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dropped += n
+			}
+			if dropped != 1 {
+				t.Errorf("AppendOutbox() over limit dropped %d entries, want 1", dropped)
+			}
+			if _, err := s.AppendOutbox("v", OutboxEntry{Data: []byte("x")}, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			// the oldest dropped, the rest in order
+			entries, err := s.Outbox("u", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data []string
+			for _, entry := range entries {
+				data = append(data, string(entry.Data))
+			}
+			if !reflect.DeepEqual(data, []string{"b", "c", "d"}) || !entries[0].Queued.Equal(queued) {
+				t.Errorf("Outbox() = %v, want entries b c d", entries)
+			}
+			if n, err := s.OutboxLen("u"); err != nil || n != 3 {
+				t.Errorf("OutboxLen() = %d, %v, want 3", n, err)
+			}
+			if first, err := s.Outbox("u", 1); err != nil || len(first) != 1 || first[0].ID != entries[0].ID {
+				t.Errorf("Outbox(1) = %v, %v, want the oldest entry", first, err)
+			}
+
+			if err := s.DeleteOutbox("u", []uint64{entries[0].ID, entries[1].ID}); err != nil {
+				t.Fatal(err)
+			}
+			if left, err := s.Outbox("u", 0); err != nil || len(left) != 1 || string(left[0].Data) != "d" {
+				t.Errorf("Outbox() after delete = %v, %v, want d", left, err)
+			}
+			if users, err := s.OutboxUsers(); err != nil || !reflect.DeepEqual(users, []string{"u", "v"}) {
+				t.Errorf("OutboxUsers() = %v, %v, want [u v]", users, err)
+			}
+			if err := s.DeleteOutbox("u", []uint64{entries[2].ID}); err != nil {
+				t.Fatal(err)
+			}
+			if users, err := s.OutboxUsers(); err != nil || !reflect.DeepEqual(users, []string{"v"}) {
+				t.Errorf("OutboxUsers() after emptying outbox = %v, %v, want [v]", users, err)
+			}
+		})
+	}
+}
+
+func TestStore_Receipts(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if got, err := s.Receipts("1", "a"); err != nil || len(got) != 0 {
+				t.Errorf("Receipts() of message without receipts = %v, %v, want none", got, err)
+			}
+
+			steps := []struct {
+				userID string
+				status ReceiptStatus
+				want   bool
+			}{
+				{"u", RECEIPT_DELIVERED, true},
+				{"u", RECEIPT_DELIVERED, false},
+				{"v", RECEIPT_READ, true},
+				// read implies delivered
+				{"v", RECEIPT_DELIVERED, false},
+				{"u", RECEIPT_READ, true},
+			}
+			for _, step := range steps {
+				if changed, err := s.SetReceipt("1", "a", step.userID, step.status); err != nil || changed != step.want {
+					t.Errorf("SetReceipt(%s, %s) = %v, %v, want %v", step.userID, step.status, changed, err, step.want)
+				}
+			}
+			if _, err := s.SetReceipt("1", "b", "u", RECEIPT_DELIVERED); err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]ReceiptStatus{"u": RECEIPT_READ, "v": RECEIPT_READ}
+			if got, err := s.Receipts("1", "a"); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("Receipts() = %v, %v, want %v", got, err, want)
+			}
+		})
+	}
+}